@@ -17,6 +17,8 @@ import (
 
 var templateType string
 
+var rawValues bool
+
 var templatePath string
 var fieldsDefinitionPath string
 
@@ -61,6 +63,8 @@ func GenerateWithTemplateCmd() *cobra.Command {
 				return err
 			}
 
+			fc = fc.WithRawValues(rawValues)
+
 			timeNow, err := getTimeNowFromFlag(timeNowAsString)
 			if err != nil {
 				return err
@@ -79,6 +83,7 @@ func GenerateWithTemplateCmd() *cobra.Command {
 
 	generateWithTemplateCmd.Flags().StringVarP(&configFile, "config-file", "c", "", "path to config file for generator settings")
 	generateWithTemplateCmd.Flags().StringVarP(&templateType, "template-type", "y", "placeholder", "either 'placeholder' or 'gotext'")
+	generateWithTemplateCmd.Flags().BoolVarP(&rawValues, "raw-values", "", false, "do not JSON escape the values in 'placeholder' templates, for templates not generating JSON")
 	generateWithTemplateCmd.Flags().Uint64VarP(&totEvents, "tot-events", "t", 1, "total events of the corpus to generate")
 	generateWithTemplateCmd.Flags().StringVarP(&timeNowAsString, "now", "n", "", "time to use for generation based on now (`date` type)")
 	generateWithTemplateCmd.Flags().Int64VarP(&randSeed, "seed", "s", 1, "seed to set as source of rand")
@@ -86,6 +86,9 @@ func TemplateCmd() *cobra.Command {
 				return err
 			}
 
+			// Schema A templates are the raw events collected by the Elastic Agent, not JSON documents
+			fc = fc.WithRawValues(flagSchema == "a")
+
 			timeNow, err := getTimeNowFromFlag(timeNowAsString)
 			if err != nil {
 				return err
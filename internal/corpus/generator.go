@@ -77,10 +77,19 @@ type GeneratorCorpus struct {
 	fs           afero.Fs
 	location     string
 	templateType int
+	// rawValues disables the JSON escaping of values in placeholder templates
+	rawValues bool
 	// timestamp allow overriding value in tests
 	timestamp timestamp
 }
 
+// WithRawValues returns a copy of the GeneratorCorpus emitting the values of placeholder templates
+// without JSON escaping, for templates not generating JSON, like Schema A log lines.
+func (gc GeneratorCorpus) WithRawValues(rawValues bool) GeneratorCorpus {
+	gc.rawValues = rawValues
+	return gc
+}
+
 func (gc GeneratorCorpus) Location() string {
 	return gc.location
 }
@@ -124,6 +133,10 @@ func (gc GeneratorCorpus) eventsPayloadFromFields(template []byte, fields Fields
 		return ErrNotValidTemplate
 	}
 
+	if gc.rawValues {
+		opts = append(opts, genlib.WithRawValues())
+	}
+
 	evgen, err := genlib.NewGenerator(gc.config, fields, totEvents, opts...)
 	if err != nil {
 		return err
@@ -0,0 +1,79 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+import (
+	"math/rand"
+)
+
+// aliasTable allows sampling indexes from a discrete weighted distribution in O(1),
+// built with Vose's alias method.
+type aliasTable struct {
+	prob  []float64
+	alias []int
+}
+
+func newAliasTable(weights []float64) aliasTable {
+	n := len(weights)
+	table := aliasTable{
+		prob:  make([]float64, n),
+		alias: make([]int, n),
+	}
+
+	var total float64
+	for _, w := range weights {
+		total += w
+	}
+
+	// scale the weights so that their average is 1
+	scaled := make([]float64, n)
+	small := make([]int, 0, n)
+	large := make([]int, 0, n)
+	for i, w := range weights {
+		scaled[i] = w * float64(n) / total
+		if scaled[i] < 1 {
+			small = append(small, i)
+		} else {
+			large = append(large, i)
+		}
+	}
+
+	for len(small) > 0 && len(large) > 0 {
+		s := small[len(small)-1]
+		small = small[:len(small)-1]
+		l := large[len(large)-1]
+		large = large[:len(large)-1]
+
+		table.prob[s] = scaled[s]
+		table.alias[s] = l
+
+		scaled[l] = scaled[l] + scaled[s] - 1
+		if scaled[l] < 1 {
+			small = append(small, l)
+		} else {
+			large = append(large, l)
+		}
+	}
+
+	// leftovers are due to floating point rounding, they must be always picked
+	for _, i := range large {
+		table.prob[i] = 1
+	}
+
+	for _, i := range small {
+		table.prob[i] = 1
+	}
+
+	return table
+}
+
+func (t aliasTable) sample(r *rand.Rand) int {
+	i := r.Intn(len(t.prob))
+	if r.Float64() < t.prob[i] {
+		return i
+	}
+
+	return t.alias[i]
+}
@@ -0,0 +1,164 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// arrayUniqueTries is the number of values generated, at most, looking for one not already in the array
+const arrayUniqueTries = 11
+
+// arrayValue is a value generated for a field with the `array` config, printed as a JSON array
+type arrayValue []any
+
+func (v arrayValue) MarshalJSON() ([]byte, error) {
+	elements := make([]any, 0, len(v))
+	for _, element := range v {
+		// dates are formatted as the values of `date` fields
+		if t, ok := element.(time.Time); ok {
+			element = t.Format(FieldTypeTimeLayout)
+		}
+
+		elements = append(elements, element)
+	}
+
+	return json.Marshal(elements)
+}
+
+func (v arrayValue) String() string {
+	b, _ := v.MarshalJSON()
+	return string(b)
+}
+
+// arrayLengthFunc returns a function picking the length of each array
+func arrayLengthFunc(fieldCfg ConfigField) (func(state *genState) int, error) {
+	if err := fieldCfg.Array.Valid(); err != nil {
+		return nil, err
+	}
+
+	minLength, maxLength := fieldCfg.Array.LengthOrDefault()
+
+	return func(state *genState) int {
+		return minLength + state.rand.Intn(maxLength-minLength+1)
+	}, nil
+}
+
+// bindArray wraps the bound function of the field, so that it emits a JSON array of its values.
+// When the elements must be unique, arrays can be shorter than their minimum length if not enough
+// different values are generated.
+func bindArray(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	lengthFunc, err := arrayLengthFunc(fieldCfg)
+	if err != nil {
+		return err
+	}
+
+	boundF, ok := fieldMap[field.Name].(emitFNotReturn)
+	if !ok {
+		return fmt.Errorf("cannot bind field %s as array", field.Name)
+	}
+
+	elementWrap := fieldElementWrapByConfig(fieldCfg, field)
+
+	var emitFNotReturn emitFNotReturn
+	emitFNotReturn = func(state *genState, buf *bytes.Buffer) error {
+		length := lengthFunc(state)
+		elements := make(map[string]struct{}, length)
+
+		buf.WriteByte('[')
+		start := buf.Len()
+		for i := 0; i < length; i++ {
+			var element bytes.Buffer
+			for try := 0; try < arrayUniqueTries; try++ {
+				element.Reset()
+				if err := boundF(state, &element); err != nil {
+					return err
+				}
+
+				if _, ok := elements[element.String()]; !fieldCfg.Array.Unique || !ok {
+					break
+				}
+			}
+
+			if _, ok := elements[element.String()]; fieldCfg.Array.Unique && ok {
+				continue
+			}
+
+			if buf.Len() > start {
+				buf.WriteByte(',')
+			}
+
+			elements[element.String()] = struct{}{}
+
+			// arrays are JSON whatever the template is: quoted elements are always escaped
+			buf.WriteString(elementWrap)
+			if elementWrap == "\"" {
+				writeJSONEscaped(buf, element.Bytes())
+			} else {
+				buf.Write(element.Bytes())
+			}
+			buf.WriteString(elementWrap)
+		}
+
+		buf.WriteByte(']')
+		return nil
+	}
+
+	fieldMap[field.Name] = emitFNotReturn
+	return nil
+}
+
+// bindArrayWithReturn wraps the bound function of the field, so that it returns an arrayValue of its values.
+// When the elements must be unique, arrays can be shorter than their minimum length if not enough
+// different values are generated.
+func bindArrayWithReturn(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	lengthFunc, err := arrayLengthFunc(fieldCfg)
+	if err != nil {
+		return err
+	}
+
+	boundF, ok := fieldMap[field.Name].(emitF)
+	if !ok {
+		return fmt.Errorf("cannot bind field %s as array", field.Name)
+	}
+
+	var emitF emitF
+	emitF = func(state *genState) any {
+		length := lengthFunc(state)
+		elements := make(map[string]struct{}, length)
+
+		value := make(arrayValue, 0, length)
+		for i := 0; i < length; i++ {
+			var element any
+			for try := 0; try < arrayUniqueTries; try++ {
+				element = boundF(state)
+
+				// dates are formatted as the values of the field, see appendDate
+				if t, ok := element.(time.Time); ok && (len(fieldCfg.Format) > 0 || field.Type == FieldTypeDateNanos) {
+					element = dateValue(fieldCfg, field, t)
+				}
+
+				if _, ok := elements[fieldValueKey(element)]; !fieldCfg.Array.Unique || !ok {
+					break
+				}
+			}
+
+			if _, ok := elements[fieldValueKey(element)]; fieldCfg.Array.Unique && ok {
+				continue
+			}
+
+			elements[fieldValueKey(element)] = struct{}{}
+			value = append(value, element)
+		}
+
+		return value
+	}
+
+	fieldMap[field.Name] = emitF
+	return nil
+}
@@ -0,0 +1,288 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+import (
+	"math"
+	"time"
+
+	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib/config"
+)
+
+// arrivalMeanInterval is the mean interval between events when their number or the time window is unknown,
+// the same as the one of the dates generated without `arrival`
+const arrivalMeanInterval = FieldTypeDurationSpan * time.Millisecond / 2
+
+// arrivalLoad is the load of events over time, constant within each hour, normalised so that its mean over a week is 1
+type arrivalLoad struct {
+	hours    []float64
+	weekdays []float64
+}
+
+func newArrivalLoad(arrivalCfg config.Arrival) arrivalLoad {
+	return arrivalLoad{
+		hours:    normaliseLoad(arrivalCfg.HoursOrDefault()),
+		weekdays: normaliseLoad(arrivalCfg.WeekdaysOrDefault()),
+	}
+}
+
+// normaliseLoad returns a copy of the load divided by its mean, or nil if the load is not defined
+func normaliseLoad(load []float64) []float64 {
+	if len(load) == 0 {
+		return nil
+	}
+
+	var total float64
+	for _, v := range load {
+		total += v
+	}
+
+	normalised := make([]float64, len(load))
+	for i, v := range load {
+		normalised[i] = v * float64(len(load)) / total
+	}
+
+	return normalised
+}
+
+// at returns the load at t, and the end of the hour the load is constant in
+func (l arrivalLoad) at(t time.Time) (float64, time.Time) {
+	load := 1.0
+	if l.hours != nil {
+		load *= l.hours[t.Hour()]
+	}
+
+	if l.weekdays != nil {
+		load *= l.weekdays[t.Weekday()]
+	}
+
+	hourEnd := time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+
+	return load, hourEnd
+}
+
+// advance returns the time after t where the load accumulated since t is amount, expressed in nanoseconds at load 1.
+// The returned time is never after end, unless end is zero.
+func (l arrivalLoad) advance(t time.Time, amount float64, end time.Time) time.Time {
+	for amount > 0 {
+		if !end.IsZero() && !t.Before(end) {
+			return end
+		}
+
+		load, hourEnd := l.at(t)
+		if !end.IsZero() && hourEnd.After(end) {
+			hourEnd = end
+		}
+
+		span := float64(hourEnd.Sub(t))
+		if load*span >= amount {
+			return t.Add(time.Duration(amount / load))
+		}
+
+		amount -= load * span
+		t = hourEnd
+	}
+
+	return t
+}
+
+// between returns the load accumulated between from and to, expressed in nanoseconds at load 1
+func (l arrivalLoad) between(from, to time.Time) float64 {
+	var amount float64
+	for from.Before(to) {
+		load, hourEnd := l.at(from)
+		if hourEnd.After(to) {
+			hourEnd = to
+		}
+
+		amount += load * float64(hourEnd.Sub(from))
+		from = hourEnd
+	}
+
+	return amount
+}
+
+// arrivalState is the state of the arrival times of the events for a date field with `arrival`
+type arrivalState struct {
+	bounded    bool
+	start, end time.Time
+	total      float64
+	// arrival time of the last event, and the load accumulated until then as a fraction of the total
+	last     time.Time
+	lastLoad float64
+	// counter of the next event to generate
+	nextCounter uint64
+	// events left in the current burst
+	burstLeft int
+}
+
+// arrivalWindow returns the time window of the values of the date field, as nearTime does, if the field defines
+// one with `range` or `period`
+func arrivalWindow(fieldCfg ConfigField, state *genState) (time.Time, time.Time, bool) {
+	from, errFrom := fieldCfg.Range.FromAsTime()
+	to, errTo := fieldCfg.Range.ToAsTime()
+
+	switch {
+	case errFrom == nil && errTo == nil:
+		return from, to, true
+	case errFrom == nil:
+		return minTime(from, state.originTime), maxTime(from, state.originTime), true
+	case errTo == nil:
+		return minTime(to, state.originTime), maxTime(to, state.originTime), true
+	case fieldCfg.Period > 0:
+		return state.originTime, state.originTime.Add(fieldCfg.Period), true
+	case fieldCfg.Period < 0:
+		return state.originTime.Add(fieldCfg.Period), state.originTime, true
+	}
+
+	return state.originTime, time.Time{}, false
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+
+	return b
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+
+	return b
+}
+
+// makeArrivalFunc returns a function generating the values of date fields with `arrival`, the arrival time
+// of the current event. When the number of events and the time window are known, the events are spread over
+// the window according to the load, and the last one arrives within the window: with the `poisson` process
+// the arrival times are the sorted values of as many random times, drawn one after the other, while with
+// the `uniform` process they are evenly spaced. Otherwise, the intervals between events are random, with
+// a mean of half a second at load 1, and never pass the end of the window, if any.
+func makeArrivalFunc(fieldCfg ConfigField) (dateFunc, error) {
+	if err := fieldCfg.Arrival.Valid(); err != nil {
+		return nil, err
+	}
+
+	load := newArrivalLoad(*fieldCfg.Arrival)
+	poisson := fieldCfg.Arrival.ProcessOrDefault() == config.ArrivalProcessPoisson
+
+	var bursts config.Bursts
+	if fieldCfg.Arrival.Bursts != nil {
+		bursts = *fieldCfg.Arrival.Bursts
+	}
+
+	minBurstSize, maxBurstSize := bursts.SizeOrDefault()
+	burstFactor := bursts.FactorOrDefault()
+
+	// arrive moves the last arrival time to the one of the event with counter k
+	arrive := func(state *genState, arrival *arrivalState, k uint64) {
+		if k == 0 && (!arrival.bounded || !poisson) {
+			// the first event arrives at the start
+			arrival.last = arrival.start
+			return
+		}
+
+		if arrival.burstLeft == 0 && bursts.Probability > 0 && state.rand.Float64() < bursts.Probability {
+			arrival.burstLeft = minBurstSize + state.rand.Intn(maxBurstSize-minBurstSize+1)
+		}
+
+		inBurst := arrival.burstLeft > 0
+		if inBurst {
+			arrival.burstLeft--
+		}
+
+		if !arrival.bounded {
+			interval := float64(arrivalMeanInterval)
+			if poisson {
+				interval *= state.rand.ExpFloat64()
+			} else {
+				interval *= 2 * state.rand.Float64()
+			}
+
+			if inBurst {
+				interval /= burstFactor
+			}
+
+			arrival.last = load.advance(arrival.last, interval, arrival.end)
+			return
+		}
+
+		// the events left to spread over the rest of the window, including this one
+		left := 1.0
+		if state.totEvents > k {
+			left = float64(state.totEvents - k)
+		}
+
+		if !poisson {
+			left++
+		}
+
+		var nextLoad float64
+		switch {
+		case inBurst:
+			// the mean interval between the events left, shortened by the burst factor
+			interval := (1 - arrival.lastLoad) / left / burstFactor
+			if poisson {
+				interval *= state.rand.ExpFloat64()
+			}
+
+			nextLoad = math.Min(arrival.lastLoad+interval, 1)
+		case poisson:
+			// the smallest of as many random values between the last one and 1 as the events left
+			nextLoad = 1 - (1-arrival.lastLoad)*math.Pow(state.rand.Float64(), 1/left)
+		default:
+			nextLoad = arrival.lastLoad + (1-arrival.lastLoad)/left
+		}
+
+		arrival.last = load.advance(arrival.last, (nextLoad-arrival.lastLoad)*arrival.total, arrival.end)
+		arrival.lastLoad = nextLoad
+	}
+
+	return func(state *genState) (time.Time, error) {
+		arrival, ok := state.arrivals[fieldCfg.Name]
+		if !ok {
+			arrival = &arrivalState{}
+			arrival.start, arrival.end, arrival.bounded = arrivalWindow(fieldCfg, state)
+			arrival.bounded = arrival.bounded && state.totEvents > 0
+			if arrival.bounded {
+				arrival.total = load.between(arrival.start, arrival.end)
+			}
+
+			arrival.last = arrival.start
+			state.arrivals[fieldCfg.Name] = arrival
+		}
+
+		// the events before the current one not generating the field, like the ones missing it, have arrived as well
+		for ; arrival.nextCounter <= state.counter; arrival.nextCounter++ {
+			arrive(state, arrival, arrival.nextCounter)
+		}
+
+		return arrival.last, nil
+	}, nil
+}
+
+// makeOutOfOrderFunc wraps the function generating the values of a date field, so that they are moved
+// with the probability of `out_of_order` by a random offset between the maximum lateness in the past and
+// the maximum earliness in the future. The values generated by timeFunc are not affected, so the next ones
+// are still in order: only the moved ones are out of order.
+func makeOutOfOrderFunc(outOfOrderCfg config.OutOfOrder, timeFunc dateFunc) (dateFunc, error) {
+	if err := outOfOrderCfg.Valid(); err != nil {
+		return nil, err
+	}
+
+	maxLateness := outOfOrderCfg.MaxLatenessOrDefault()
+	span := int64(maxLateness + outOfOrderCfg.MaxEarliness)
+
+	return func(state *genState) (time.Time, error) {
+		t, err := timeFunc(state)
+		if err != nil || state.rand.Float64() >= outOfOrderCfg.Probability {
+			return t, err
+		}
+
+		return t.Add(time.Duration(state.rand.Int63n(span+1)) - maxLateness), nil
+	}, nil
+}
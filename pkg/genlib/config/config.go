@@ -2,9 +2,12 @@ package config
 
 import (
 	"errors"
+	"fmt"
+	"regexp/syntax"
 	"time"
 
 	"math"
+	"net/netip"
 	"os"
 
 	"github.com/elastic/go-ucfg/yaml"
@@ -15,6 +18,19 @@ var rangeBoundNotSet = errors.New("range bound not set")
 var rangeTimeNotSet = errors.New("range time not set")
 var rangeInvalidConfig = errors.New("range defining both `period` and `from`/`to`")
 var counterInvalidConfig = errors.New("both `range` and `counter` defined")
+var weightedEnumInvalidConfig = errors.New("both `enum` and `weighted_enum` defined")
+var weightedEnumInvalidWeight = errors.New("weighted_enum weight must be greater than zero")
+var distributionInvalidConfig = errors.New("both `distribution` and `counter` defined")
+var shapeInvalidConfig = errors.New("`shape` defined together with `counter` or `distribution`")
+var patternInvalidConfig = errors.New("`pattern` defined together with `enum` or `weighted_enum`")
+var fakerInvalidConfig = errors.New("`faker` defined together with `enum`, `weighted_enum`, `pattern`, `counter`, `distribution` or `shape`")
+var entityInvalidConfig = errors.New("`entity` defined together with `cardinality`, `counter` or `derive`")
+var versionInvalidConfig = errors.New("`version` defined together with `enum` or `weighted_enum`")
+var samplesInvalidConfig = errors.New("`histogram` and `aggregate_metric_double` fields cannot define `counter` or `shape`")
+var dateFormatInvalidConfig = errors.New("`format` is not one of 'epoch_millis', 'epoch_second', 'rfc3164', 'common_log' nor a Go time layout")
+var dateCounterInvalidConfig = errors.New("date `counter` requires a positive `period` and cannot define `range.to`, `arrival` or `relative_to`")
+var relativeToInvalidConfig = errors.New("`relative_to` defined together with `period`, `range` or `arrival`")
+var deriveInvalidConfig = errors.New("`derive` defined together with `value`, `enum`, `weighted_enum`, `counter`, `distribution`, `shape` or `cardinality`")
 
 type TimeRange struct {
 	time.Time
@@ -35,20 +51,857 @@ type Range struct {
 }
 
 type Config struct {
-	m map[string]ConfigField
+	m        map[string]ConfigField
+	entities map[string]Entity
+}
+
+// IP restricts the addresses generated for an `ip` field
+type IP struct {
+	CIDRs     []string `config:"cidrs"`
+	Private   bool     `config:"private"`
+	IPv6Ratio *float64 `config:"ipv6_ratio"`
+}
+
+// IPv6RatioOrDefault returns the ratio of ipv6 addresses to generate. When not set, addresses are generated
+// from all the configured cidrs with the same probability, or are all ipv4 if no cidrs are configured
+func (ip IP) IPv6RatioOrDefault() float64 {
+	if ip.IPv6Ratio != nil {
+		return *ip.IPv6Ratio
+	}
+
+	if len(ip.CIDRs) == 0 {
+		return 0
+	}
+
+	var ipv6CIDRs int
+	for _, cidr := range ip.CIDRs {
+		if prefix, err := netip.ParsePrefix(cidr); err == nil && prefix.Addr().Is6() {
+			ipv6CIDRs++
+		}
+	}
+
+	return float64(ipv6CIDRs) / float64(len(ip.CIDRs))
+}
+
+func (ip IP) Valid() error {
+	if ip.Private && len(ip.CIDRs) > 0 {
+		return errors.New("ip 'private' and 'cidrs' cannot be both defined")
+	}
+
+	if ip.IPv6Ratio != nil && (*ip.IPv6Ratio < 0 || *ip.IPv6Ratio > 1) {
+		return errors.New("ip 'ipv6_ratio' value must be between 0 and 1")
+	}
+
+	var hasIPv4, hasIPv6 bool
+	for _, cidr := range ip.CIDRs {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return fmt.Errorf("invalid ip cidr: %w", err)
+		}
+
+		if prefix.Addr().Is4() {
+			hasIPv4 = true
+		} else {
+			hasIPv6 = true
+		}
+	}
+
+	if len(ip.CIDRs) > 0 && ip.IPv6Ratio != nil {
+		if *ip.IPv6Ratio > 0 && !hasIPv6 {
+			return errors.New("ip 'ipv6_ratio' greater than 0 requires at least an ipv6 cidr")
+		}
+
+		if *ip.IPv6Ratio < 1 && !hasIPv4 {
+			return errors.New("ip 'ipv6_ratio' less than 1 requires at least an ipv4 cidr")
+		}
+	}
+
+	return nil
+}
+
+const (
+	GeoPointFormatString  = "string"
+	GeoPointFormatObject  = "object"
+	GeoPointFormatGeoJSON = "geojson"
+	GeoPointFormatWKT     = "wkt"
+)
+
+// GeoPoint restricts the points generated for a `geo_point` field to regions, and sets their format
+type GeoPoint struct {
+	Format        string        `config:"format"`
+	BoundingBoxes []BoundingBox `config:"bounding_boxes"`
+	Cities        []GeoCity     `config:"cities"`
+	Polygons      [][][]float64 `config:"polygons"`
+}
+
+type BoundingBox struct {
+	MinLat float64 `config:"min_lat"`
+	MaxLat float64 `config:"max_lat"`
+	MinLon float64 `config:"min_lon"`
+	MaxLon float64 `config:"max_lon"`
+}
+
+// GeoCity is a circular region around the centre of a city, either a known one by name or the given coordinates
+type GeoCity struct {
+	Name   string   `config:"name"`
+	Lat    *float64 `config:"lat"`
+	Lon    *float64 `config:"lon"`
+	Radius float64  `config:"radius"`
+}
+
+func (g GeoPoint) FormatOrDefault() string {
+	if len(g.Format) == 0 {
+		return GeoPointFormatString
+	}
+
+	return g.Format
+}
+
+// IsJSON returns true if the points are formatted as JSON objects, instead of strings
+func (g GeoPoint) IsJSON() bool {
+	format := g.FormatOrDefault()
+	return format == GeoPointFormatObject || format == GeoPointFormatGeoJSON
+}
+
+func (g GeoPoint) Valid() error {
+	switch g.FormatOrDefault() {
+	case GeoPointFormatString, GeoPointFormatObject, GeoPointFormatGeoJSON, GeoPointFormatWKT:
+	default:
+		return errors.New("geo_point format must be one of 'string', 'object', 'geojson', 'wkt'")
+	}
+
+	for _, box := range g.BoundingBoxes {
+		if box.MinLat < -90 || box.MaxLat > 90 || box.MinLat > box.MaxLat {
+			return errors.New("geo_point bounding box latitudes must be between -90 and 90, with 'min_lat' less than 'max_lat'")
+		}
+
+		if box.MinLon < -180 || box.MaxLon > 180 || box.MinLon > box.MaxLon {
+			return errors.New("geo_point bounding box longitudes must be between -180 and 180, with 'min_lon' less than 'max_lon'")
+		}
+	}
+
+	for _, city := range g.Cities {
+		if city.Radius <= 0 {
+			return errors.New("geo_point city 'radius' value must be greater than zero")
+		}
+
+		if (city.Lat == nil) != (city.Lon == nil) {
+			return errors.New("geo_point city requires both 'lat' and 'lon' values, or none of them")
+		}
+
+		if city.Lat == nil && len(city.Name) == 0 {
+			return errors.New("geo_point city requires either 'name' or 'lat' and 'lon' values")
+		}
+	}
+
+	for _, polygon := range g.Polygons {
+		if len(polygon) < 3 {
+			return errors.New("geo_point polygon requires at least 3 points")
+		}
+
+		for _, point := range polygon {
+			if len(point) != 2 {
+				return errors.New("geo_point polygon points must be [lon, lat] pairs")
+			}
+
+			if point[0] < -180 || point[0] > 180 || point[1] < -90 || point[1] > 90 {
+				return errors.New("geo_point polygon points must have longitudes between -180 and 180, and latitudes between -90 and 90")
+			}
+		}
+	}
+
+	return nil
+}
+
+const (
+	TextStyleWords    = "words"
+	TextStyleSentence = "sentence"
+	TextStyleLorem    = "lorem"
+	TextStyleLog      = "log"
+
+	DefaultTextMinWords = 5
+	DefaultTextMaxWords = 15
+)
+
+// Text sets how the values of `text`, `match_only_text` and `wildcard` fields are generated
+type Text struct {
+	Style        string `config:"style"`
+	MinWords     int    `config:"min_words"`
+	MaxWords     int    `config:"max_words"`
+	MinSentences int    `config:"min_sentences"`
+	MaxSentences int    `config:"max_sentences"`
+	MinLength    int    `config:"min_length"`
+	MaxLength    int    `config:"max_length"`
+}
+
+func (t Text) StyleOrDefault() string {
+	if len(t.Style) == 0 {
+		return TextStyleSentence
+	}
+
+	return t.Style
+}
+
+// WordsOrDefault returns the minimum and maximum number of words of each sentence, or of the value for the `words` style
+func (t Text) WordsOrDefault() (int, int) {
+	minWords, maxWords := t.MinWords, t.MaxWords
+	if minWords == 0 && maxWords == 0 {
+		return DefaultTextMinWords, DefaultTextMaxWords
+	}
+
+	if maxWords == 0 {
+		maxWords = minWords
+	}
+
+	return max(minWords, 1), maxWords
+}
+
+// SentencesOrDefault returns the minimum and maximum number of sentences of the value
+func (t Text) SentencesOrDefault() (int, int) {
+	minSentences, maxSentences := t.MinSentences, t.MaxSentences
+	if maxSentences == 0 {
+		maxSentences = max(minSentences, 1)
+	}
+
+	return max(minSentences, 1), maxSentences
+}
+
+func (t Text) Valid() error {
+	switch t.StyleOrDefault() {
+	case TextStyleWords, TextStyleSentence, TextStyleLorem, TextStyleLog:
+	default:
+		return errors.New("text style must be one of 'words', 'sentence', 'lorem', 'log'")
+	}
+
+	if t.MinWords < 0 || t.MaxWords < 0 || t.MinSentences < 0 || t.MaxSentences < 0 || t.MinLength < 0 || t.MaxLength < 0 {
+		return errors.New("text words, sentences and length values must be greater than or equal to zero")
+	}
+
+	if minWords, maxWords := t.WordsOrDefault(); minWords > maxWords {
+		return errors.New("text 'min_words' value must be less than or equal to 'max_words'")
+	}
+
+	if minSentences, maxSentences := t.SentencesOrDefault(); minSentences > maxSentences {
+		return errors.New("text 'min_sentences' value must be less than or equal to 'max_sentences'")
+	}
+
+	if t.MaxLength > 0 && t.MinLength > t.MaxLength {
+		return errors.New("text 'min_length' value must be less than or equal to 'max_length'")
+	}
+
+	return nil
+}
+
+const (
+	DefaultVersionMaxMajor = 9
+	DefaultVersionMaxMinor = 20
+	DefaultVersionMaxPatch = 30
+)
+
+// VersionPart is the range of values of the major, minor or patch part of the versions generated for a `version` field
+type VersionPart struct {
+	Min *int `config:"min"`
+	Max *int `config:"max"`
+}
+
+// OrDefault returns the range of the part, using defaultMax as maximum if not set and 0 as minimum if not set
+func (p VersionPart) OrDefault(defaultMax int) (int, int) {
+	var minValue int
+	if p.Min != nil {
+		minValue = *p.Min
+	}
+
+	maxValue := max(defaultMax, minValue)
+	if p.Max != nil {
+		maxValue = *p.Max
+	}
+
+	return minValue, maxValue
+}
+
+// Version sets how the semver strings of `version` fields are generated
+type Version struct {
+	Major                 VersionPart `config:"major"`
+	Minor                 VersionPart `config:"minor"`
+	Patch                 VersionPart `config:"patch"`
+	PrereleaseProbability float64     `config:"prerelease_probability"`
+}
+
+func (v Version) Valid() error {
+	parts := []struct {
+		name       string
+		part       VersionPart
+		defaultMax int
+	}{
+		{"major", v.Major, DefaultVersionMaxMajor},
+		{"minor", v.Minor, DefaultVersionMaxMinor},
+		{"patch", v.Patch, DefaultVersionMaxPatch},
+	}
+
+	for _, p := range parts {
+		minValue, maxValue := p.part.OrDefault(p.defaultMax)
+		if minValue < 0 {
+			return fmt.Errorf("version '%s.min' value must be greater than or equal to zero", p.name)
+		}
+
+		if minValue > maxValue {
+			return fmt.Errorf("version '%s.min' value must be less than or equal to '%s.max'", p.name, p.name)
+		}
+	}
+
+	if v.PrereleaseProbability < 0 || v.PrereleaseProbability > 1 {
+		return errors.New("version 'prerelease_probability' value must be between 0 and 1")
+	}
+
+	return nil
+}
+
+const (
+	DefaultSamplesMin = 1
+	DefaultSamplesMax = 100
+	DefaultBuckets    = 10
+)
+
+// Samples is the range of the number of values, drawn from the `distribution` of the field, that are
+// summarised in each value of `histogram` and `aggregate_metric_double` fields
+type Samples struct {
+	Min int `config:"min"`
+	Max int `config:"max"`
+}
+
+// OrDefault returns the minimum and maximum number of samples
+func (s *Samples) OrDefault() (int, int) {
+	if s == nil || (s.Min == 0 && s.Max == 0) {
+		return DefaultSamplesMin, DefaultSamplesMax
+	}
+
+	if s.Max == 0 {
+		return s.Min, s.Min
+	}
+
+	return s.Min, s.Max
+}
+
+const (
+	ArrivalProcessUniform = "uniform"
+	ArrivalProcessPoisson = "poisson"
+
+	ArrivalProfileBusinessHours = "business_hours"
+
+	DefaultBurstMinSize = 5
+	DefaultBurstMaxSize = 50
+	DefaultBurstFactor  = 10
+)
+
+// businessHoursLoad is the load of each hour of the day of the `business_hours` profile, from midnight
+var businessHoursLoad = []float64{
+	0.1, 0.1, 0.1, 0.1, 0.1, 0.1, 0.2, 0.5,
+	1, 1, 1, 1, 0.8, 1, 1, 1,
+	1, 1, 0.5, 0.3, 0.3, 0.2, 0.2, 0.1,
+}
+
+// businessDaysLoad is the load of each day of the week of the `business_hours` profile, from Sunday
+var businessDaysLoad = []float64{0.2, 1, 1, 1, 1, 1, 0.2}
+
+// Arrival sets how the events arrive over time, that is how the values of `date` fields are spaced:
+// the process generating them, the bursts of events, and the load over the hours of the day and the days
+// of the week. The loads are relative to each other, a zero load means no events.
+type Arrival struct {
+	Process  string    `config:"process"`
+	Profile  string    `config:"profile"`
+	Hours    []float64 `config:"hours"`
+	Weekdays []float64 `config:"weekdays"`
+	Bursts   *Bursts   `config:"bursts"`
+}
+
+// Bursts are sequences of events arriving Factor times faster than the others, each event having
+// Probability to start one
+type Bursts struct {
+	Probability float64 `config:"probability"`
+	MinSize     int     `config:"min_size"`
+	MaxSize     int     `config:"max_size"`
+	Factor      float64 `config:"factor"`
+}
+
+func (a Arrival) ProcessOrDefault() string {
+	if len(a.Process) == 0 {
+		return ArrivalProcessPoisson
+	}
+
+	return a.Process
+}
+
+// HoursOrDefault returns the load of each hour of the day, or nil if the load is the same for all of them
+func (a Arrival) HoursOrDefault() []float64 {
+	if len(a.Hours) == 0 && a.Profile == ArrivalProfileBusinessHours {
+		return businessHoursLoad
+	}
+
+	return a.Hours
+}
+
+// WeekdaysOrDefault returns the load of each day of the week from Sunday, or nil if the load is the same for all of them
+func (a Arrival) WeekdaysOrDefault() []float64 {
+	if len(a.Weekdays) == 0 && a.Profile == ArrivalProfileBusinessHours {
+		return businessDaysLoad
+	}
+
+	return a.Weekdays
+}
+
+func (a Arrival) Valid() error {
+	switch a.Process {
+	case "", ArrivalProcessUniform, ArrivalProcessPoisson:
+	default:
+		return fmt.Errorf("arrival 'process' must be one of '%s', '%s'", ArrivalProcessUniform, ArrivalProcessPoisson)
+	}
+
+	if len(a.Profile) > 0 && a.Profile != ArrivalProfileBusinessHours {
+		return fmt.Errorf("arrival 'profile' must be '%s'", ArrivalProfileBusinessHours)
+	}
+
+	if err := validLoad("hours", a.Hours, 24); err != nil {
+		return err
+	}
+
+	if err := validLoad("weekdays", a.Weekdays, 7); err != nil {
+		return err
+	}
+
+	if a.Bursts != nil {
+		return a.Bursts.Valid()
+	}
+
+	return nil
+}
+
+// validLoad checks that the load is either not defined, or defined for each of the size periods, with at
+// least one of them having events
+func validLoad(name string, load []float64, size int) error {
+	if len(load) == 0 {
+		return nil
+	}
+
+	if len(load) != size {
+		return fmt.Errorf("arrival '%s' must have %d values", name, size)
+	}
+
+	var total float64
+	for _, v := range load {
+		if v < 0 {
+			return fmt.Errorf("arrival '%s' values must be greater than or equal to 0", name)
+		}
+
+		total += v
+	}
+
+	if total == 0 {
+		return fmt.Errorf("arrival '%s' must have at least one value greater than 0", name)
+	}
+
+	return nil
+}
+
+// SizeOrDefault returns the minimum and maximum number of events of each burst
+func (b Bursts) SizeOrDefault() (int, int) {
+	if b.MinSize == 0 && b.MaxSize == 0 {
+		return DefaultBurstMinSize, DefaultBurstMaxSize
+	}
+
+	if b.MaxSize == 0 {
+		return b.MinSize, b.MinSize
+	}
+
+	return b.MinSize, b.MaxSize
+}
+
+func (b Bursts) FactorOrDefault() float64 {
+	if b.Factor == 0 {
+		return DefaultBurstFactor
+	}
+
+	return b.Factor
+}
+
+func (b Bursts) Valid() error {
+	if b.Probability < 0 || b.Probability > 1 {
+		return errors.New("bursts 'probability' must be between 0 and 1")
+	}
+
+	if minSize, maxSize := b.SizeOrDefault(); minSize < 1 || minSize > maxSize {
+		return errors.New("bursts 'min_size' must be at least 1 and less than or equal to 'max_size'")
+	}
+
+	if b.FactorOrDefault() < 1 {
+		return errors.New("bursts 'factor' must be greater than or equal to 1")
+	}
+
+	return nil
+}
+
+// DefaultRelativeToUnit is the unit of the durations drawn from the distribution of `relative_to`
+const DefaultRelativeToUnit = time.Second
+
+// RelativeTo generates the values of a `date` field by adding a duration to the value of the date Field
+// in the same event. The duration is drawn from Distribution, in Unit, and clamped between Min and Max,
+// or is uniformly random between Min and Max when no Distribution is defined.
+type RelativeTo struct {
+	Field        string        `config:"field"`
+	Min          time.Duration `config:"min"`
+	Max          time.Duration `config:"max"`
+	Unit         time.Duration `config:"unit"`
+	Distribution *Distribution `config:"distribution"`
+}
+
+func (r RelativeTo) UnitOrDefault() time.Duration {
+	if r.Unit == 0 {
+		return DefaultRelativeToUnit
+	}
+
+	return r.Unit
+}
+
+// MaxOrDefault returns the maximum duration, that is unbounded when only a distribution is defined
+func (r RelativeTo) MaxOrDefault() time.Duration {
+	if r.Max == 0 && r.Distribution != nil {
+		return time.Duration(math.MaxInt64)
+	}
+
+	return r.Max
+}
+
+func (r RelativeTo) Valid() error {
+	if len(r.Field) == 0 {
+		return errors.New("relative_to requires 'field' value to be set")
+	}
+
+	if r.Unit < 0 {
+		return errors.New("relative_to 'unit' must not be negative")
+	}
+
+	if r.Min > r.MaxOrDefault() {
+		return errors.New("relative_to 'min' must be less than or equal to 'max'")
+	}
+
+	if r.Distribution != nil {
+		return r.Distribution.Valid()
+	}
+
+	return nil
+}
+
+// DefaultMaxLateness is the maximum lateness of the out of order dates when neither `max_lateness` nor `max_earliness` are defined
+const DefaultMaxLateness = time.Minute
+
+// OutOfOrder moves the values of `date` fields with Probability backwards in time, as late events, by up to
+// MaxLateness, or forwards, as early events, by up to MaxEarliness
+type OutOfOrder struct {
+	Probability  float64       `config:"probability"`
+	MaxLateness  time.Duration `config:"max_lateness"`
+	MaxEarliness time.Duration `config:"max_earliness"`
+}
+
+func (o OutOfOrder) MaxLatenessOrDefault() time.Duration {
+	if o.MaxLateness == 0 && o.MaxEarliness == 0 {
+		return DefaultMaxLateness
+	}
+
+	return o.MaxLateness
+}
+
+func (o OutOfOrder) Valid() error {
+	if o.Probability < 0 || o.Probability > 1 {
+		return errors.New("out_of_order 'probability' must be between 0 and 1")
+	}
+
+	if o.MaxLateness < 0 || o.MaxEarliness < 0 {
+		return errors.New("out_of_order 'max_lateness' and 'max_earliness' must be greater than or equal to 0")
+	}
+
+	return nil
+}
+
+const (
+	VectorElementTypeFloat = "float"
+	VectorElementTypeByte  = "byte"
+	VectorElementTypeBit   = "bit"
+
+	DefaultVectorDims           = 128
+	DefaultVectorSpread         = 0.1
+	DefaultVectorVocabularySize = 30522
+	DefaultVectorMinTokens      = 10
+	DefaultVectorMaxTokens      = 50
+)
+
+// Vector sets how the values of `dense_vector` and `sparse_vector` fields are generated
+type Vector struct {
+	// dense_vector
+	Dims        int     `config:"dims"`
+	ElementType string  `config:"element_type"`
+	Normalize   bool    `config:"normalize"`
+	Centroids   int     `config:"centroids"`
+	Spread      float64 `config:"spread"`
+	// sparse_vector
+	VocabularySize int `config:"vocabulary_size"`
+	MinTokens      int `config:"min_tokens"`
+	MaxTokens      int `config:"max_tokens"`
+}
+
+func (v Vector) DimsOrDefault() int {
+	if v.Dims == 0 {
+		return DefaultVectorDims
+	}
+
+	return v.Dims
+}
+
+func (v Vector) ElementTypeOrDefault() string {
+	if len(v.ElementType) == 0 {
+		return VectorElementTypeFloat
+	}
+
+	return v.ElementType
+}
+
+func (v Vector) SpreadOrDefault() float64 {
+	if v.Spread == 0 {
+		return DefaultVectorSpread
+	}
+
+	return v.Spread
+}
+
+func (v Vector) VocabularySizeOrDefault() int {
+	if v.VocabularySize == 0 {
+		return DefaultVectorVocabularySize
+	}
+
+	return v.VocabularySize
+}
+
+// TokensOrDefault returns the minimum and maximum number of tokens of the values of `sparse_vector` fields,
+// the defaults being capped to the size of the vocabulary
+func (v Vector) TokensOrDefault() (int, int) {
+	vocabularySize := v.VocabularySizeOrDefault()
+	if v.MinTokens == 0 && v.MaxTokens == 0 {
+		return min(DefaultVectorMinTokens, vocabularySize), min(DefaultVectorMaxTokens, vocabularySize)
+	}
+
+	if v.MaxTokens == 0 {
+		return v.MinTokens, max(v.MinTokens, min(DefaultVectorMaxTokens, vocabularySize))
+	}
+
+	return v.MinTokens, v.MaxTokens
+}
+
+func (v Vector) Valid() error {
+	if v.Dims < 0 {
+		return errors.New("vector 'dims' value must be greater than zero")
+	}
+
+	switch v.ElementTypeOrDefault() {
+	case VectorElementTypeFloat:
+	case VectorElementTypeByte:
+		if v.Normalize {
+			return errors.New("vector 'normalize' is supported only for 'float' element type")
+		}
+	case VectorElementTypeBit:
+		if v.Normalize {
+			return errors.New("vector 'normalize' is supported only for 'float' element type")
+		}
+
+		if v.DimsOrDefault()%8 != 0 {
+			return errors.New("vector 'dims' value must be a multiple of 8 for 'bit' element type")
+		}
+	default:
+		return errors.New("vector element_type must be one of 'float', 'byte', 'bit'")
+	}
+
+	if v.Centroids < 0 {
+		return errors.New("vector 'centroids' value must be greater than or equal to zero")
+	}
+
+	if v.Spread < 0 {
+		return errors.New("vector 'spread' value must be greater than zero")
+	}
+
+	if v.VocabularySize < 0 {
+		return errors.New("vector 'vocabulary_size' value must be greater than zero")
+	}
+
+	minTokens, maxTokens := v.TokensOrDefault()
+	if minTokens < 1 {
+		return errors.New("vector 'min_tokens' value must be greater than zero")
+	}
+
+	if minTokens > maxTokens {
+		return errors.New("vector 'min_tokens' value must be less than or equal to 'max_tokens'")
+	}
+
+	if maxTokens > v.VocabularySizeOrDefault() {
+		return errors.New("vector 'max_tokens' value must be less than or equal to 'vocabulary_size'")
+	}
+
+	return nil
+}
+
+const (
+	DefaultArrayMinLength = 1
+	DefaultArrayMaxLength = 3
+)
+
+// Array makes a field generate arrays of values of its type, with a length between MinLength and MaxLength
+type Array struct {
+	MinLength int  `config:"min_length"`
+	MaxLength int  `config:"max_length"`
+	Unique    bool `config:"unique"`
+}
+
+// LengthOrDefault returns the minimum and maximum length of the arrays
+func (a Array) LengthOrDefault() (int, int) {
+	if a.MinLength == 0 && a.MaxLength == 0 {
+		return DefaultArrayMinLength, DefaultArrayMaxLength
+	}
+
+	if a.MaxLength == 0 {
+		return a.MinLength, a.MinLength
+	}
+
+	return a.MinLength, a.MaxLength
+}
+
+func (a Array) Valid() error {
+	if a.MinLength < 0 || a.MaxLength < 0 {
+		return errors.New("array 'min_length' and 'max_length' values must be greater than or equal to zero")
+	}
+
+	if minLength, maxLength := a.LengthOrDefault(); minLength > maxLength {
+		return errors.New("array 'min_length' value must be less than or equal to 'max_length'")
+	}
+
+	return nil
+}
+
+// Entity is a named pool of Size entities: the fields referencing the same entity in an event
+// always have the values generated for the same entity of the pool.
+type Entity struct {
+	Name string `config:"name"`
+	Size int    `config:"size"`
 }
 
 type ConfigField struct {
-	Name         string        `config:"name"`
-	Fuzziness    float64       `config:"fuzziness"`
-	Range        Range         `config:"range"`
-	Cardinality  int           `config:"cardinality"`
-	Period       time.Duration `config:"period"`
-	Enum         []string      `config:"enum"`
-	ObjectKeys   []string      `config:"object_keys"`
-	Value        any           `config:"value"`
-	Counter      bool          `config:"counter"`
-	CounterReset *CounterReset `config:"counter_reset"`
+	Name               string         `config:"name"`
+	Fuzziness          float64        `config:"fuzziness"`
+	Range              Range          `config:"range"`
+	Cardinality        int            `config:"cardinality"`
+	CardinalityPer     string         `config:"cardinality_per"`
+	Period             time.Duration  `config:"period"`
+	Enum               []string       `config:"enum"`
+	WeightedEnum       []WeightedEnum `config:"weighted_enum"`
+	ObjectKeys         []string       `config:"object_keys"`
+	Value              any            `config:"value"`
+	Counter            bool           `config:"counter"`
+	CounterReset       *CounterReset  `config:"counter_reset"`
+	Distribution       *Distribution  `config:"distribution"`
+	Shape              *Shape         `config:"shape"`
+	MissingProbability float64        `config:"missing_probability"`
+	Derive             string         `config:"derive"`
+	Entity             string         `config:"entity"`
+	Pattern            string         `config:"pattern"`
+	Faker              string         `config:"faker"`
+	IP                 *IP            `config:"ip"`
+	GeoPoint           *GeoPoint      `config:"geo_point"`
+	Text               *Text          `config:"text"`
+	Version            *Version       `config:"version"`
+	Samples            *Samples       `config:"samples"`
+	Buckets            int            `config:"buckets"`
+	Vector             *Vector        `config:"vector"`
+	Array              *Array         `config:"array"`
+	Format             string         `config:"format"`
+	Arrival            *Arrival       `config:"arrival"`
+	OutOfOrder         *OutOfOrder    `config:"out_of_order"`
+	RelativeTo         *RelativeTo    `config:"relative_to"`
+}
+
+// WeightedEnum is a single value of a weighted enum: the probability of the value
+// to be chosen is its weight divided by the sum of all the weights of the enum.
+type WeightedEnum struct {
+	Value  string  `config:"value"`
+	Weight float64 `config:"weight"`
+}
+
+const (
+	DistributionNormal      string = "normal"
+	DistributionLogNormal   string = "lognormal"
+	DistributionExponential string = "exponential"
+	DistributionZipf        string = "zipf"
+	DistributionPoisson     string = "poisson"
+)
+
+// Distribution defines the statistical distribution numeric values are drawn from,
+// only the parameters relevant to its type are used.
+type Distribution struct {
+	Type string `config:"type"`
+	// normal
+	Mean   float64 `config:"mean"`
+	StdDev float64 `config:"stddev"`
+	// lognormal, parameters of the underlying normal distribution
+	Mu    float64 `config:"mu"`
+	Sigma float64 `config:"sigma"`
+	// exponential
+	Rate float64 `config:"rate"`
+	// poisson
+	Lambda float64 `config:"lambda"`
+	// zipf
+	S float64 `config:"s"`
+	V float64 `config:"v"`
+}
+
+const DefaultShapeTimestampField = "@timestamp"
+const DefaultShapePeriod = 24 * time.Hour
+
+// Shape defines a value changing over time as the sum of a base value, a sinusoidal
+// seasonality and a linear trend, with some random noise on top of it
+type Shape struct {
+	// date field whose generated value is used to compute the shape, default `@timestamp`
+	TimestampField string  `config:"timestamp_field"`
+	Base           float64 `config:"base"`
+	// seasonality: the period of the sine wave (default 24h), its amplitude and phase shift
+	Period    time.Duration `config:"period"`
+	Amplitude float64       `config:"amplitude"`
+	Phase     time.Duration `config:"phase"`
+	// trend: delta of the value for each hour
+	Trend float64 `config:"trend"`
+	// noise: maximum random delta, as a ratio of the value
+	Noise float64 `config:"noise"`
+}
+
+func (s Shape) TimestampFieldOrDefault() string {
+	if len(s.TimestampField) == 0 {
+		return DefaultShapeTimestampField
+	}
+
+	return s.TimestampField
+}
+
+func (s Shape) PeriodOrDefault() time.Duration {
+	if s.Period == 0 {
+		return DefaultShapePeriod
+	}
+
+	return s.Period
+}
+
+const (
+	DateFormatEpochMillis = "epoch_millis"
+	DateFormatEpochSecond = "epoch_second"
+	DateFormatRFC3164     = "rfc3164"
+	DateFormatCommonLog   = "common_log"
+)
+
+// IsEpochDateFormat returns true for the date formats generating numbers
+func IsEpochDateFormat(format string) bool {
+	return format == DateFormatEpochMillis || format == DateFormatEpochSecond
 }
 
 const (
@@ -91,10 +944,51 @@ func (cf ConfigField) ValidateCounterResetProbabilistic() error {
 }
 
 func (cf ConfigField) ValidForDateField() error {
+	if cf.Counter {
+		return cf.ValidDateCounter()
+	}
+
 	if cf.Period.Abs() > 0 && (cf.Range.From != nil || cf.Range.To != nil) {
 		return rangeInvalidConfig
 	}
 
+	if cf.RelativeTo != nil {
+		if cf.Period.Abs() > 0 || cf.Range.From != nil || cf.Range.To != nil || cf.Arrival != nil {
+			return relativeToInvalidConfig
+		}
+
+		return cf.RelativeTo.Valid()
+	}
+
+	return nil
+}
+
+// ValidDateCounter checks that a date `counter` defines the `period` it advances by, starting from `range.from`
+// if defined, and a `fuzziness` within the period
+func (cf ConfigField) ValidDateCounter() error {
+	if cf.Period <= 0 || cf.Range.To != nil || cf.Arrival != nil || cf.RelativeTo != nil {
+		return dateCounterInvalidConfig
+	}
+
+	if cf.Fuzziness < 0 || cf.Fuzziness > 1 {
+		return errors.New("date counter 'fuzziness' must be between 0 and 1")
+	}
+
+	return nil
+}
+
+// ValidDateFormat checks that `format` is one of the predefined date formats, or a Go time layout
+// with at least one element of the reference time
+func (cf ConfigField) ValidDateFormat() error {
+	switch cf.Format {
+	case "", DateFormatEpochMillis, DateFormatEpochSecond, DateFormatRFC3164, DateFormatCommonLog:
+		return nil
+	}
+
+	if (time.Time{}).Format(cf.Format) == cf.Format {
+		return dateFormatInvalidConfig
+	}
+
 	return nil
 }
 
@@ -106,6 +1000,203 @@ func (cf ConfigField) ValidCounter() error {
 	return nil
 }
 
+func (cf ConfigField) ValidCardinality() error {
+	if len(cf.CardinalityPer) > 0 && cf.Cardinality <= 0 {
+		return errors.New("`cardinality_per` requires `cardinality` value to be set")
+	}
+
+	return nil
+}
+
+func (cf ConfigField) ValidWeightedEnum() error {
+	if len(cf.WeightedEnum) == 0 {
+		return nil
+	}
+
+	if len(cf.Enum) > 0 {
+		return weightedEnumInvalidConfig
+	}
+
+	for _, weightedEnum := range cf.WeightedEnum {
+		if weightedEnum.Weight <= 0 {
+			return weightedEnumInvalidWeight
+		}
+	}
+
+	return nil
+}
+
+func (cf ConfigField) ValidDistribution() error {
+	if cf.Distribution == nil {
+		return nil
+	}
+
+	if cf.Counter {
+		return distributionInvalidConfig
+	}
+
+	return cf.Distribution.Valid()
+}
+
+func (d Distribution) Valid() error {
+	switch d.Type {
+	case DistributionNormal:
+		if d.StdDev <= 0 {
+			return errors.New("normal distribution requires 'stddev' value greater than zero")
+		}
+	case DistributionLogNormal:
+		if d.Sigma <= 0 {
+			return errors.New("lognormal distribution requires 'sigma' value greater than zero")
+		}
+	case DistributionExponential:
+		if d.Rate <= 0 {
+			return errors.New("exponential distribution requires 'rate' value greater than zero")
+		}
+	case DistributionPoisson:
+		if d.Lambda <= 0 {
+			return errors.New("poisson distribution requires 'lambda' value greater than zero")
+		}
+	case DistributionZipf:
+		if d.S <= 1 {
+			return errors.New("zipf distribution requires 's' value greater than one")
+		}
+		if d.V != 0 && d.V < 1 {
+			return errors.New("zipf distribution requires 'v' value greater than or equal to one")
+		}
+	default:
+		return errors.New("distribution type must be one of 'normal', 'lognormal', 'exponential', 'zipf', 'poisson'")
+	}
+
+	return nil
+}
+
+func (cf ConfigField) ValidShape() error {
+	if cf.Shape == nil {
+		return nil
+	}
+
+	if cf.Counter || cf.Distribution != nil {
+		return shapeInvalidConfig
+	}
+
+	if cf.Shape.Period < 0 {
+		return errors.New("shape 'period' value must be greater than zero")
+	}
+
+	if cf.Shape.Noise < 0 || cf.Shape.Noise > 1 {
+		return errors.New("shape 'noise' value must be between 0 and 1")
+	}
+
+	return nil
+}
+
+func (cf ConfigField) ValidDerive() error {
+	if len(cf.Derive) == 0 {
+		return nil
+	}
+
+	if cf.Value != nil || len(cf.Enum) > 0 || len(cf.WeightedEnum) > 0 || cf.Counter || cf.Distribution != nil || cf.Shape != nil || cf.Cardinality > 0 {
+		return deriveInvalidConfig
+	}
+
+	return nil
+}
+
+func (cf ConfigField) ValidPattern() error {
+	if len(cf.Pattern) == 0 {
+		return nil
+	}
+
+	if len(cf.Enum) > 0 || len(cf.WeightedEnum) > 0 {
+		return patternInvalidConfig
+	}
+
+	if _, err := syntax.Parse(cf.Pattern, syntax.Perl); err != nil {
+		return fmt.Errorf("invalid pattern: %w", err)
+	}
+
+	return nil
+}
+
+func (cf ConfigField) ValidFaker() error {
+	if len(cf.Faker) == 0 {
+		return nil
+	}
+
+	if len(cf.Enum) > 0 || len(cf.WeightedEnum) > 0 || len(cf.Pattern) > 0 || cf.Counter || cf.Distribution != nil || cf.Shape != nil {
+		return fakerInvalidConfig
+	}
+
+	return nil
+}
+
+func (cf ConfigField) ValidEntity() error {
+	if len(cf.Entity) == 0 {
+		return nil
+	}
+
+	// date counters, advancing by `period`, have a series of values for each entity
+	if cf.Cardinality > 0 || (cf.Counter && cf.Period <= 0) || len(cf.Derive) > 0 {
+		return entityInvalidConfig
+	}
+
+	return nil
+}
+
+func (cf ConfigField) ValidVersion() error {
+	if cf.Version == nil {
+		return nil
+	}
+
+	if len(cf.Enum) > 0 || len(cf.WeightedEnum) > 0 {
+		return versionInvalidConfig
+	}
+
+	return cf.Version.Valid()
+}
+
+func (cf ConfigField) ValidSamples() error {
+	if err := cf.ValidDistribution(); err != nil {
+		return err
+	}
+
+	if cf.Counter || cf.Shape != nil {
+		return samplesInvalidConfig
+	}
+
+	minSamples, maxSamples := cf.Samples.OrDefault()
+	if minSamples < 1 {
+		return errors.New("samples 'min' value must be greater than zero")
+	}
+
+	if minSamples > maxSamples {
+		return errors.New("samples 'min' value must be less than or equal to 'max'")
+	}
+
+	if cf.Buckets < 0 {
+		return errors.New("buckets value must be greater than zero")
+	}
+
+	return nil
+}
+
+// BucketsOrDefault returns the maximum number of buckets of the values of `histogram` fields
+func (cf ConfigField) BucketsOrDefault() int {
+	if cf.Buckets == 0 {
+		return DefaultBuckets
+	}
+
+	return cf.Buckets
+}
+
+func (cf ConfigField) ValidMissingProbability() error {
+	if cf.MissingProbability < 0 || cf.MissingProbability > 1 {
+		return errors.New("missing_probability must be between 0 and 1")
+	}
+
+	return nil
+}
+
 func (r Range) FromAsTime() (time.Time, error) {
 	if r.From == nil {
 		return time.Time{}, rangeTimeNotSet
@@ -138,6 +1229,37 @@ func (r Range) MaxAsInt64() (int64, error) {
 	return int64(*r.Max), nil
 }
 
+// MinAsUint64 returns the min bound of the range for `unsigned_long` fields, negative bounds are returned as zero
+func (r Range) MinAsUint64() (uint64, error) {
+	if r.Min == nil {
+		return 0, rangeBoundNotSet
+	}
+
+	return float64ToUint64(*r.Min), nil
+}
+
+// MaxAsUint64 returns the max bound of the range for `unsigned_long` fields, negative bounds are returned as zero
+func (r Range) MaxAsUint64() (uint64, error) {
+	if r.Max == nil {
+		return math.MaxUint64, rangeBoundNotSet
+	}
+
+	return float64ToUint64(*r.Max), nil
+}
+
+// float64ToUint64 converts v to uint64, saturating at the bounds of uint64 since float64 cannot represent math.MaxUint64 exactly
+func float64ToUint64(v float64) uint64 {
+	if v <= 0 {
+		return 0
+	}
+
+	if v >= math.MaxUint64 {
+		return math.MaxUint64
+	}
+
+	return uint64(v)
+}
+
 func (r Range) MinAsFloat64() (float64, error) {
 	if r.Min == nil {
 		return 0, rangeBoundNotSet
@@ -155,7 +1277,8 @@ func (r Range) MaxAsFloat64() (float64, error) {
 }
 
 type ConfigFile struct {
-	Fields []ConfigField `config:"fields"`
+	Fields   []ConfigField `config:"fields"`
+	Entities []Entity      `config:"entities"`
 }
 
 func LoadConfig(fs afero.Fs, configFile string) (Config, error) {
@@ -190,10 +1313,43 @@ func LoadConfigFromYaml(c []byte) (Config, error) {
 	}
 
 	outCfg := Config{
-		m: make(map[string]ConfigField),
+		m:        make(map[string]ConfigField),
+		entities: make(map[string]Entity),
+	}
+
+	for _, e := range cfgfile.Entities {
+		if len(e.Name) == 0 {
+			return Config{}, errors.New("entity name must be set")
+		}
+
+		if e.Size <= 0 {
+			return Config{}, fmt.Errorf("entity %s: size must be greater than zero", e.Name)
+		}
+
+		if _, ok := outCfg.entities[e.Name]; ok {
+			return Config{}, fmt.Errorf("entity %s: defined more than once", e.Name)
+		}
+
+		outCfg.entities[e.Name] = e
 	}
 
 	for _, c := range cfgfile.Fields {
+		if err := c.ValidWeightedEnum(); err != nil {
+			return Config{}, fmt.Errorf("field %s: %w", c.Name, err)
+		}
+
+		if err := c.ValidEntity(); err != nil {
+			return Config{}, fmt.Errorf("field %s: %w", c.Name, err)
+		}
+
+		if err := c.ValidCardinality(); err != nil {
+			return Config{}, fmt.Errorf("field %s: %w", c.Name, err)
+		}
+
+		if _, ok := outCfg.entities[c.Entity]; len(c.Entity) > 0 && !ok {
+			return Config{}, fmt.Errorf("field %s: entity %s not defined", c.Name, c.Entity)
+		}
+
 		outCfg.m[c.Name] = c
 	}
 
@@ -205,6 +1361,11 @@ func (c Config) GetField(fieldName string) (ConfigField, bool) {
 	return v, ok
 }
 
+func (c Config) GetEntity(entityName string) (Entity, bool) {
+	v, ok := c.entities[entityName]
+	return v, ok
+}
+
 func (c Config) SetField(fieldName string, configField ConfigField) {
 	configField.Name = fieldName
 	c.m[fieldName] = configField
@@ -247,6 +247,1338 @@ func TestIsValidCounter(t *testing.T) {
 	}
 }
 
+func TestLoadConfigWithWeightedEnum(t *testing.T) {
+	testCases := []struct {
+		scenario string
+		config   string
+		hasError bool
+	}{
+		{
+			scenario: "weighted enum",
+			config:   "fields:\n  - name: field\n    weighted_enum:\n      - value: a\n        weight: 1\n      - value: b\n        weight: 0.5",
+			hasError: false,
+		},
+		{
+			scenario: "weighted enum and enum",
+			config:   "fields:\n  - name: field\n    enum: [\"a\"]\n    weighted_enum:\n      - value: a\n        weight: 1",
+			hasError: true,
+		},
+		{
+			scenario: "weighted enum with zero weight",
+			config:   "fields:\n  - name: field\n    weighted_enum:\n      - value: a\n        weight: 0",
+			hasError: true,
+		},
+		{
+			scenario: "weighted enum with negative weight",
+			config:   "fields:\n  - name: field\n    weighted_enum:\n      - value: a\n        weight: -1",
+			hasError: true,
+		},
+		{
+			scenario: "weighted enum without weight",
+			config:   "fields:\n  - name: field\n    weighted_enum:\n      - value: a",
+			hasError: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.scenario, func(t *testing.T) {
+			_, err := LoadConfigFromYaml([]byte(testCase.config))
+			if testCase.hasError && err == nil {
+				t.Fatal("expected error but got nil")
+			}
+			if !testCase.hasError && err != nil {
+				t.Fatalf("expected no error but got one: %v", err)
+			}
+		})
+	}
+}
+
+func TestLoadConfigWithCardinality(t *testing.T) {
+	testCases := []struct {
+		scenario            string
+		config              string
+		expectedCardinality int
+		expectedPer         string
+		hasError            bool
+	}{
+		{
+			scenario:            "cardinality",
+			config:              "fields:\n  - name: field\n    cardinality: 10",
+			expectedCardinality: 10,
+			hasError:            false,
+		},
+		{
+			scenario:            "cardinality per parent",
+			config:              "fields:\n  - name: field\n    cardinality: 10\n    cardinality_per: parent",
+			expectedCardinality: 10,
+			expectedPer:         "parent",
+			hasError:            false,
+		},
+		{
+			scenario: "cardinality per parent without cardinality",
+			config:   "fields:\n  - name: field\n    cardinality_per: parent",
+			hasError: true,
+		},
+		{
+			scenario: "cardinality as string",
+			config:   "fields:\n  - name: field\n    cardinality: ten",
+			hasError: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.scenario, func(t *testing.T) {
+			cfg, err := LoadConfigFromYaml([]byte(testCase.config))
+			if testCase.hasError {
+				if err == nil {
+					t.Fatal("expected error but got nil")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("expected no error but got one: %v", err)
+			}
+
+			fieldCfg, _ := cfg.GetField("field")
+			if fieldCfg.Cardinality != testCase.expectedCardinality || fieldCfg.CardinalityPer != testCase.expectedPer {
+				t.Errorf("expected cardinality %d per %q, got %d per %q", testCase.expectedCardinality, testCase.expectedPer, fieldCfg.Cardinality, fieldCfg.CardinalityPer)
+			}
+		})
+	}
+}
+
+func TestLoadConfigWithEntities(t *testing.T) {
+	testCases := []struct {
+		scenario string
+		config   string
+		hasError bool
+	}{
+		{
+			scenario: "entity",
+			config:   "entities:\n  - name: hosts\n    size: 10\nfields:\n  - name: host.name\n    entity: hosts",
+			hasError: false,
+		},
+		{
+			scenario: "entity not defined",
+			config:   "fields:\n  - name: host.name\n    entity: hosts",
+			hasError: true,
+		},
+		{
+			scenario: "entity without size",
+			config:   "entities:\n  - name: hosts\nfields:\n  - name: host.name\n    entity: hosts",
+			hasError: true,
+		},
+		{
+			scenario: "entity without name",
+			config:   "entities:\n  - size: 10",
+			hasError: true,
+		},
+		{
+			scenario: "entity defined twice",
+			config:   "entities:\n  - name: hosts\n    size: 10\n  - name: hosts\n    size: 5",
+			hasError: true,
+		},
+		{
+			scenario: "entity with cardinality",
+			config:   "entities:\n  - name: hosts\n    size: 10\nfields:\n  - name: host.name\n    entity: hosts\n    cardinality: 10",
+			hasError: true,
+		},
+		{
+			scenario: "entity with counter",
+			config:   "entities:\n  - name: hosts\n    size: 10\nfields:\n  - name: host.name\n    entity: hosts\n    counter: true",
+			hasError: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.scenario, func(t *testing.T) {
+			cfg, err := LoadConfigFromYaml([]byte(testCase.config))
+			if testCase.hasError {
+				if err == nil {
+					t.Fatal("expected error but got nil")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("expected no error but got one: %v", err)
+			}
+
+			if entity, ok := cfg.GetEntity("hosts"); !ok || entity.Size != 10 {
+				t.Errorf("expected entity hosts with size 10, got %+v", entity)
+			}
+		})
+	}
+}
+
+func TestIsValidDistribution(t *testing.T) {
+	testCases := []struct {
+		scenario string
+		config   string
+		hasError bool
+	}{
+		{
+			scenario: "no distribution",
+			config:   "name: field",
+			hasError: false,
+		},
+		{
+			scenario: "unknown distribution",
+			config:   "name: field\ndistribution:\n  type: unknown",
+			hasError: true,
+		},
+		{
+			scenario: "normal",
+			config:   "name: field\ndistribution:\n  type: normal\n  mean: 0\n  stddev: 1",
+			hasError: false,
+		},
+		{
+			scenario: "normal without stddev",
+			config:   "name: field\ndistribution:\n  type: normal\n  mean: 10",
+			hasError: true,
+		},
+		{
+			scenario: "lognormal",
+			config:   "name: field\ndistribution:\n  type: lognormal\n  mu: 0\n  sigma: 1",
+			hasError: false,
+		},
+		{
+			scenario: "lognormal without sigma",
+			config:   "name: field\ndistribution:\n  type: lognormal\n  mu: 1",
+			hasError: true,
+		},
+		{
+			scenario: "exponential",
+			config:   "name: field\ndistribution:\n  type: exponential\n  rate: 0.5",
+			hasError: false,
+		},
+		{
+			scenario: "exponential with negative rate",
+			config:   "name: field\ndistribution:\n  type: exponential\n  rate: -0.5",
+			hasError: true,
+		},
+		{
+			scenario: "poisson",
+			config:   "name: field\ndistribution:\n  type: poisson\n  lambda: 3",
+			hasError: false,
+		},
+		{
+			scenario: "poisson without lambda",
+			config:   "name: field\ndistribution:\n  type: poisson",
+			hasError: true,
+		},
+		{
+			scenario: "zipf",
+			config:   "name: field\ndistribution:\n  type: zipf\n  s: 1.5",
+			hasError: false,
+		},
+		{
+			scenario: "zipf with s lower than one",
+			config:   "name: field\ndistribution:\n  type: zipf\n  s: 0.5",
+			hasError: true,
+		},
+		{
+			scenario: "zipf with v lower than one",
+			config:   "name: field\ndistribution:\n  type: zipf\n  s: 1.5\n  v: 0.5",
+			hasError: true,
+		},
+		{
+			scenario: "distribution with counter",
+			config:   "name: field\ncounter: true\ndistribution:\n  type: poisson\n  lambda: 3",
+			hasError: true,
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.scenario, func(t *testing.T) {
+			cfg, err := yaml.NewConfig([]byte(testCase.config))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var config ConfigField
+			err = cfg.Unpack(&config)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = config.ValidDistribution()
+			if testCase.hasError && err == nil {
+				t.Fatal("expected error but got nil")
+			}
+			if !testCase.hasError && err != nil {
+				t.Fatalf("expected no error but got one: %v", err)
+			}
+		})
+	}
+}
+
+func TestIsValidShape(t *testing.T) {
+	testCases := []struct {
+		scenario string
+		config   string
+		hasError bool
+	}{
+		{
+			scenario: "no shape",
+			config:   "name: field",
+			hasError: false,
+		},
+		{
+			scenario: "shape",
+			config:   "name: field\nshape:\n  base: 10\n  period: 1h\n  amplitude: 2\n  trend: 0.1\n  noise: 0.05",
+			hasError: false,
+		},
+		{
+			scenario: "shape with negative period",
+			config:   "name: field\nshape:\n  period: -1h\n  amplitude: 2",
+			hasError: true,
+		},
+		{
+			scenario: "shape with noise greater than one",
+			config:   "name: field\nshape:\n  noise: 1.5",
+			hasError: true,
+		},
+		{
+			scenario: "shape with counter",
+			config:   "name: field\ncounter: true\nshape:\n  base: 10",
+			hasError: true,
+		},
+		{
+			scenario: "shape with distribution",
+			config:   "name: field\ndistribution:\n  type: poisson\n  lambda: 3\nshape:\n  base: 10",
+			hasError: true,
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.scenario, func(t *testing.T) {
+			cfg, err := yaml.NewConfig([]byte(testCase.config))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var config ConfigField
+			err = cfg.Unpack(&config)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = config.ValidShape()
+			if testCase.hasError && err == nil {
+				t.Fatal("expected error but got nil")
+			}
+			if !testCase.hasError && err != nil {
+				t.Fatalf("expected no error but got one: %v", err)
+			}
+		})
+	}
+}
+
+func TestIsValidDerive(t *testing.T) {
+	testCases := []struct {
+		scenario string
+		config   string
+		hasError bool
+	}{
+		{
+			scenario: "no derive",
+			config:   "name: field",
+			hasError: false,
+		},
+		{
+			scenario: "derive",
+			config:   "name: field\nderive: \"$other * 15\"",
+			hasError: false,
+		},
+		{
+			scenario: "derive with value",
+			config:   "name: field\nderive: \"$other * 15\"\nvalue: 10",
+			hasError: true,
+		},
+		{
+			scenario: "derive with enum",
+			config:   "name: field\nderive: \"$other * 15\"\nenum: [\"a\"]",
+			hasError: true,
+		},
+		{
+			scenario: "derive with counter",
+			config:   "name: field\nderive: \"$other * 15\"\ncounter: true",
+			hasError: true,
+		},
+		{
+			scenario: "derive with cardinality",
+			config:   "name: field\nderive: \"$other * 15\"\ncardinality: 10",
+			hasError: true,
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.scenario, func(t *testing.T) {
+			cfg, err := yaml.NewConfig([]byte(testCase.config))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var config ConfigField
+			err = cfg.Unpack(&config)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = config.ValidDerive()
+			if testCase.hasError && err == nil {
+				t.Fatal("expected error but got nil")
+			}
+			if !testCase.hasError && err != nil {
+				t.Fatalf("expected no error but got one: %v", err)
+			}
+		})
+	}
+}
+
+func TestIsValidPattern(t *testing.T) {
+	testCases := []struct {
+		scenario string
+		config   string
+		hasError bool
+	}{
+		{
+			scenario: "no pattern",
+			config:   "name: field",
+			hasError: false,
+		},
+		{
+			scenario: "pattern",
+			config:   "name: field\npattern: 'i-[0-9a-f]{17}'",
+			hasError: false,
+		},
+		{
+			scenario: "invalid pattern",
+			config:   "name: field\npattern: 'i-[0-9a-f'",
+			hasError: true,
+		},
+		{
+			scenario: "pattern with enum",
+			config:   "name: field\npattern: 'i-[0-9a-f]{17}'\nenum: [\"a\"]",
+			hasError: true,
+		},
+		{
+			scenario: "pattern with weighted enum",
+			config:   "name: field\npattern: 'i-[0-9a-f]{17}'\nweighted_enum:\n  - value: a\n    weight: 1",
+			hasError: true,
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.scenario, func(t *testing.T) {
+			cfg, err := yaml.NewConfig([]byte(testCase.config))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var config ConfigField
+			err = cfg.Unpack(&config)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = config.ValidPattern()
+			if testCase.hasError && err == nil {
+				t.Fatal("expected error but got nil")
+			}
+			if !testCase.hasError && err != nil {
+				t.Fatalf("expected no error but got one: %v", err)
+			}
+		})
+	}
+}
+
+func TestIsValidFaker(t *testing.T) {
+	testCases := []struct {
+		scenario string
+		config   string
+		hasError bool
+	}{
+		{
+			scenario: "no faker",
+			config:   "name: field",
+			hasError: false,
+		},
+		{
+			scenario: "faker",
+			config:   "name: field\nfaker: email",
+			hasError: false,
+		},
+		{
+			scenario: "faker with cardinality",
+			config:   "name: field\nfaker: email\ncardinality: 10",
+			hasError: false,
+		},
+		{
+			scenario: "faker with enum",
+			config:   "name: field\nfaker: email\nenum: [\"a\"]",
+			hasError: true,
+		},
+		{
+			scenario: "faker with pattern",
+			config:   "name: field\nfaker: email\npattern: '[a-z]+'",
+			hasError: true,
+		},
+		{
+			scenario: "faker with counter",
+			config:   "name: field\nfaker: http_status\ncounter: true",
+			hasError: true,
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.scenario, func(t *testing.T) {
+			cfg, err := yaml.NewConfig([]byte(testCase.config))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var config ConfigField
+			err = cfg.Unpack(&config)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = config.ValidFaker()
+			if testCase.hasError && err == nil {
+				t.Fatal("expected error but got nil")
+			}
+			if !testCase.hasError && err != nil {
+				t.Fatalf("expected no error but got one: %v", err)
+			}
+		})
+	}
+}
+
+func TestIsValidIP(t *testing.T) {
+	testCases := []struct {
+		scenario          string
+		config            string
+		expectedIPv6Ratio float64
+		hasError          bool
+	}{
+		{
+			scenario:          "cidrs",
+			config:            "cidrs: [\"10.0.0.0/8\", \"192.168.0.0/16\", \"2001:db8::/32\", \"fd00::/8\"]",
+			expectedIPv6Ratio: 0.5,
+			hasError:          false,
+		},
+		{
+			scenario:          "ipv6 ratio",
+			config:            "ipv6_ratio: 0.2",
+			expectedIPv6Ratio: 0.2,
+			hasError:          false,
+		},
+		{
+			scenario:          "private",
+			config:            "private: true",
+			expectedIPv6Ratio: 0,
+			hasError:          false,
+		},
+		{
+			scenario: "invalid cidr",
+			config:   "cidrs: [\"10.0.0.0/33\"]",
+			hasError: true,
+		},
+		{
+			scenario: "private and cidrs",
+			config:   "private: true\ncidrs: [\"10.0.0.0/8\"]",
+			hasError: true,
+		},
+		{
+			scenario: "ipv6 ratio out of range",
+			config:   "ipv6_ratio: 1.5",
+			hasError: true,
+		},
+		{
+			scenario: "ipv6 ratio without ipv6 cidrs",
+			config:   "ipv6_ratio: 0.5\ncidrs: [\"10.0.0.0/8\"]",
+			hasError: true,
+		},
+		{
+			scenario: "ipv6 ratio without ipv4 cidrs",
+			config:   "ipv6_ratio: 0.5\ncidrs: [\"2001:db8::/32\"]",
+			hasError: true,
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.scenario, func(t *testing.T) {
+			cfg, err := yaml.NewConfig([]byte(testCase.config))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var ip IP
+			err = cfg.Unpack(&ip)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = ip.Valid()
+			if testCase.hasError && err == nil {
+				t.Fatal("expected error but got nil")
+			}
+			if !testCase.hasError && err != nil {
+				t.Fatalf("expected no error but got one: %v", err)
+			}
+
+			if !testCase.hasError && ip.IPv6RatioOrDefault() != testCase.expectedIPv6Ratio {
+				t.Errorf("expected ipv6 ratio %f, got %f", testCase.expectedIPv6Ratio, ip.IPv6RatioOrDefault())
+			}
+		})
+	}
+}
+
+func TestIsValidGeoPoint(t *testing.T) {
+	testCases := []struct {
+		scenario string
+		config   string
+		hasError bool
+	}{
+		{
+			scenario: "no regions",
+			config:   "format: wkt",
+			hasError: false,
+		},
+		{
+			scenario: "regions",
+			config:   "bounding_boxes:\n  - {min_lat: 40, max_lat: 41, min_lon: -75, max_lon: -73}\ncities:\n  - {name: paris, radius: 10}\n  - {lat: 1.5, lon: 2.5, radius: 1}\npolygons:\n  - [[0, 0], [10, 0], [0, 10]]",
+			hasError: false,
+		},
+		{
+			scenario: "invalid format",
+			config:   "format: svg",
+			hasError: true,
+		},
+		{
+			scenario: "bounding box out of range",
+			config:   "bounding_boxes:\n  - {min_lat: -100, max_lat: 41, min_lon: -75, max_lon: -73}",
+			hasError: true,
+		},
+		{
+			scenario: "bounding box with min greater than max",
+			config:   "bounding_boxes:\n  - {min_lat: 40, max_lat: 41, min_lon: -73, max_lon: -75}",
+			hasError: true,
+		},
+		{
+			scenario: "city without radius",
+			config:   "cities:\n  - {name: paris}",
+			hasError: true,
+		},
+		{
+			scenario: "city with lat only",
+			config:   "cities:\n  - {lat: 1.5, radius: 1}",
+			hasError: true,
+		},
+		{
+			scenario: "polygon with 2 points",
+			config:   "polygons:\n  - [[0, 0], [10, 0]]",
+			hasError: true,
+		},
+		{
+			scenario: "polygon with longitude out of range",
+			config:   "polygons:\n  - [[0, 0], [190, 0], [0, 10]]",
+			hasError: true,
+		},
+		{
+			scenario: "polygon with latitude out of range",
+			config:   "polygons:\n  - [[0, 0], [10, 0], [0, -95]]",
+			hasError: true,
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.scenario, func(t *testing.T) {
+			cfg, err := yaml.NewConfig([]byte(testCase.config))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var geoPoint GeoPoint
+			err = cfg.Unpack(&geoPoint)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = geoPoint.Valid()
+			if testCase.hasError && err == nil {
+				t.Fatal("expected error but got nil")
+			}
+			if !testCase.hasError && err != nil {
+				t.Fatalf("expected no error but got one: %v", err)
+			}
+		})
+	}
+}
+
+func TestIsValidText(t *testing.T) {
+	testCases := []struct {
+		scenario string
+		config   string
+		hasError bool
+	}{
+		{
+			scenario: "default",
+			config:   "style: sentence",
+			hasError: false,
+		},
+		{
+			scenario: "ranges",
+			config:   "style: lorem\nmin_words: 1\nmax_words: 3\nmin_sentences: 2\nmax_sentences: 4\nmin_length: 10\nmax_length: 100",
+			hasError: false,
+		},
+		{
+			scenario: "invalid style",
+			config:   "style: poem",
+			hasError: true,
+		},
+		{
+			scenario: "min words greater than max words",
+			config:   "min_words: 10\nmax_words: 3",
+			hasError: true,
+		},
+		{
+			scenario: "min sentences greater than max sentences",
+			config:   "min_sentences: 10\nmax_sentences: 3",
+			hasError: true,
+		},
+		{
+			scenario: "min length greater than max length",
+			config:   "min_length: 100\nmax_length: 10",
+			hasError: true,
+		},
+		{
+			scenario: "negative length",
+			config:   "max_length: -1",
+			hasError: true,
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.scenario, func(t *testing.T) {
+			cfg, err := yaml.NewConfig([]byte(testCase.config))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var text Text
+			err = cfg.Unpack(&text)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = text.Valid()
+			if testCase.hasError && err == nil {
+				t.Fatal("expected error but got nil")
+			}
+			if !testCase.hasError && err != nil {
+				t.Fatalf("expected no error but got one: %v", err)
+			}
+		})
+	}
+}
+
+func TestIsValidVersion(t *testing.T) {
+	testCases := []struct {
+		scenario string
+		config   string
+		hasError bool
+	}{
+		{
+			scenario: "default",
+			config:   "name: alpha\nversion: {}",
+			hasError: false,
+		},
+		{
+			scenario: "ranges",
+			config:   "name: alpha\nversion:\n  major: {min: 8, max: 8}\n  minor: {max: 17}\n  patch: {min: 1}\n  prerelease_probability: 0.1",
+			hasError: false,
+		},
+		{
+			scenario: "min greater than max",
+			config:   "name: alpha\nversion:\n  minor: {min: 5, max: 2}",
+			hasError: true,
+		},
+		{
+			scenario: "negative min",
+			config:   "name: alpha\nversion:\n  patch: {min: -1}",
+			hasError: true,
+		},
+		{
+			scenario: "invalid prerelease probability",
+			config:   "name: alpha\nversion:\n  prerelease_probability: 1.5",
+			hasError: true,
+		},
+		{
+			scenario: "version with enum",
+			config:   "name: alpha\nenum: [\"1.0.0\"]\nversion: {}",
+			hasError: true,
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.scenario, func(t *testing.T) {
+			cfg, err := yaml.NewConfig([]byte(testCase.config))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var configField ConfigField
+			err = cfg.Unpack(&configField)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = configField.ValidVersion()
+			if testCase.hasError && err == nil {
+				t.Fatal("expected error but got nil")
+			}
+			if !testCase.hasError && err != nil {
+				t.Fatalf("expected no error but got one: %v", err)
+			}
+		})
+	}
+}
+
+func TestIsValidSamples(t *testing.T) {
+	testCases := []struct {
+		scenario string
+		config   string
+		hasError bool
+	}{
+		{
+			scenario: "default",
+			config:   "name: alpha",
+			hasError: false,
+		},
+		{
+			scenario: "samples and buckets",
+			config:   "name: alpha\nbuckets: 5\nsamples:\n  min: 10\n  max: 20\ndistribution:\n  type: exponential\n  rate: 0.5",
+			hasError: false,
+		},
+		{
+			scenario: "min samples greater than max samples",
+			config:   "name: alpha\nsamples:\n  min: 20\n  max: 10",
+			hasError: true,
+		},
+		{
+			scenario: "zero min samples",
+			config:   "name: alpha\nsamples:\n  min: 0\n  max: 10",
+			hasError: true,
+		},
+		{
+			scenario: "negative buckets",
+			config:   "name: alpha\nbuckets: -1",
+			hasError: true,
+		},
+		{
+			scenario: "invalid distribution",
+			config:   "name: alpha\ndistribution:\n  type: normal",
+			hasError: true,
+		},
+		{
+			scenario: "shape",
+			config:   "name: alpha\nshape:\n  base: 10",
+			hasError: true,
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.scenario, func(t *testing.T) {
+			cfg, err := yaml.NewConfig([]byte(testCase.config))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var configField ConfigField
+			err = cfg.Unpack(&configField)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = configField.ValidSamples()
+			if testCase.hasError && err == nil {
+				t.Fatal("expected error but got nil")
+			}
+			if !testCase.hasError && err != nil {
+				t.Fatalf("expected no error but got one: %v", err)
+			}
+		})
+	}
+}
+
+func TestIsValidVector(t *testing.T) {
+	testCases := []struct {
+		scenario string
+		config   string
+		hasError bool
+	}{
+		{
+			scenario: "default",
+			config:   "dims: 0",
+			hasError: false,
+		},
+		{
+			scenario: "dense vector",
+			config:   "dims: 384\nelement_type: float\nnormalize: true\ncentroids: 10\nspread: 0.2",
+			hasError: false,
+		},
+		{
+			scenario: "sparse vector",
+			config:   "vocabulary_size: 1000\nmin_tokens: 20\nmax_tokens: 100",
+			hasError: false,
+		},
+		{
+			scenario: "small vocabulary with default tokens",
+			config:   "vocabulary_size: 5",
+			hasError: false,
+		},
+		{
+			scenario: "invalid element type",
+			config:   "element_type: double",
+			hasError: true,
+		},
+		{
+			scenario: "normalized byte vector",
+			config:   "element_type: byte\nnormalize: true",
+			hasError: true,
+		},
+		{
+			scenario: "bit vector dims not multiple of 8",
+			config:   "element_type: bit\ndims: 12",
+			hasError: true,
+		},
+		{
+			scenario: "min tokens greater than max tokens",
+			config:   "min_tokens: 20\nmax_tokens: 10",
+			hasError: true,
+		},
+		{
+			scenario: "max tokens greater than vocabulary size",
+			config:   "vocabulary_size: 10\nmax_tokens: 20",
+			hasError: true,
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.scenario, func(t *testing.T) {
+			cfg, err := yaml.NewConfig([]byte(testCase.config))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var vector Vector
+			err = cfg.Unpack(&vector)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = vector.Valid()
+			if testCase.hasError && err == nil {
+				t.Fatal("expected error but got nil")
+			}
+			if !testCase.hasError && err != nil {
+				t.Fatalf("expected no error but got one: %v", err)
+			}
+		})
+	}
+}
+
+func TestIsValidArray(t *testing.T) {
+	testCases := []struct {
+		scenario string
+		config   string
+		hasError bool
+	}{
+		{
+			scenario: "default",
+			config:   "unique: false",
+			hasError: false,
+		},
+		{
+			scenario: "length",
+			config:   "min_length: 0\nmax_length: 5\nunique: true",
+			hasError: false,
+		},
+		{
+			scenario: "only min length",
+			config:   "min_length: 5",
+			hasError: false,
+		},
+		{
+			scenario: "min length greater than max length",
+			config:   "min_length: 5\nmax_length: 2",
+			hasError: true,
+		},
+		{
+			scenario: "negative length",
+			config:   "min_length: -1",
+			hasError: true,
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.scenario, func(t *testing.T) {
+			cfg, err := yaml.NewConfig([]byte(testCase.config))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var array Array
+			err = cfg.Unpack(&array)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = array.Valid()
+			if testCase.hasError && err == nil {
+				t.Fatal("expected error but got nil")
+			}
+			if !testCase.hasError && err != nil {
+				t.Fatalf("expected no error but got one: %v", err)
+			}
+		})
+	}
+}
+
+func TestIsValidDateFormat(t *testing.T) {
+	testCases := []struct {
+		scenario string
+		config   string
+		hasError bool
+	}{
+		{
+			scenario: "default",
+			config:   "name: timestamp",
+			hasError: false,
+		},
+		{
+			scenario: "epoch_millis",
+			config:   "format: epoch_millis",
+			hasError: false,
+		},
+		{
+			scenario: "rfc3164",
+			config:   "format: rfc3164",
+			hasError: false,
+		},
+		{
+			scenario: "common_log",
+			config:   "format: common_log",
+			hasError: false,
+		},
+		{
+			scenario: "custom layout",
+			config:   "format: \"2006-01-02 15:04:05\"",
+			hasError: false,
+		},
+		{
+			scenario: "not a layout",
+			config:   "format: epoch_nanos",
+			hasError: true,
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.scenario, func(t *testing.T) {
+			cfg, err := yaml.NewConfig([]byte(testCase.config))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var field ConfigField
+			err = cfg.Unpack(&field)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = field.ValidDateFormat()
+			if testCase.hasError && err == nil {
+				t.Fatal("expected error but got nil")
+			}
+			if !testCase.hasError && err != nil {
+				t.Fatalf("expected no error but got one: %v", err)
+			}
+		})
+	}
+}
+
+func TestIsValidArrival(t *testing.T) {
+	testCases := []struct {
+		scenario string
+		config   string
+		hasError bool
+	}{
+		{
+			scenario: "default",
+			config:   "process: poisson",
+			hasError: false,
+		},
+		{
+			scenario: "business hours with bursts",
+			config:   "profile: business_hours\nbursts:\n  probability: 0.1\n  min_size: 2\n  max_size: 10\n  factor: 5",
+			hasError: false,
+		},
+		{
+			scenario: "weekdays",
+			config:   "weekdays: [0, 1, 1, 1, 1, 1, 0]",
+			hasError: false,
+		},
+		{
+			scenario: "unknown process",
+			config:   "process: hawkes",
+			hasError: true,
+		},
+		{
+			scenario: "unknown profile",
+			config:   "profile: night_shift",
+			hasError: true,
+		},
+		{
+			scenario: "hours not for each hour",
+			config:   "hours: [1, 2, 3]",
+			hasError: true,
+		},
+		{
+			scenario: "no events",
+			config:   "weekdays: [0, 0, 0, 0, 0, 0, 0]",
+			hasError: true,
+		},
+		{
+			scenario: "negative load",
+			config:   "weekdays: [-1, 1, 1, 1, 1, 1, 1]",
+			hasError: true,
+		},
+		{
+			scenario: "bursts probability",
+			config:   "bursts:\n  probability: 2",
+			hasError: true,
+		},
+		{
+			scenario: "bursts size",
+			config:   "bursts:\n  min_size: 10\n  max_size: 2",
+			hasError: true,
+		},
+		{
+			scenario: "bursts factor",
+			config:   "bursts:\n  factor: 0.5",
+			hasError: true,
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.scenario, func(t *testing.T) {
+			cfg, err := yaml.NewConfig([]byte(testCase.config))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var arrival Arrival
+			err = cfg.Unpack(&arrival)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = arrival.Valid()
+			if testCase.hasError && err == nil {
+				t.Fatal("expected error but got nil")
+			}
+			if !testCase.hasError && err != nil {
+				t.Fatalf("expected no error but got one: %v", err)
+			}
+		})
+	}
+}
+
+func TestIsValidOutOfOrder(t *testing.T) {
+	testCases := []struct {
+		scenario string
+		config   string
+		hasError bool
+	}{
+		{
+			scenario: "late",
+			config:   "probability: 0.1\nmax_lateness: 5m",
+			hasError: false,
+		},
+		{
+			scenario: "late and early",
+			config:   "probability: 1\nmax_lateness: 5m\nmax_earliness: 10s",
+			hasError: false,
+		},
+		{
+			scenario: "probability",
+			config:   "probability: 1.5",
+			hasError: true,
+		},
+		{
+			scenario: "negative lateness",
+			config:   "probability: 0.1\nmax_lateness: -5m",
+			hasError: true,
+		},
+		{
+			scenario: "negative earliness",
+			config:   "probability: 0.1\nmax_earliness: -5m",
+			hasError: true,
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.scenario, func(t *testing.T) {
+			cfg, err := yaml.NewConfig([]byte(testCase.config))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var outOfOrder OutOfOrder
+			err = cfg.Unpack(&outOfOrder)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = outOfOrder.Valid()
+			if testCase.hasError && err == nil {
+				t.Fatal("expected error but got nil")
+			}
+			if !testCase.hasError && err != nil {
+				t.Fatalf("expected no error but got one: %v", err)
+			}
+		})
+	}
+}
+
+func TestIsValidRelativeTo(t *testing.T) {
+	testCases := []struct {
+		scenario string
+		config   string
+		hasError bool
+	}{
+		{
+			scenario: "uniform",
+			config:   "relative_to:\n  field: \"@timestamp\"\n  min: 1s\n  max: 1m",
+			hasError: false,
+		},
+		{
+			scenario: "distribution",
+			config:   "relative_to:\n  field: \"@timestamp\"\n  distribution:\n    type: exponential\n    rate: 0.1",
+			hasError: false,
+		},
+		{
+			scenario: "no field",
+			config:   "relative_to:\n  max: 1m",
+			hasError: true,
+		},
+		{
+			scenario: "negative unit",
+			config:   "relative_to:\n  field: \"@timestamp\"\n  unit: -1s",
+			hasError: true,
+		},
+		{
+			scenario: "min greater than max",
+			config:   "relative_to:\n  field: \"@timestamp\"\n  min: 1m\n  max: 1s",
+			hasError: true,
+		},
+		{
+			scenario: "invalid distribution",
+			config:   "relative_to:\n  field: \"@timestamp\"\n  distribution:\n    type: exponential",
+			hasError: true,
+		},
+		{
+			scenario: "period",
+			config:   "period: 1h\nrelative_to:\n  field: \"@timestamp\"",
+			hasError: true,
+		},
+		{
+			scenario: "arrival",
+			config:   "arrival:\n  process: poisson\nrelative_to:\n  field: \"@timestamp\"",
+			hasError: true,
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.scenario, func(t *testing.T) {
+			cfg, err := yaml.NewConfig([]byte(testCase.config))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var configField ConfigField
+			err = cfg.Unpack(&configField)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = configField.ValidForDateField()
+			if testCase.hasError && err == nil {
+				t.Fatal("expected error but got nil")
+			}
+			if !testCase.hasError && err != nil {
+				t.Fatalf("expected no error but got one: %v", err)
+			}
+		})
+	}
+}
+
+func TestIsValidDateCounter(t *testing.T) {
+	testCases := []struct {
+		scenario string
+		config   string
+		hasError bool
+	}{
+		{
+			scenario: "period",
+			config:   "counter: true\nperiod: 10s",
+			hasError: false,
+		},
+		{
+			scenario: "from and fuzziness",
+			config:   "counter: true\nperiod: 10s\nfuzziness: 0.02\nrange:\n  from: \"2024-03-04T00:00:00+00:00\"",
+			hasError: false,
+		},
+		{
+			scenario: "no period",
+			config:   "counter: true",
+			hasError: true,
+		},
+		{
+			scenario: "negative period",
+			config:   "counter: true\nperiod: -10s",
+			hasError: true,
+		},
+		{
+			scenario: "to",
+			config:   "counter: true\nperiod: 10s\nrange:\n  to: \"2024-03-04T00:00:00+00:00\"",
+			hasError: true,
+		},
+		{
+			scenario: "arrival",
+			config:   "counter: true\nperiod: 10s\narrival:\n  process: poisson",
+			hasError: true,
+		},
+		{
+			scenario: "fuzziness",
+			config:   "counter: true\nperiod: 10s\nfuzziness: 1.5",
+			hasError: true,
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.scenario, func(t *testing.T) {
+			cfg, err := yaml.NewConfig([]byte(testCase.config))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var configField ConfigField
+			err = cfg.Unpack(&configField)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = configField.ValidForDateField()
+			if testCase.hasError && err == nil {
+				t.Fatal("expected error but got nil")
+			}
+			if !testCase.hasError && err != nil {
+				t.Fatalf("expected no error but got one: %v", err)
+			}
+		})
+	}
+}
+
 func TestRange_MaxAsFloat64(t *testing.T) {
 	testCases := []struct {
 		scenario  string
@@ -361,6 +1693,73 @@ func TestRange_MaxAsInt64(t *testing.T) {
 	}
 }
 
+func TestRange_MaxAsUint64(t *testing.T) {
+	testCases := []struct {
+		scenario  string
+		rangeYaml string
+		expected  uint64
+		hasError  bool
+	}{
+		{
+			scenario:  "max nil",
+			rangeYaml: "min: 10",
+			expected:  math.MaxUint64,
+			hasError:  true,
+		},
+		{
+			scenario:  "float64",
+			rangeYaml: "max: 10.",
+			expected:  10,
+		},
+		{
+			scenario:  "uint64",
+			rangeYaml: "max: 10",
+			expected:  10,
+		},
+		{
+			scenario:  "beyond int64",
+			rangeYaml: "max: 12000000000000000000",
+			expected:  12000000000000000000,
+		},
+		{
+			scenario:  "beyond uint64",
+			rangeYaml: "max: 20000000000000000000",
+			expected:  math.MaxUint64,
+		},
+		{
+			scenario:  "negative",
+			rangeYaml: "max: -10",
+			expected:  0,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.scenario, func(t *testing.T) {
+			cfg, err := yaml.NewConfig([]byte(testCase.rangeYaml))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var rangeCfg Range
+			err = cfg.Unpack(&rangeCfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			v, err := rangeCfg.MaxAsUint64()
+			if testCase.hasError && err == nil {
+				t.Fatal("expected error but got nil")
+			}
+			if !testCase.hasError && err != nil {
+				t.Fatal("expected no error but got one")
+			}
+			if testCase.expected != v {
+				t.Fatalf("expected %v, got %v", testCase.expected, v)
+			}
+		})
+	}
+}
+
 func TestRange_MinAsFloat64(t *testing.T) {
 	testCases := []struct {
 		scenario  string
@@ -475,6 +1874,68 @@ func TestRange_MinAsInt64(t *testing.T) {
 	}
 }
 
+func TestRange_MinAsUint64(t *testing.T) {
+	testCases := []struct {
+		scenario  string
+		rangeYaml string
+		expected  uint64
+		hasError  bool
+	}{
+		{
+			scenario:  "min nil",
+			rangeYaml: "max: 10",
+			expected:  0,
+			hasError:  true,
+		},
+		{
+			scenario:  "float64",
+			rangeYaml: "min: 10.",
+			expected:  10,
+		},
+		{
+			scenario:  "uint64",
+			rangeYaml: "min: 10",
+			expected:  10,
+		},
+		{
+			scenario:  "beyond int64",
+			rangeYaml: "min: 12000000000000000000",
+			expected:  12000000000000000000,
+		},
+		{
+			scenario:  "negative",
+			rangeYaml: "min: -10",
+			expected:  0,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.scenario, func(t *testing.T) {
+			cfg, err := yaml.NewConfig([]byte(testCase.rangeYaml))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var rangeCfg Range
+			err = cfg.Unpack(&rangeCfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			v, err := rangeCfg.MinAsUint64()
+			if testCase.hasError && err == nil {
+				t.Fatal("expected error but got nil")
+			}
+			if !testCase.hasError && err != nil {
+				t.Fatal("expected no error but got one")
+			}
+			if testCase.expected != v {
+				t.Fatalf("expected %v, got %v", testCase.expected, v)
+			}
+		})
+	}
+}
+
 func TestRange_FromAsTime(t *testing.T) {
 	from, err := time.Parse("2006-01-02T15:04:05.999999999-07:00", "2023-11-23T08:35:38+00:00")
 	if err != nil {
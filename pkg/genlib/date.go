@@ -0,0 +1,87 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib/config"
+)
+
+// commonLogTimeLayout is the layout of the timestamps in the Apache Common Log Format
+const commonLogTimeLayout = "02/Jan/2006:15:04:05 -0700"
+
+// textTemplateTimeLayout is the layout the values of `date` fields are formatted with in the auto-generated text template
+const textTemplateTimeLayout = "2006-01-02T15:04:05.999999999Z07:00"
+
+// isDateField returns true for the fields whose values are generated as time.Time
+func isDateField(field Field) bool {
+	return field.Type == FieldTypeDate || field.Type == FieldTypeDateNanos
+}
+
+// dateLayout returns the layout the values of the date field are formatted with, according to its `format`.
+// It is empty for epoch formats, whose values are numbers.
+func dateLayout(fieldCfg ConfigField, field Field) string {
+	switch fieldCfg.Format {
+	case "":
+		if field.Type == FieldTypeDateNanos {
+			return FieldTypeNanosTimeLayout
+		}
+
+		return FieldTypeTimeLayout
+	case config.DateFormatEpochMillis, config.DateFormatEpochSecond:
+		return ""
+	case config.DateFormatRFC3164:
+		return time.Stamp
+	case config.DateFormatCommonLog:
+		return commonLogTimeLayout
+	default:
+		return fieldCfg.Format
+	}
+}
+
+// appendDate appends to b the value of the date field, formatted according to its `format`
+func appendDate(b []byte, fieldCfg ConfigField, field Field, t time.Time) []byte {
+	switch fieldCfg.Format {
+	case config.DateFormatEpochMillis:
+		return strconv.AppendInt(b, t.UnixMilli(), 10)
+	case config.DateFormatEpochSecond:
+		return strconv.AppendInt(b, t.Unix(), 10)
+	}
+
+	return t.AppendFormat(b, dateLayout(fieldCfg, field))
+}
+
+// dateValue returns the value of the date field formatted according to its `format`: a number for epoch formats,
+// a string otherwise
+func dateValue(fieldCfg ConfigField, field Field, t time.Time) any {
+	switch fieldCfg.Format {
+	case config.DateFormatEpochMillis:
+		return t.UnixMilli()
+	case config.DateFormatEpochSecond:
+		return t.Unix()
+	}
+
+	return t.Format(dateLayout(fieldCfg, field))
+}
+
+// dateValueTemplate returns the text template formatting the value of the date field in the variable,
+// according to its `format`
+func dateValueTemplate(cfg Config, field Field, variableName string) string {
+	fieldCfg, _ := cfg.GetField(field.Name)
+
+	switch {
+	case fieldCfg.Format == config.DateFormatEpochMillis:
+		return fmt.Sprintf(`{{$%s.UnixMilli}}`, variableName)
+	case fieldCfg.Format == config.DateFormatEpochSecond:
+		return fmt.Sprintf(`{{$%s.Unix}}`, variableName)
+	case len(fieldCfg.Format) == 0 && field.Type == FieldTypeDate:
+		return fmt.Sprintf(`{{$%s.Format "%s"}}`, variableName, textTemplateTimeLayout)
+	default:
+		return fmt.Sprintf(`{{$%s.Format %q}}`, variableName, dateLayout(fieldCfg, field))
+	}
+}
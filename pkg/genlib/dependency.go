@@ -0,0 +1,252 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// eventValue is the value generated for a field in the event identified by counter
+type eventValue struct {
+	counter uint64
+	value   any
+}
+
+// fieldValue returns the value generated for a field other fields depend on in the current event,
+// generating it if the field was not emitted yet
+func (s *genState) fieldValue(fieldName string) (any, error) {
+	if v, ok := s.eventValues[fieldName]; ok && v.counter == s.counter {
+		return v.value, nil
+	}
+
+	dependencyFunc, ok := s.dependencyFuncs[fieldName]
+	if !ok {
+		return nil, fmt.Errorf("field %s is not a dependency", fieldName)
+	}
+
+	value, err := dependencyFunc(s)
+	if err != nil {
+		return nil, err
+	}
+
+	s.eventValues[fieldName] = eventValue{counter: s.counter, value: value}
+
+	return value, nil
+}
+
+// fieldValueKey returns a string identifying the value of a field, usable as map key
+func fieldValueKey(value any) string {
+	switch v := value.(type) {
+	case renderedValue:
+		return string(v.raw)
+	case []byte:
+		return string(v)
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// fieldDependencies returns the name of the fields whose value in the event is needed to generate the field
+func fieldDependencies(fieldCfg ConfigField) []string {
+	var dependencies []string
+	if len(fieldCfg.CardinalityPer) > 0 {
+		dependencies = append(dependencies, fieldCfg.CardinalityPer)
+	}
+
+	if len(fieldCfg.Derive) > 0 {
+		if node, err := parseDerive(fieldCfg.Derive); err == nil {
+			dependencies = append(dependencies, deriveFieldRefs(node)...)
+		}
+	}
+
+	return append(dependencies, dateFieldDependencies(fieldCfg)...)
+}
+
+// dateFieldDependencies returns the name of the date fields whose time in the event is needed to generate the field
+func dateFieldDependencies(fieldCfg ConfigField) []string {
+	var dependencies []string
+	if fieldCfg.Shape != nil {
+		dependencies = append(dependencies, fieldCfg.Shape.TimestampFieldOrDefault())
+	}
+
+	if fieldCfg.RelativeTo != nil && len(fieldCfg.RelativeTo.Field) > 0 {
+		dependencies = append(dependencies, fieldCfg.RelativeTo.Field)
+	}
+
+	return dependencies
+}
+
+// eventTime returns the value generated for the date field in the current event, generating it if the field
+// was not emitted yet. The field must be a dependency, see dateFieldDependencies.
+func eventTime(state *genState, dateFieldName string) (time.Time, error) {
+	value, err := state.fieldValue(dateFieldName)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if t, ok := value.(time.Time); ok {
+		return t, nil
+	}
+
+	// the placeholder engine renders the value, while the gotext engine returns nil when the field is missing:
+	// either way the time is kept in prevCache, see bindNearTime and bindMissingWithReturn
+	t, ok := state.prevCache[dateFieldName].(time.Time)
+	if !ok {
+		return time.Time{}, fmt.Errorf("field %s is not a date field", dateFieldName)
+	}
+
+	return t, nil
+}
+
+// bindDependencies checks the dependencies between fields, then wraps the bound function of the fields other
+// fields depend on, so that their value is generated only once for each event
+func bindDependencies(cfg Config, fields Fields, fieldMap map[string]any, state *genState) error {
+	fieldsByName := make(map[string]Field, len(fields))
+	for _, field := range fields {
+		fieldsByName[field.Name] = field
+	}
+
+	graph := make(map[string][]string)
+	for _, field := range fields {
+		fieldCfg, _ := cfg.GetField(field.Name)
+		for _, dependency := range fieldDependencies(fieldCfg) {
+			if _, ok := fieldsByName[dependency]; !ok {
+				return fmt.Errorf("field %s depends on field %s not present in fields definition", field.Name, dependency)
+			}
+
+			graph[field.Name] = append(graph[field.Name], dependency)
+		}
+	}
+
+	for _, field := range fields {
+		fieldCfg, _ := cfg.GetField(field.Name)
+		for _, dependency := range dateFieldDependencies(fieldCfg) {
+			if !isDateField(fieldsByName[dependency]) {
+				return fmt.Errorf("field %s depends on field %s that is not a date field", field.Name, dependency)
+			}
+		}
+	}
+
+	if err := checkDependencyCycles(graph); err != nil {
+		return err
+	}
+
+	for _, dependencies := range graph {
+		for _, dependency := range dependencies {
+			if _, ok := state.dependencyFuncs[dependency]; ok {
+				continue
+			}
+
+			if err := bindDependency(fieldsByName[dependency], fieldMap, state); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkDependencyCycles returns an error if a field depends, directly or not, on itself
+func checkDependencyCycles(graph map[string][]string) error {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	status := make(map[string]int, len(graph))
+	var path []string
+
+	var visit func(fieldName string) error
+	visit = func(fieldName string) error {
+		switch status[fieldName] {
+		case visiting:
+			return fmt.Errorf("dependency cycle between fields: %s -> %s", strings.Join(path, " -> "), fieldName)
+		case visited:
+			return nil
+		}
+
+		status[fieldName] = visiting
+		path = append(path, fieldName)
+		for _, dependency := range graph[fieldName] {
+			if err := visit(dependency); err != nil {
+				return err
+			}
+		}
+
+		path = path[:len(path)-1]
+		status[fieldName] = visited
+
+		return nil
+	}
+
+	for fieldName := range graph {
+		if err := visit(fieldName); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// bindDependency wraps the bound function of a field other fields depend on, so that its value is generated
+// only once for each event and it is available to the other fields through genState.fieldValue
+func bindDependency(field Field, fieldMap map[string]any, state *genState) error {
+	// the hardcoded value is rendered as JSON by bindStatic: the other fields depend on the value itself,
+	// read according to the type of the field
+	if len(field.Value) > 0 {
+		state.dependencyFuncs[field.Name] = func(state *genState) (any, error) {
+			return renderedValue{raw: []byte(field.Value), fieldType: field.Type}, nil
+		}
+
+		return nil
+	}
+
+	switch boundF := fieldMap[field.Name].(type) {
+	case emitFNotReturn:
+		state.dependencyFuncs[field.Name] = func(state *genState) (any, error) {
+			var tmp bytes.Buffer
+			if err := boundF(state, &tmp); err != nil {
+				return nil, err
+			}
+
+			return renderedValue{raw: tmp.Bytes(), fieldType: field.Type}, nil
+		}
+
+		var emitFNotReturn emitFNotReturn
+		emitFNotReturn = func(state *genState, buf *bytes.Buffer) error {
+			value, err := state.fieldValue(field.Name)
+			if err != nil {
+				return err
+			}
+
+			buf.Write(value.(renderedValue).raw)
+			return nil
+		}
+
+		fieldMap[field.Name] = emitFNotReturn
+	case emitF:
+		state.dependencyFuncs[field.Name] = func(state *genState) (any, error) {
+			return boundF(state), nil
+		}
+
+		var emitF emitF
+		emitF = func(state *genState) any {
+			value, _ := state.fieldValue(field.Name)
+			return value
+		}
+
+		fieldMap[field.Name] = emitF
+	default:
+		return fmt.Errorf("cannot bind field %s as dependency", field.Name)
+	}
+
+	return nil
+}
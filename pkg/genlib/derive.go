@@ -0,0 +1,765 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var deriveDivisionByZero = errors.New("division by zero")
+
+// deriveNode is a node of a parsed `derive` expression
+type deriveNode interface {
+	eval(state *genState) (any, error)
+}
+
+type deriveLiteral struct {
+	value any
+}
+
+type deriveFieldRef struct {
+	name string
+}
+
+type deriveList struct {
+	items []deriveNode
+}
+
+type deriveMap struct {
+	keys   []string
+	values []deriveNode
+}
+
+type deriveUnary struct {
+	op      byte
+	operand deriveNode
+}
+
+type deriveBinary struct {
+	op          byte
+	left, right deriveNode
+}
+
+type deriveIndex struct {
+	target, index deriveNode
+}
+
+// renderedValue is the value of a field bound by the placeholder engine, as written in the event
+type renderedValue struct {
+	raw       []byte
+	fieldType string
+}
+
+// parseDerive parses a `derive` expression. The expression supports:
+//   - integer, float and string literals: 15, 1.5, "running"
+//   - references to the value of other fields in the event: $aws.ec2.network.in.packets
+//   - arithmetic operators: +, -, *, /, %; + concatenates strings
+//   - list and object literals: ["a", "b"], {"running": 16, "stopped": 80}
+//   - lookups by index or key: ["a", "b"][$idx], $InstanceType[$instanceTypeIdx]
+func parseDerive(expr string) (deriveNode, error) {
+	p := &deriveParser{expr: expr}
+	node, err := p.parseExpr()
+	if err != nil {
+		return nil, fmt.Errorf("invalid derive expression %q: %w", expr, err)
+	}
+
+	p.skipSpaces()
+	if p.pos < len(p.expr) {
+		return nil, fmt.Errorf("invalid derive expression %q: unexpected %q at position %d", expr, p.expr[p.pos], p.pos)
+	}
+
+	return node, nil
+}
+
+// deriveFieldRefs returns the names of the fields referenced by a `derive` expression
+func deriveFieldRefs(node deriveNode) []string {
+	var refs []string
+	switch n := node.(type) {
+	case deriveFieldRef:
+		refs = append(refs, n.name)
+	case deriveList:
+		for _, item := range n.items {
+			refs = append(refs, deriveFieldRefs(item)...)
+		}
+	case deriveMap:
+		for _, value := range n.values {
+			refs = append(refs, deriveFieldRefs(value)...)
+		}
+	case deriveUnary:
+		refs = append(refs, deriveFieldRefs(n.operand)...)
+	case deriveBinary:
+		refs = append(refs, deriveFieldRefs(n.left)...)
+		refs = append(refs, deriveFieldRefs(n.right)...)
+	case deriveIndex:
+		refs = append(refs, deriveFieldRefs(n.target)...)
+		refs = append(refs, deriveFieldRefs(n.index)...)
+	}
+
+	return refs
+}
+
+type deriveParser struct {
+	expr string
+	pos  int
+}
+
+func (p *deriveParser) skipSpaces() {
+	for p.pos < len(p.expr) && strings.ContainsRune(" \t\r\n", rune(p.expr[p.pos])) {
+		p.pos++
+	}
+}
+
+func (p *deriveParser) peek() byte {
+	p.skipSpaces()
+	if p.pos >= len(p.expr) {
+		return 0
+	}
+
+	return p.expr[p.pos]
+}
+
+func (p *deriveParser) expect(c byte) error {
+	if p.peek() != c {
+		return fmt.Errorf("expected %q at position %d", c, p.pos)
+	}
+
+	p.pos++
+	return nil
+}
+
+// parseExpr parses additions and subtractions
+func (p *deriveParser) parseExpr() (deriveNode, error) {
+	left, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+
+	for op := p.peek(); op == '+' || op == '-'; op = p.peek() {
+		p.pos++
+		right, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+
+		left = deriveBinary{op: op, left: left, right: right}
+	}
+
+	return left, nil
+}
+
+// parseTerm parses multiplications, divisions and modulos
+func (p *deriveParser) parseTerm() (deriveNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for op := p.peek(); op == '*' || op == '/' || op == '%'; op = p.peek() {
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		left = deriveBinary{op: op, left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *deriveParser) parseUnary() (deriveNode, error) {
+	if p.peek() == '-' {
+		p.pos++
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		return deriveUnary{op: '-', operand: operand}, nil
+	}
+
+	return p.parsePostfix()
+}
+
+// parsePostfix parses lookups by index or key
+func (p *deriveParser) parsePostfix() (deriveNode, error) {
+	node, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek() == '[' {
+		p.pos++
+		index, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := p.expect(']'); err != nil {
+			return nil, err
+		}
+
+		node = deriveIndex{target: node, index: index}
+	}
+
+	return node, nil
+}
+
+func (p *deriveParser) parsePrimary() (deriveNode, error) {
+	switch c := p.peek(); {
+	case c == 0:
+		return nil, errors.New("unexpected end of expression")
+	case c == '(':
+		p.pos++
+		node, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := p.expect(')'); err != nil {
+			return nil, err
+		}
+
+		return node, nil
+	case c == '$':
+		p.pos++
+		start := p.pos
+		for p.pos < len(p.expr) && isDeriveFieldNameChar(p.expr[p.pos]) {
+			p.pos++
+		}
+
+		if start == p.pos {
+			return nil, fmt.Errorf("expected field name at position %d", start)
+		}
+
+		return deriveFieldRef{name: p.expr[start:p.pos]}, nil
+	case c == '"' || c == '\'':
+		value, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+
+		return deriveLiteral{value: value}, nil
+	case c >= '0' && c <= '9' || c == '.':
+		return p.parseNumber()
+	case c == '[':
+		p.pos++
+		list := deriveList{}
+		for p.peek() != ']' {
+			item, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+
+			list.items = append(list.items, item)
+			if p.peek() != ',' {
+				break
+			}
+
+			p.pos++
+		}
+
+		if err := p.expect(']'); err != nil {
+			return nil, err
+		}
+
+		return list, nil
+	case c == '{':
+		p.pos++
+		m := deriveMap{}
+		for p.peek() != '}' {
+			key, err := p.parsePrimary()
+			if err != nil {
+				return nil, err
+			}
+
+			literal, ok := key.(deriveLiteral)
+			if !ok {
+				return nil, fmt.Errorf("object keys must be literals at position %d", p.pos)
+			}
+
+			if err := p.expect(':'); err != nil {
+				return nil, err
+			}
+
+			value, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+
+			m.keys = append(m.keys, deriveString(literal.value))
+			m.values = append(m.values, value)
+			if p.peek() != ',' {
+				break
+			}
+
+			p.pos++
+		}
+
+		if err := p.expect('}'); err != nil {
+			return nil, err
+		}
+
+		return m, nil
+	default:
+		return nil, fmt.Errorf("unexpected %q at position %d", c, p.pos)
+	}
+}
+
+func (p *deriveParser) parseString() (string, error) {
+	quote := p.expr[p.pos]
+	start := p.pos
+	p.pos++
+	for p.pos < len(p.expr) && p.expr[p.pos] != quote {
+		if p.expr[p.pos] == '\\' {
+			p.pos++
+		}
+
+		p.pos++
+	}
+
+	if p.pos >= len(p.expr) {
+		return "", fmt.Errorf("unterminated string at position %d", start)
+	}
+
+	p.pos++
+	literal := p.expr[start:p.pos]
+	if quote == '\'' {
+		literal = `"` + strings.ReplaceAll(literal[1:len(literal)-1], `"`, `\"`) + `"`
+	}
+
+	return strconv.Unquote(literal)
+}
+
+func (p *deriveParser) parseNumber() (deriveNode, error) {
+	start := p.pos
+	for p.pos < len(p.expr) && strings.ContainsRune("0123456789.eE", rune(p.expr[p.pos])) {
+		// sign of the exponent
+		if (p.expr[p.pos] == 'e' || p.expr[p.pos] == 'E') && p.pos+1 < len(p.expr) && (p.expr[p.pos+1] == '-' || p.expr[p.pos+1] == '+') {
+			p.pos++
+		}
+
+		p.pos++
+	}
+
+	literal := p.expr[start:p.pos]
+	if i, err := strconv.ParseInt(literal, 10, 64); err == nil {
+		return deriveLiteral{value: i}, nil
+	}
+
+	f, err := strconv.ParseFloat(literal, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid number %q at position %d", literal, start)
+	}
+
+	return deriveLiteral{value: f}, nil
+}
+
+func isDeriveFieldNameChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '.' || c == '@'
+}
+
+func (n deriveLiteral) eval(_ *genState) (any, error) {
+	return n.value, nil
+}
+
+func (n deriveFieldRef) eval(state *genState) (any, error) {
+	value, err := state.fieldValue(n.name)
+	if err != nil {
+		return nil, err
+	}
+
+	return deriveOperand(value), nil
+}
+
+func (n deriveList) eval(state *genState) (any, error) {
+	items := make([]any, 0, len(n.items))
+	for _, item := range n.items {
+		value, err := item.eval(state)
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, value)
+	}
+
+	return items, nil
+}
+
+func (n deriveMap) eval(state *genState) (any, error) {
+	m := make(map[string]any, len(n.keys))
+	for i, key := range n.keys {
+		value, err := n.values[i].eval(state)
+		if err != nil {
+			return nil, err
+		}
+
+		m[key] = value
+	}
+
+	return m, nil
+}
+
+func (n deriveUnary) eval(state *genState) (any, error) {
+	value, err := n.operand.eval(state)
+	if err != nil {
+		return nil, err
+	}
+
+	switch v := value.(type) {
+	case int64:
+		return -v, nil
+	case float64:
+		return -v, nil
+	default:
+		return nil, fmt.Errorf("cannot negate %v", value)
+	}
+}
+
+func (n deriveBinary) eval(state *genState) (any, error) {
+	left, err := n.left.eval(state)
+	if err != nil {
+		return nil, err
+	}
+
+	right, err := n.right.eval(state)
+	if err != nil {
+		return nil, err
+	}
+
+	_, leftIsString := left.(string)
+	_, rightIsString := right.(string)
+	if n.op == '+' && (leftIsString || rightIsString) {
+		return deriveString(left) + deriveString(right), nil
+	}
+
+	leftInt, leftIsInt := left.(int64)
+	rightInt, rightIsInt := right.(int64)
+	if leftIsInt && rightIsInt {
+		switch n.op {
+		case '+':
+			return leftInt + rightInt, nil
+		case '-':
+			return leftInt - rightInt, nil
+		case '*':
+			return leftInt * rightInt, nil
+		case '/', '%':
+			if rightInt == 0 {
+				return nil, deriveDivisionByZero
+			}
+
+			if n.op == '/' {
+				return leftInt / rightInt, nil
+			}
+
+			return leftInt % rightInt, nil
+		}
+	}
+
+	leftFloat, leftIsNumber := deriveFloat(left)
+	rightFloat, rightIsNumber := deriveFloat(right)
+	if !leftIsNumber || !rightIsNumber {
+		return nil, fmt.Errorf("cannot apply %q to %v and %v", n.op, left, right)
+	}
+
+	switch n.op {
+	case '+':
+		return leftFloat + rightFloat, nil
+	case '-':
+		return leftFloat - rightFloat, nil
+	case '*':
+		return leftFloat * rightFloat, nil
+	case '/':
+		if rightFloat == 0 {
+			return nil, deriveDivisionByZero
+		}
+
+		return leftFloat / rightFloat, nil
+	default:
+		if rightFloat == 0 {
+			return nil, deriveDivisionByZero
+		}
+
+		return math.Mod(leftFloat, rightFloat), nil
+	}
+}
+
+func (n deriveIndex) eval(state *genState) (any, error) {
+	target, err := n.target.eval(state)
+	if err != nil {
+		return nil, err
+	}
+
+	index, err := n.index.eval(state)
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := target.(type) {
+	case []any:
+		i, ok := deriveFloat(index)
+		if !ok || i != math.Trunc(i) {
+			return nil, fmt.Errorf("list index must be an integer, got %v", index)
+		}
+
+		if i < 0 || int(i) >= len(t) {
+			return nil, fmt.Errorf("list index %v out of range [0, %d)", index, len(t))
+		}
+
+		return deriveOperand(t[int(i)]), nil
+	case map[string]any:
+		value, ok := t[deriveString(index)]
+		if !ok {
+			return nil, fmt.Errorf("key %v not found", index)
+		}
+
+		return deriveOperand(value), nil
+	default:
+		return nil, fmt.Errorf("cannot lookup %v in %v", index, target)
+	}
+}
+
+// deriveOperand normalises the value of a field to the types handled by `derive` expressions:
+// int64, float64, string, []any and map[string]any
+func deriveOperand(value any) any {
+	switch v := value.(type) {
+	case renderedValue:
+		switch v.fieldType {
+		case FieldTypeByte, FieldTypeShort, FieldTypeInteger, FieldTypeLong, FieldTypeUnsignedLong,
+			FieldTypeDouble, FieldTypeFloat, FieldTypeHalfFloat, FieldTypeScaledFloat:
+			if i, err := strconv.ParseInt(string(v.raw), 10, 64); err == nil {
+				return i
+			}
+
+			if f, err := strconv.ParseFloat(string(v.raw), 64); err == nil {
+				return f
+			}
+		}
+
+		return string(v.raw)
+	case []byte:
+		return string(v)
+	case int:
+		return int64(v)
+	case int8:
+		return int64(v)
+	case int16:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case uint:
+		return deriveOperand(uint64(v))
+	case uint8:
+		return int64(v)
+	case uint16:
+		return int64(v)
+	case uint32:
+		return int64(v)
+	case uint64:
+		if v > math.MaxInt64 {
+			return float64(v)
+		}
+
+		return int64(v)
+	case float32:
+		return float64(v)
+	case []string:
+		items := make([]any, 0, len(v))
+		for _, item := range v {
+			items = append(items, item)
+		}
+
+		return items
+	case map[string]string:
+		m := make(map[string]any, len(v))
+		for key, item := range v {
+			m[key] = item
+		}
+
+		return m
+	default:
+		return value
+	}
+}
+
+func deriveFloat(value any) (float64, bool) {
+	switch v := value.(type) {
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	default:
+		return 0, false
+	}
+}
+
+func deriveString(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		return v.Format(FieldTypeTimeLayout)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// makeDeriveFunc returns a function evaluating the `derive` expression of the field in the current event,
+// converted to the type of the field.
+// References to fields with a static `value` in the config are resolved to that value.
+func makeDeriveFunc(cfg Config, fieldCfg ConfigField, field Field) (func(state *genState) (any, error), error) {
+	if err := fieldCfg.ValidDerive(); err != nil {
+		return nil, err
+	}
+
+	node, err := parseDerive(fieldCfg.Derive)
+	if err != nil {
+		return nil, err
+	}
+
+	// a field that can be missing has no value to derive from in the events where it is omitted
+	for _, ref := range deriveFieldRefs(node) {
+		if refCfg, ok := cfg.GetField(ref); ok && refCfg.MissingProbability > 0 {
+			return nil, fmt.Errorf("field %s: `derive` cannot reference field %s with `missing_probability`", field.Name, ref)
+		}
+	}
+
+	node = resolveStaticFieldRefs(cfg, node)
+
+	return func(state *genState) (any, error) {
+		value, err := node.eval(state)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: cannot derive value: %w", field.Name, err)
+		}
+
+		switch field.Type {
+		case FieldTypeByte, FieldTypeShort, FieldTypeInteger, FieldTypeLong:
+			f, ok := deriveFloat(value)
+			if !ok {
+				return nil, fmt.Errorf("field %s: derived value %v is not a number", field.Name, value)
+			}
+
+			if i, ok := value.(int64); ok {
+				return i, nil
+			}
+
+			return int64(math.Round(f)), nil
+		case FieldTypeUnsignedLong:
+			f, ok := deriveFloat(value)
+			if !ok {
+				return nil, fmt.Errorf("field %s: derived value %v is not a number", field.Name, value)
+			}
+
+			if i, ok := value.(int64); ok && i >= 0 {
+				return uint64(i), nil
+			}
+
+			// values above math.MaxInt64 are derived as float64
+			return clampFloat64ToUint64(math.Round(f), 0, math.MaxUint64), nil
+		case FieldTypeDouble, FieldTypeFloat, FieldTypeHalfFloat, FieldTypeScaledFloat:
+			f, ok := deriveFloat(value)
+			if !ok {
+				return nil, fmt.Errorf("field %s: derived value %v is not a number", field.Name, value)
+			}
+
+			return f, nil
+		default:
+			return deriveString(value), nil
+		}
+	}, nil
+}
+
+// resolveStaticFieldRefs replaces the references to fields with a static `value` in the config with the value itself
+func resolveStaticFieldRefs(cfg Config, node deriveNode) deriveNode {
+	switch n := node.(type) {
+	case deriveFieldRef:
+		if refCfg, ok := cfg.GetField(n.name); ok && refCfg.Value != nil {
+			return deriveLiteral{value: deriveOperand(refCfg.Value)}
+		}
+	case deriveList:
+		items := make([]deriveNode, 0, len(n.items))
+		for _, item := range n.items {
+			items = append(items, resolveStaticFieldRefs(cfg, item))
+		}
+
+		return deriveList{items: items}
+	case deriveMap:
+		values := make([]deriveNode, 0, len(n.values))
+		for _, value := range n.values {
+			values = append(values, resolveStaticFieldRefs(cfg, value))
+		}
+
+		return deriveMap{keys: n.keys, values: values}
+	case deriveUnary:
+		return deriveUnary{op: n.op, operand: resolveStaticFieldRefs(cfg, n.operand)}
+	case deriveBinary:
+		return deriveBinary{op: n.op, left: resolveStaticFieldRefs(cfg, n.left), right: resolveStaticFieldRefs(cfg, n.right)}
+	case deriveIndex:
+		return deriveIndex{target: resolveStaticFieldRefs(cfg, n.target), index: resolveStaticFieldRefs(cfg, n.index)}
+	}
+
+	return node
+}
+
+func bindDerive(cfg Config, fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	deriveFunc, err := makeDeriveFunc(cfg, fieldCfg, field)
+	if err != nil {
+		return err
+	}
+
+	var emitFNotReturn emitFNotReturn
+	emitFNotReturn = func(state *genState, buf *bytes.Buffer) error {
+		value, err := deriveFunc(state)
+		if err != nil {
+			return err
+		}
+
+		switch v := value.(type) {
+		case int64:
+			buf.WriteString(strconv.FormatInt(v, 10))
+		case uint64:
+			buf.WriteString(strconv.FormatUint(v, 10))
+		case float64:
+			_, err = fmt.Fprintf(buf, "%f", v)
+		default:
+			buf.WriteString(v.(string))
+		}
+
+		return err
+	}
+
+	fieldMap[field.Name] = emitFNotReturn
+	return nil
+}
+
+func bindDeriveWithReturn(cfg Config, fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	deriveFunc, err := makeDeriveFunc(cfg, fieldCfg, field)
+	if err != nil {
+		return err
+	}
+
+	var emitF emitF
+	emitF = func(state *genState) any {
+		value, err := deriveFunc(state)
+		if err != nil {
+			panic(err)
+		}
+
+		return value
+	}
+
+	fieldMap[field.Name] = emitF
+	return nil
+}
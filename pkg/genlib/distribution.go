@@ -0,0 +1,80 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib/config"
+)
+
+// poissonNormalApproximationThreshold is the lambda above which poisson values are approximated
+// with a normal distribution, Knuth's algorithm being linear in lambda
+const poissonNormalApproximationThreshold = 30
+
+// makeDistributionFunc returns a function drawing values from the given distribution, clamped between min and max
+func makeDistributionFunc(r *rand.Rand, distribution config.Distribution, min, max float64) func() float64 {
+	var dummyFunc func() float64
+
+	switch distribution.Type {
+	case config.DistributionNormal:
+		dummyFunc = func() float64 {
+			return distribution.Mean + r.NormFloat64()*distribution.StdDev
+		}
+	case config.DistributionLogNormal:
+		dummyFunc = func() float64 {
+			return math.Exp(distribution.Mu + r.NormFloat64()*distribution.Sigma)
+		}
+	case config.DistributionExponential:
+		dummyFunc = func() float64 {
+			return r.ExpFloat64() / distribution.Rate
+		}
+	case config.DistributionPoisson:
+		dummyFunc = func() float64 {
+			return randPoisson(r, distribution.Lambda)
+		}
+	case config.DistributionZipf:
+		// zipf values are ranks, they start from min when it is positive
+		offset := math.Max(min, 0)
+		imax := uint64(math.MaxUint64)
+		if span := max - offset; span < math.MaxUint64 {
+			imax = uint64(span)
+		}
+
+		v := distribution.V
+		if v == 0 {
+			v = 1
+		}
+
+		zipf := rand.NewZipf(r, distribution.S, v, imax)
+		dummyFunc = func() float64 {
+			return offset + float64(zipf.Uint64())
+		}
+	default:
+		dummyFunc = func() float64 { return r.Float64() }
+	}
+
+	return func() float64 {
+		return math.Min(math.Max(dummyFunc(), min), max)
+	}
+}
+
+func randPoisson(r *rand.Rand, lambda float64) float64 {
+	if lambda > poissonNormalApproximationThreshold {
+		return math.Max(math.Round(lambda+r.NormFloat64()*math.Sqrt(lambda)), 0)
+	}
+
+	// Knuth's algorithm
+	limit := math.Exp(-lambda)
+	k := float64(0)
+	p := r.Float64()
+	for p > limit {
+		k++
+		p *= r.Float64()
+	}
+
+	return k
+}
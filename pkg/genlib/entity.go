@@ -0,0 +1,47 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+import (
+	"fmt"
+
+	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib/config"
+)
+
+// fieldEntity returns the entity pool referenced by the field, if any
+func fieldEntity(cfg Config, fieldCfg ConfigField) (config.Entity, error) {
+	if len(fieldCfg.Entity) == 0 {
+		return config.Entity{}, nil
+	}
+
+	if err := fieldCfg.ValidEntity(); err != nil {
+		return config.Entity{}, err
+	}
+
+	// only date counters have a series of values for each entity, see makeDateCounterFunc
+	if fieldCfg.Counter {
+		return config.Entity{}, fmt.Errorf("field %s: `entity` defined together with `counter` for a field that is not a date", fieldCfg.Name)
+	}
+
+	entity, ok := cfg.GetEntity(fieldCfg.Entity)
+	if !ok {
+		return config.Entity{}, fmt.Errorf("field %s: entity %s not defined", fieldCfg.Name, fieldCfg.Entity)
+	}
+
+	return entity, nil
+}
+
+// entityIndex returns the index of the entity of the pool picked for the current event,
+// picking it at random if no field referencing the entity was generated yet in the event
+func (s *genState) entityIndex(entity config.Entity) int {
+	if v, ok := s.entityIndexes[entity.Name]; ok && v.counter == s.counter {
+		return v.value.(int)
+	}
+
+	idx := s.rand.Intn(entity.Size)
+	s.entityIndexes[entity.Name] = eventValue{counter: s.counter, value: idx}
+
+	return idx
+}
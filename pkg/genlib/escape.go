@@ -0,0 +1,100 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+import (
+	"bytes"
+	"unicode/utf8"
+)
+
+const hexDigits = "0123456789abcdef"
+
+// needsJSONEscape returns true if b contains bytes that cannot be written verbatim in a JSON string
+func needsJSONEscape(b []byte) bool {
+	for i := 0; i < len(b); {
+		c := b[i]
+		if c < utf8.RuneSelf {
+			if c < 0x20 || c == '"' || c == '\\' {
+				return true
+			}
+
+			i++
+			continue
+		}
+
+		r, size := utf8.DecodeRune(b[i:])
+		if r == utf8.RuneError && size == 1 {
+			return true
+		}
+
+		i += size
+	}
+
+	return false
+}
+
+// writeJSONEscaped writes b to buf as the content of a JSON string, without the surrounding quotes.
+// Invalid UTF-8 sequences are replaced with the unicode replacement character.
+func writeJSONEscaped(buf *bytes.Buffer, b []byte) {
+	for i := 0; i < len(b); {
+		c := b[i]
+		if c < utf8.RuneSelf {
+			switch c {
+			case '"', '\\':
+				buf.WriteByte('\\')
+				buf.WriteByte(c)
+			case '\n':
+				buf.WriteString(`\n`)
+			case '\r':
+				buf.WriteString(`\r`)
+			case '\t':
+				buf.WriteString(`\t`)
+			default:
+				if c < 0x20 {
+					buf.WriteString(`\u00`)
+					buf.WriteByte(hexDigits[c>>4])
+					buf.WriteByte(hexDigits[c&0xF])
+				} else {
+					buf.WriteByte(c)
+				}
+			}
+
+			i++
+			continue
+		}
+
+		r, size := utf8.DecodeRune(b[i:])
+		if r == utf8.RuneError && size == 1 {
+			buf.WriteString(`\ufffd`)
+		} else {
+			buf.Write(b[i : i+size])
+		}
+
+		i += size
+	}
+}
+
+// makeJSONEscapeFunc wraps the bound function of a field whose values are quoted in the template, so that
+// they are escaped as the content of a JSON string. Values not needing escape are written as they are.
+func makeJSONEscapeFunc(boundF emitFNotReturn) emitFNotReturn {
+	var value bytes.Buffer
+	return func(state *genState, buf *bytes.Buffer) error {
+		start := buf.Len()
+		if err := boundF(state, buf); err != nil {
+			return err
+		}
+
+		if !needsJSONEscape(buf.Bytes()[start:]) {
+			return nil
+		}
+
+		value.Reset()
+		value.Write(buf.Bytes()[start:])
+		buf.Truncate(start)
+		writeJSONEscaped(buf, value.Bytes())
+
+		return nil
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/brianvoe/gofakeit/v7"
+)
+
+// fakerProvider generates the values of a faker provider, for the field types they are compatible with
+type fakerProvider struct {
+	generate   func(f *gofakeit.Faker) any
+	fieldTypes []string
+}
+
+// fakerStringFieldTypes are the field types compatible with the providers generating strings
+var fakerStringFieldTypes = []string{FieldTypeKeyword, FieldTypeConstantKeyword, FieldTypeText, FieldTypeMatchOnlyText, FieldTypeWildcard}
+
+// fakerNumberFieldTypes are the field types compatible with the providers generating numbers, that can be
+// stored in string fields as well
+var fakerNumberFieldTypes = append([]string{FieldTypeShort, FieldTypeInteger, FieldTypeLong, FieldTypeUnsignedLong, FieldTypeFloat, FieldTypeDouble}, fakerStringFieldTypes...)
+
+// stringFakerProvider returns a provider generating strings, compatible with the string field types and fieldTypes
+func stringFakerProvider(generate func(f *gofakeit.Faker) any, fieldTypes ...string) fakerProvider {
+	return fakerProvider{generate: generate, fieldTypes: append(fieldTypes, fakerStringFieldTypes...)}
+}
+
+// fakerProviders are the providers that can be selected with the `faker` config option, by name
+var fakerProviders = map[string]fakerProvider{
+	"app_name":       stringFakerProvider(func(f *gofakeit.Faker) any { return f.AppName() }),
+	"app_version":    stringFakerProvider(func(f *gofakeit.Faker) any { return f.AppVersion() }, FieldTypeVersion),
+	"city":           stringFakerProvider(func(f *gofakeit.Faker) any { return f.City() }),
+	"color":          stringFakerProvider(func(f *gofakeit.Faker) any { return f.Color() }),
+	"company":        stringFakerProvider(func(f *gofakeit.Faker) any { return f.Company() }),
+	"country":        stringFakerProvider(func(f *gofakeit.Faker) any { return f.Country() }),
+	"country_code":   stringFakerProvider(func(f *gofakeit.Faker) any { return f.CountryAbr() }),
+	"currency_code":  stringFakerProvider(func(f *gofakeit.Faker) any { return f.CurrencyShort() }),
+	"domain":         stringFakerProvider(func(f *gofakeit.Faker) any { return f.DomainName() }),
+	"email":          stringFakerProvider(func(f *gofakeit.Faker) any { return f.Email() }),
+	"file_extension": stringFakerProvider(func(f *gofakeit.Faker) any { return f.FileExtension() }),
+	"file_path":      stringFakerProvider(fakeFilePath),
+	"first_name":     stringFakerProvider(func(f *gofakeit.Faker) any { return f.FirstName() }),
+	"http_method":    stringFakerProvider(func(f *gofakeit.Faker) any { return f.HTTPMethod() }),
+	"http_status":    {generate: func(f *gofakeit.Faker) any { return int64(f.HTTPStatusCodeSimple()) }, fieldTypes: fakerNumberFieldTypes},
+	"http_version":   stringFakerProvider(func(f *gofakeit.Faker) any { return f.HTTPVersion() }),
+	"ipv4":           stringFakerProvider(func(f *gofakeit.Faker) any { return f.IPv4Address() }, FieldTypeIP),
+	"ipv6":           stringFakerProvider(func(f *gofakeit.Faker) any { return f.IPv6Address() }, FieldTypeIP),
+	"job_title":      stringFakerProvider(func(f *gofakeit.Faker) any { return f.JobTitle() }),
+	"language_code":  stringFakerProvider(func(f *gofakeit.Faker) any { return f.LanguageAbbreviation() }),
+	"last_name":      stringFakerProvider(func(f *gofakeit.Faker) any { return f.LastName() }),
+	"mac_address":    stringFakerProvider(func(f *gofakeit.Faker) any { return f.MacAddress() }),
+	"mime_type":      stringFakerProvider(func(f *gofakeit.Faker) any { return f.FileMimeType() }),
+	"name":           stringFakerProvider(func(f *gofakeit.Faker) any { return f.Name() }),
+	"phone":          stringFakerProvider(func(f *gofakeit.Faker) any { return f.Phone() }),
+	"product_name":   stringFakerProvider(func(f *gofakeit.Faker) any { return f.ProductName() }),
+	"state":          stringFakerProvider(func(f *gofakeit.Faker) any { return f.State() }),
+	"street":         stringFakerProvider(func(f *gofakeit.Faker) any { return f.Street() }),
+	"timezone":       stringFakerProvider(func(f *gofakeit.Faker) any { return f.TimeZone() }),
+	"url":            stringFakerProvider(func(f *gofakeit.Faker) any { return f.URL() }),
+	"user_agent":     stringFakerProvider(func(f *gofakeit.Faker) any { return f.UserAgent() }),
+	"username":       stringFakerProvider(func(f *gofakeit.Faker) any { return f.Username() }),
+	"uuid":           stringFakerProvider(func(f *gofakeit.Faker) any { return f.UUID() }),
+	"word":           stringFakerProvider(func(f *gofakeit.Faker) any { return f.Word() }),
+	"zip":            stringFakerProvider(func(f *gofakeit.Faker) any { return f.Zip() }),
+}
+
+// fakeFilePath returns an absolute unix file path, with a random depth between 1 and 4 directories
+func fakeFilePath(f *gofakeit.Faker) any {
+	depth := 1 + f.IntN(4)
+	parts := make([]string, 0, depth+1)
+	for i := 0; i < depth; i++ {
+		parts = append(parts, strings.ToLower(f.Word()))
+	}
+
+	parts = append(parts, strings.ToLower(f.Word())+"."+f.FileExtension())
+
+	return "/" + strings.Join(parts, "/")
+}
+
+func makeFakerFunc(fieldCfg ConfigField, field Field) (func(f *gofakeit.Faker) any, error) {
+	if err := fieldCfg.ValidFaker(); err != nil {
+		return nil, err
+	}
+
+	provider, ok := fakerProviders[fieldCfg.Faker]
+	if !ok {
+		names := make([]string, 0, len(fakerProviders))
+		for name := range fakerProviders {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+
+		return nil, fmt.Errorf("faker provider %s not supported, must be one of: %s", fieldCfg.Faker, strings.Join(names, ", "))
+	}
+
+	if !slices.Contains(provider.fieldTypes, field.Type) {
+		return nil, fmt.Errorf("field %s: faker provider %s not supported for %s fields, must be one of: %s", field.Name, fieldCfg.Faker, field.Type, strings.Join(provider.fieldTypes, ", "))
+	}
+
+	return provider.generate, nil
+}
+
+func bindFaker(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	fakerFunc, err := makeFakerFunc(fieldCfg, field)
+	if err != nil {
+		return err
+	}
+
+	var emitFNotReturn emitFNotReturn
+	emitFNotReturn = func(state *genState, buf *bytes.Buffer) error {
+		switch v := fakerFunc(state.faker).(type) {
+		case int64:
+			buf.WriteString(strconv.FormatInt(v, 10))
+		case string:
+			buf.WriteString(v)
+		}
+
+		return nil
+	}
+
+	fieldMap[field.Name] = emitFNotReturn
+	return nil
+}
+
+func bindFakerWithReturn(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	fakerFunc, err := makeFakerFunc(fieldCfg, field)
+	if err != nil {
+		return err
+	}
+
+	var emitF emitF
+	emitF = func(state *genState) any {
+		return fakerFunc(state.faker)
+	}
+
+	fieldMap[field.Name] = emitF
+	return nil
+}
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib/config"
 	"github.com/lithammer/shortuuid/v3"
 )
 
@@ -23,7 +24,7 @@ func fieldValueWrapByType(field Field) string {
 	}
 
 	switch field.Type {
-	case FieldTypeDate, FieldTypeIP:
+	case FieldTypeDate, FieldTypeDateNanos, FieldTypeIP:
 		return "\""
 	case FieldTypeDouble, FieldTypeFloat, FieldTypeHalfFloat, FieldTypeScaledFloat:
 		return ""
@@ -33,6 +34,10 @@ func fieldValueWrapByType(field Field) string {
 		return "\""
 	case FieldTypeKeyword:
 		return "\""
+	case FieldTypeText, FieldTypeMatchOnlyText, FieldTypeWildcard:
+		return "\""
+	case FieldTypeVersion:
+		return "\""
 	case FieldTypeBool:
 		return ""
 	case FieldTypeObject, FieldTypeNested, FieldTypeFlattened:
@@ -44,43 +49,135 @@ func fieldValueWrapByType(field Field) string {
 		return fieldValueWrapByType(field)
 	case FieldTypeGeoPoint:
 		return "\""
+	case FieldTypeHistogram, FieldTypeAggregateMetricDouble:
+		return ""
+	case FieldTypeDenseVector, FieldTypeSparseVector:
+		return ""
+	case FieldTypeIntegerRange, FieldTypeLongRange, FieldTypeFloatRange, FieldTypeDoubleRange, FieldTypeDateRange, FieldTypeIPRange:
+		return ""
 	default:
 		return "\""
 	}
 }
 
-func generateCustomTemplateFromField(cfg Config, fields Fields, state *genState) ([]byte, []Field) {
-	return generateTemplateFromField(cfg, fields, customTemplateEngine, state)
+func generateCustomTemplateFromField(cfg Config, fields Fields, nested bool, state *genState) ([]byte, []Field) {
+	return generateTemplateFromField(cfg, fields, customTemplateEngine, nested, state)
 }
 
-func generateTextTemplateFromField(cfg Config, fields Fields, state *genState) ([]byte, []Field) {
-	return generateTemplateFromField(cfg, fields, textTemplateEngine, state)
+func generateTextTemplateFromField(cfg Config, fields Fields, nested bool, state *genState) ([]byte, []Field) {
+	return generateTemplateFromField(cfg, fields, textTemplateEngine, nested, state)
 }
 
-func generateTemplateFromField(cfg Config, fields Fields, templateEngine int, state *genState) ([]byte, []Field) {
+// fieldValueWrapByConfig returns the wrap of the value of the field, taking into account any value override or format in the config.
+// Arrays are not wrapped, their elements are, see fieldElementWrapByConfig
+func fieldValueWrapByConfig(cfg Config, field Field) string {
+	fieldCfg, _ := cfg.GetField(field.Name)
+	if fieldCfg.Array != nil {
+		return ""
+	}
+
+	return fieldElementWrapByConfig(fieldCfg, field)
+}
+
+// fieldElementWrapByConfig returns the wrap of a single value of the field, taking into account any value override or format in the config
+func fieldElementWrapByConfig(fieldCfg ConfigField, field Field) string {
+	if fieldCfg.Value != nil {
+		return ""
+	}
+
+	if field.Type == FieldTypeGeoPoint && fieldCfg.GeoPoint != nil && fieldCfg.GeoPoint.IsJSON() {
+		return ""
+	}
+
+	if isDateField(field) && config.IsEpochDateFormat(fieldCfg.Format) {
+		return ""
+	}
+
+	return fieldValueWrapByType(field)
+}
+
+// isArrayField returns true for fields generating arrays of values
+func isArrayField(cfg Config, field Field) bool {
+	fieldCfg, _ := cfg.GetField(field.Name)
+	return fieldCfg.Array != nil
+}
+
+// isDynamicField returns true for fields whose keys are randomly generated on the fly
+func isDynamicField(field Field) bool {
+	return strings.HasSuffix(field.Name, ".*") || field.Type == FieldTypeObject || field.Type == FieldTypeNested || field.Type == FieldTypeFlattened
+}
+
+// isMissingField returns true for fields that can be missing from an event
+func isMissingField(cfg Config, field Field) bool {
+	fieldCfg, _ := cfg.GetField(field.Name)
+	return fieldCfg.MissingProbability > 0 && !isDynamicField(field)
+}
+
+// dynamicFieldKey returns a random key for a dynamic field, not already in dupes
+func dynamicFieldKey(state *genState, dupes map[string]struct{}) string {
+	var try int
+	const maxTries = 10
+	rNoun := state.faker.Noun()
+	_, ok := dupes[rNoun]
+	for ; ok && try < maxTries; try++ {
+		rNoun = state.faker.Noun()
+		_, ok = dupes[rNoun]
+	}
+
+	// If all else fails, use a shortuuid.
+	// Try to avoid this as it is alloc expensive
+	if try >= maxTries {
+		rNoun = shortuuid.New()
+	}
+
+	dupes[rNoun] = struct{}{}
+
+	return rNoun
+}
+
+// objectKeysFields returns the fields bound for the `object_keys` of an object field, or nil if it has none
+func objectKeysFields(cfg Config, field Field) Fields {
+	if field.Type != FieldTypeObject && field.Type != FieldTypeNested && field.Type != FieldTypeFlattened {
+		return nil
+	}
+
+	fieldCfg, _ := cfg.GetField(field.Name)
+	if len(fieldCfg.ObjectKeys) == 0 {
+		return nil
+	}
+
+	keyField := field
+	keyField.Type = FieldTypeKeyword
+	if len(field.ObjectType) > 0 {
+		keyField.Type = field.ObjectType
+	}
+
+	keysFields := make(Fields, 0, len(fieldCfg.ObjectKeys))
+	for _, objectKey := range fieldCfg.ObjectKeys {
+		keyField.Name = replacer.Replace(field.Name) + "." + objectKey
+		keysFields = append(keysFields, keyField)
+	}
+
+	return keysFields
+}
+
+func generateTemplateFromField(cfg Config, fields Fields, templateEngine int, nested bool, state *genState) ([]byte, []Field) {
 	if len(fields) == 0 {
 		return nil, nil
 	}
 
+	if nested {
+		return generateNestedTemplateFromField(cfg, fields, templateEngine, state)
+	}
+
 	dupes := make(map[string]struct{})
 	objectKeysField := make([]Field, 0, len(fields))
 
-	templatePrefix := "{ "
-	templateBuffer := bytes.NewBufferString(templatePrefix)
-	for i, field := range fields {
-		fieldWrap := fieldValueWrapByType(field)
-		if fieldCfg, ok := cfg.GetField(field.Name); ok {
-			if fieldCfg.Value != nil {
-				fieldWrap = ""
-			}
-		}
-
-		fieldTrailer := []byte(",")
-		if i == len(fields)-1 {
-			fieldTrailer = []byte(" }")
-		}
+	root := newTemplateObject()
+	for _, field := range fields {
+		fieldWrap := fieldValueWrapByConfig(cfg, field)
 
-		if strings.HasSuffix(field.Name, ".*") || field.Type == FieldTypeObject || field.Type == FieldTypeNested || field.Type == FieldTypeFlattened {
+		if isDynamicField(field) {
 			// This is a special case.  We are randomly generating keys on the fly
 			// Will set the json field name as "field.Name.N"
 			N := 5
@@ -90,42 +187,23 @@ func generateTemplateFromField(cfg Config, fields Fields, templateEngine int, st
 					continue
 				}
 
-				if string(fieldTrailer) == "}" && ii < N-1 {
-					fieldTrailer = []byte(",")
-				}
-
-				var try int
-				const maxTries = 10
-				rNoun := state.faker.Noun()
-				_, ok := dupes[rNoun]
-				for ; ok && try < maxTries; try++ {
-					rNoun = state.faker.Noun()
-					_, ok = dupes[rNoun]
-				}
-
-				// If all else fails, use a shortuuid.
-				// Try to avoid this as it is alloc expensive
-				if try >= maxTries {
-					rNoun = shortuuid.New()
-				}
-
-				dupes[rNoun] = struct{}{}
+				rNoun := dynamicFieldKey(state, dupes)
 				var fieldTemplate string
 
 				fieldNameRoot := replacer.Replace(field.Name)
 				fieldVariableName := fieldNormalizerRegex.ReplaceAllString(fmt.Sprintf("%s%s", fieldNameRoot, rNoun), "")
 				fieldVariableName += "Var"
-				if field.Type == FieldTypeDate {
+				if isDateField(field) {
 					if templateEngine == textTemplateEngine {
-						fieldTemplate = fmt.Sprintf(`{{ $%s := generate "%s.%s" }}"%s.%s": %s{{$%s.Format "2006-01-02T15:04:05.999999999Z07:00"}}%s%s`, fieldVariableName, fieldNameRoot, rNoun, fieldNameRoot, rNoun, fieldWrap, fieldVariableName, fieldWrap, fieldTrailer)
+						fieldTemplate = fmt.Sprintf(`{{ $%s := generate "%s.%s" }}"%s.%s": %s%s%s`, fieldVariableName, fieldNameRoot, rNoun, fieldNameRoot, rNoun, fieldWrap, dateValueTemplate(cfg, field, fieldVariableName), fieldWrap)
 					} else if templateEngine == customTemplateEngine {
-						fieldTemplate = fmt.Sprintf(`"%s.%s": %s{{.%s.%s}}%s%s`, fieldNameRoot, rNoun, fieldWrap, fieldNameRoot, rNoun, fieldWrap, fieldTrailer)
+						fieldTemplate = fmt.Sprintf(`"%s.%s": %s{{.%s.%s}}%s`, fieldNameRoot, rNoun, fieldWrap, fieldNameRoot, rNoun, fieldWrap)
 					}
 				} else {
 					if templateEngine == textTemplateEngine {
-						fieldTemplate = fmt.Sprintf(`"%s.%s": %s{{generate "%s.%s"}}%s%s`, fieldNameRoot, rNoun, fieldWrap, fieldNameRoot, rNoun, fieldWrap, fieldTrailer)
+						fieldTemplate = fmt.Sprintf(`"%s.%s": %s{{generate "%s.%s"}}%s`, fieldNameRoot, rNoun, fieldWrap, fieldNameRoot, rNoun, fieldWrap)
 					} else if templateEngine == customTemplateEngine {
-						fieldTemplate = fmt.Sprintf(`"%s.%s": %s{{.%s.%s}}%s%s`, fieldNameRoot, rNoun, fieldWrap, fieldNameRoot, rNoun, fieldWrap, fieldTrailer)
+						fieldTemplate = fmt.Sprintf(`"%s.%s": %s{{.%s.%s}}%s`, fieldNameRoot, rNoun, fieldWrap, fieldNameRoot, rNoun, fieldWrap)
 					}
 				}
 
@@ -134,30 +212,37 @@ func generateTemplateFromField(cfg Config, fields Fields, templateEngine int, st
 				objectKeysField = append(objectKeysField, field)
 				field.Name = originalFieldName
 
-				templateBuffer.WriteString(fieldTemplate)
+				root.addField(fieldTemplate)
 			}
+		} else if isMissingField(cfg, field) {
+			// the key is emitted only when the field is present
+			root.addMissingField(field, field.Name)
 		} else {
 			var fieldTemplate string
 			fieldVariableName := fieldNormalizerRegex.ReplaceAllString(field.Name, "")
 			fieldVariableName += "Var"
-			if field.Type == FieldTypeDate {
+			if isDateField(field) && !isArrayField(cfg, field) {
 				if templateEngine == textTemplateEngine {
-					fieldTemplate = fmt.Sprintf(`{{ $%s := generate "%s" }}"%s": %s{{$%s.Format "2006-01-02T15:04:05.999999999Z07:00"}}%s%s`, fieldVariableName, field.Name, field.Name, fieldWrap, fieldVariableName, fieldWrap, fieldTrailer)
+					fieldTemplate = fmt.Sprintf(`{{ $%s := generate "%s" }}"%s": %s%s%s`, fieldVariableName, field.Name, field.Name, fieldWrap, dateValueTemplate(cfg, field, fieldVariableName), fieldWrap)
 				} else if templateEngine == customTemplateEngine {
-					fieldTemplate = fmt.Sprintf(`"%s": %s{{.%s}}%s%s`, field.Name, fieldWrap, field.Name, fieldWrap, fieldTrailer)
+					fieldTemplate = fmt.Sprintf(`"%s": %s{{.%s}}%s`, field.Name, fieldWrap, field.Name, fieldWrap)
 				}
 			} else {
 				if templateEngine == textTemplateEngine {
-					fieldTemplate = fmt.Sprintf(`"%s": %s{{generate "%s"}}%s%s`, field.Name, fieldWrap, field.Name, fieldWrap, fieldTrailer)
+					fieldTemplate = fmt.Sprintf(`"%s": %s{{generate "%s"}}%s`, field.Name, fieldWrap, field.Name, fieldWrap)
 				} else if templateEngine == customTemplateEngine {
-					fieldTemplate = fmt.Sprintf(`"%s": %s{{.%s}}%s%s`, field.Name, fieldWrap, field.Name, fieldWrap, fieldTrailer)
+					fieldTemplate = fmt.Sprintf(`"%s": %s{{.%s}}%s`, field.Name, fieldWrap, field.Name, fieldWrap)
 				}
 			}
 
-			templateBuffer.WriteString(fieldTemplate)
+			root.addField(fieldTemplate)
 		}
 	}
 
+	templateBuffer := bytes.NewBufferString("")
+	var fieldSeparatorDeclared bool
+	root.write(templateBuffer, cfg, templateEngine, nil, state, &fieldSeparatorDeclared)
+
 	return templateBuffer.Bytes(), objectKeysField
 }
 
@@ -31,27 +31,44 @@ type (
 )
 
 const (
-	FieldTypeBool            = "boolean"
-	FieldTypeKeyword         = "keyword"
-	FieldTypeConstantKeyword = "constant_keyword"
-	FieldTypeDate            = "date"
-	FieldTypeIP              = "ip"
-	FieldTypeDouble          = "double"
-	FieldTypeFloat           = "float"
-	FieldTypeHalfFloat       = "half_float"
-	FieldTypeScaledFloat     = "scaled_float"
-	FieldTypeByte            = "byte"
-	FieldTypeShort           = "short"
-	FieldTypeInteger         = "integer"
-	FieldTypeLong            = "long"
-	FieldTypeUnsignedLong    = "unsigned_long"
-	FieldTypeObject          = "object"
-	FieldTypeNested          = "nested"
-	FieldTypeFlattened       = "flattened"
-	FieldTypeGeoPoint        = "geo_point"
+	FieldTypeBool                  = "boolean"
+	FieldTypeKeyword               = "keyword"
+	FieldTypeText                  = "text"
+	FieldTypeMatchOnlyText         = "match_only_text"
+	FieldTypeWildcard              = "wildcard"
+	FieldTypeVersion               = "version"
+	FieldTypeConstantKeyword       = "constant_keyword"
+	FieldTypeDate                  = "date"
+	FieldTypeDateNanos             = "date_nanos"
+	FieldTypeIP                    = "ip"
+	FieldTypeDouble                = "double"
+	FieldTypeFloat                 = "float"
+	FieldTypeHalfFloat             = "half_float"
+	FieldTypeScaledFloat           = "scaled_float"
+	FieldTypeByte                  = "byte"
+	FieldTypeShort                 = "short"
+	FieldTypeInteger               = "integer"
+	FieldTypeLong                  = "long"
+	FieldTypeUnsignedLong          = "unsigned_long"
+	FieldTypeObject                = "object"
+	FieldTypeNested                = "nested"
+	FieldTypeFlattened             = "flattened"
+	FieldTypeGeoPoint              = "geo_point"
+	FieldTypeHistogram             = "histogram"
+	FieldTypeAggregateMetricDouble = "aggregate_metric_double"
+	FieldTypeDenseVector           = "dense_vector"
+	FieldTypeSparseVector          = "sparse_vector"
+	FieldTypeIntegerRange          = "integer_range"
+	FieldTypeLongRange             = "long_range"
+	FieldTypeFloatRange            = "float_range"
+	FieldTypeDoubleRange           = "double_range"
+	FieldTypeDateRange             = "date_range"
+	FieldTypeIPRange               = "ip_range"
 
 	FieldTypeDurationSpan = 1000 // milliseconds
 	FieldTypeTimeLayout   = "2006-01-02T15:04:05.999999Z07:00"
+	// FieldTypeNanosTimeLayout is the layout of the values of `date_nanos` fields
+	FieldTypeNanosTimeLayout = "2006-01-02T15:04:05.000000000Z07:00"
 )
 
 var (
@@ -71,8 +88,6 @@ func getIntTypeBounds(fieldType string) (min int64, max int64) {
 		return math.MinInt32, math.MaxInt32
 	case FieldTypeLong:
 		return math.MinInt64, math.MaxInt64
-	case FieldTypeUnsignedLong:
-		return 0, math.MaxInt64 // TODO: generate > 63 bit values for unsigned_long
 	default:
 		// Default to long bounds
 		return math.MinInt64, math.MaxInt64
@@ -95,6 +110,8 @@ type genState struct {
 	rand *rand.Rand
 	// start time of the generator
 	startTime time.Time
+	// start time of the generator, never moved forward
+	originTime time.Time
 	// gofakeit instance
 	faker *gofakeit.Faker
 	// event counter
@@ -107,27 +124,89 @@ type genState struct {
 	prevCacheForDup map[string]map[any]struct{}
 	// previous cardinality value cache; necessary for cardinality
 	prevCacheCardinality map[string][]any
+	// previous cardinality counter cache; necessary for cardinality scoped to a parent field
+	prevCacheCardinalityCounter map[string]uint64
+	// functions generating the value of the fields other fields depend on
+	dependencyFuncs map[string]func(state *genState) (any, error)
+	// values generated in the current event for the fields other fields depend on
+	eventValues map[string]eventValue
+	// index of the entity picked in the current event for each entity pool
+	entityIndexes map[string]eventValue
+	// arrival state of the date fields with `arrival`
+	arrivals map[string]*arrivalState
+	// position in the auto-generated template of the fields that can be missing
+	missingFieldPositions map[string]missingFieldPosition
+	// counter of the last event where a field that can be missing has been emitted, for each object of the
+	// auto-generated template where no field is always emitted
+	emittedObjects map[string]uint64
 	// internal buffer pool to decrease load on GC
 	pool sync.Pool
 }
 
 func newGenState(randSeed int64, startTime time.Time) *genState {
 	return &genState{
-		prevCache:            make(map[string]any),
-		prevCacheForDup:      make(map[string]map[any]struct{}),
-		prevCacheCardinality: make(map[string][]any, 0),
+		prevCache:                   make(map[string]any),
+		prevCacheForDup:             make(map[string]map[any]struct{}),
+		prevCacheCardinality:        make(map[string][]any, 0),
+		prevCacheCardinalityCounter: make(map[string]uint64),
+		dependencyFuncs:             make(map[string]func(state *genState) (any, error)),
+		eventValues:                 make(map[string]eventValue),
+		entityIndexes:               make(map[string]eventValue),
+		arrivals:                    make(map[string]*arrivalState),
+		missingFieldPositions:       make(map[string]missingFieldPosition),
+		emittedObjects:              make(map[string]uint64),
 		pool: sync.Pool{
 			New: func() any {
 				return new(bytes.Buffer)
 			},
 		},
-		rand:      rand.New(rand.NewSource(randSeed)),
-		faker:     gofakeit.New(uint64(randSeed)),
-		startTime: startTime,
+		rand:       rand.New(rand.NewSource(randSeed)),
+		faker:      gofakeit.New(uint64(randSeed)),
+		startTime:  startTime,
+		originTime: startTime,
 	}
 }
 
 func bindField(cfg Config, field Field, fieldMap map[string]any, withReturn bool) error {
+	fieldCfg, _ := cfg.GetField(field.Name)
+	if err := fieldCfg.ValidMissingProbability(); err != nil {
+		return err
+	}
+
+	if err := fieldCfg.ValidCardinality(); err != nil {
+		return err
+	}
+
+	if len(fieldCfg.WeightedEnum) > 0 && field.Type != FieldTypeKeyword && field.Type != FieldTypeVersion {
+		return fmt.Errorf("field %s: `weighted_enum` is only supported for keyword and version fields", field.Name)
+	}
+
+	if err := bindFieldValue(cfg, fieldCfg, field, fieldMap, withReturn); err != nil {
+		return err
+	}
+
+	if fieldCfg.Array != nil {
+		if withReturn {
+			if err := bindArrayWithReturn(fieldCfg, field, fieldMap); err != nil {
+				return err
+			}
+		} else {
+			if err := bindArray(fieldCfg, field, fieldMap); err != nil {
+				return err
+			}
+		}
+	}
+
+	// The placeholder engine omits missing fields in the auto-generated template, where the whole key is emitted,
+	// see makeMissingFieldStub
+	if withReturn && fieldCfg.MissingProbability > 0 {
+		return bindMissingWithReturn(fieldCfg, field, fieldMap)
+	}
+
+	return nil
+}
+
+func bindFieldValue(cfg Config, fieldCfg ConfigField, field Field, fieldMap map[string]any, withReturn bool) error {
 	// Check for hardcoded field value
 	if len(field.Value) > 0 {
 		if withReturn {
@@ -138,7 +217,6 @@ func bindField(cfg Config, field Field, fieldMap map[string]any, withReturn bool
 	}
 
 	// Check config override of value
-	fieldCfg, _ := cfg.GetField(field.Name)
 	if fieldCfg.Value != nil {
 		if withReturn {
 			return bindStaticWithReturn(field, fieldCfg.Value, fieldMap)
@@ -147,7 +225,16 @@ func bindField(cfg Config, field Field, fieldMap map[string]any, withReturn bool
 		}
 	}
 
-	if fieldCfg.Cardinality > 0 {
+	if len(fieldCfg.Derive) > 0 {
+		if withReturn {
+			return bindDeriveWithReturn(cfg, fieldCfg, field, fieldMap)
+		} else {
+			return bindDerive(cfg, fieldCfg, field, fieldMap)
+		}
+	}
+
+	// date counters keep a series of values for each entity, see makeDateCounterFunc
+	if fieldCfg.Cardinality > 0 || (len(fieldCfg.Entity) > 0 && !(fieldCfg.Counter && isDateField(field))) {
 		if withReturn {
 			return bindCardinalityWithReturn(cfg, field, fieldMap)
 		} else {
@@ -195,25 +282,44 @@ func bindByType(cfg Config, field Field, fieldMap map[string]any) (err error) {
 
 	fieldCfg, _ := cfg.GetField(field.Name)
 
+	// Faker providers generate values for any field type
+	if len(fieldCfg.Faker) > 0 {
+		return bindFaker(fieldCfg, field, fieldMap)
+	}
+
 	switch field.Type {
-	case FieldTypeDate:
-		err = bindNearTime(fieldCfg, field, fieldMap)
+	case FieldTypeDate, FieldTypeDateNanos:
+		err = bindNearTime(cfg, fieldCfg, field, fieldMap)
 	case FieldTypeIP:
-		err = bindIP(field, fieldMap)
+		err = bindIP(fieldCfg, field, fieldMap)
 	case FieldTypeDouble, FieldTypeFloat, FieldTypeHalfFloat, FieldTypeScaledFloat:
 		err = bindDouble(fieldCfg, field, fieldMap)
-	case FieldTypeByte, FieldTypeShort, FieldTypeInteger, FieldTypeLong, FieldTypeUnsignedLong: // TODO: generate > 63 bit values for unsigned_long
+	case FieldTypeByte, FieldTypeShort, FieldTypeInteger, FieldTypeLong:
 		err = bindLong(fieldCfg, field, fieldMap)
+	case FieldTypeUnsignedLong:
+		err = bindUnsignedLong(fieldCfg, field, fieldMap)
 	case FieldTypeConstantKeyword:
 		err = bindConstantKeyword(field, fieldMap)
 	case FieldTypeKeyword:
 		err = bindKeyword(fieldCfg, field, fieldMap)
+	case FieldTypeText, FieldTypeMatchOnlyText, FieldTypeWildcard:
+		err = bindText(fieldCfg, field, fieldMap)
+	case FieldTypeVersion:
+		err = bindVersion(fieldCfg, field, fieldMap)
 	case FieldTypeBool:
 		err = bindBool(field, fieldMap)
 	case FieldTypeObject, FieldTypeNested, FieldTypeFlattened:
 		err = bindObject(cfg, fieldCfg, field, fieldMap)
 	case FieldTypeGeoPoint:
-		err = bindGeoPoint(field, fieldMap)
+		err = bindGeoPoint(fieldCfg, field, fieldMap)
+	case FieldTypeHistogram:
+		err = bindHistogram(fieldCfg, field, fieldMap)
+	case FieldTypeAggregateMetricDouble:
+		err = bindAggregateMetricDouble(fieldCfg, field, fieldMap)
+	case FieldTypeDenseVector, FieldTypeSparseVector:
+		err = bindVector(fieldCfg, field, fieldMap)
+	case FieldTypeIntegerRange, FieldTypeLongRange, FieldTypeFloatRange, FieldTypeDoubleRange, FieldTypeDateRange, FieldTypeIPRange:
+		err = bindRange(fieldCfg, field, fieldMap)
 	default:
 		err = bindWordN(field, 25, fieldMap)
 	}
@@ -224,25 +330,44 @@ func bindByType(cfg Config, field Field, fieldMap map[string]any) (err error) {
 func bindByTypeWithReturn(cfg Config, field Field, fieldMap map[string]any) (err error) {
 	fieldCfg, _ := cfg.GetField(field.Name)
 
+	// Faker providers generate values for any field type
+	if len(fieldCfg.Faker) > 0 {
+		return bindFakerWithReturn(fieldCfg, field, fieldMap)
+	}
+
 	switch field.Type {
-	case FieldTypeDate:
-		err = bindNearTimeWithReturn(fieldCfg, field, fieldMap)
+	case FieldTypeDate, FieldTypeDateNanos:
+		err = bindNearTimeWithReturn(cfg, fieldCfg, field, fieldMap)
 	case FieldTypeIP:
-		err = bindIPWithReturn(field, fieldMap)
+		err = bindIPWithReturn(fieldCfg, field, fieldMap)
 	case FieldTypeDouble, FieldTypeFloat, FieldTypeHalfFloat, FieldTypeScaledFloat:
 		err = bindDoubleWithReturn(fieldCfg, field, fieldMap)
-	case FieldTypeByte, FieldTypeShort, FieldTypeInteger, FieldTypeLong, FieldTypeUnsignedLong: // TODO: generate > 63 bit values for unsigned_long
+	case FieldTypeByte, FieldTypeShort, FieldTypeInteger, FieldTypeLong:
 		err = bindLongWithReturn(fieldCfg, field, fieldMap)
+	case FieldTypeUnsignedLong:
+		err = bindUnsignedLongWithReturn(fieldCfg, field, fieldMap)
 	case FieldTypeConstantKeyword:
 		err = bindConstantKeywordWithReturn(field, fieldMap)
 	case FieldTypeKeyword:
 		err = bindKeywordWithReturn(fieldCfg, field, fieldMap)
+	case FieldTypeText, FieldTypeMatchOnlyText, FieldTypeWildcard:
+		err = bindTextWithReturn(fieldCfg, field, fieldMap)
+	case FieldTypeVersion:
+		err = bindVersionWithReturn(fieldCfg, field, fieldMap)
 	case FieldTypeBool:
 		err = bindBoolWithReturn(field, fieldMap)
 	case FieldTypeObject, FieldTypeNested, FieldTypeFlattened:
 		err = bindObjectWithReturn(cfg, fieldCfg, field, fieldMap)
 	case FieldTypeGeoPoint:
-		err = bindGeoPointWithReturn(field, fieldMap)
+		err = bindGeoPointWithReturn(fieldCfg, field, fieldMap)
+	case FieldTypeHistogram:
+		err = bindHistogramWithReturn(fieldCfg, field, fieldMap)
+	case FieldTypeAggregateMetricDouble:
+		err = bindAggregateMetricDoubleWithReturn(fieldCfg, field, fieldMap)
+	case FieldTypeDenseVector, FieldTypeSparseVector:
+		err = bindVectorWithReturn(fieldCfg, field, fieldMap)
+	case FieldTypeIntegerRange, FieldTypeLongRange, FieldTypeFloatRange, FieldTypeDoubleRange, FieldTypeDateRange, FieldTypeIPRange:
+		err = bindRangeWithReturn(fieldCfg, field, fieldMap)
 	default:
 		err = bindWordNWithReturn(field, 25, fieldMap)
 	}
@@ -260,6 +385,20 @@ func makeFloatFunc(r *rand.Rand, fieldCfg ConfigField, field Field) func() float
 
 	var dummyFunc func() float64
 
+	if fieldCfg.Distribution != nil {
+		distributionMin, err := fieldCfg.Range.MinAsFloat64()
+		if err != nil {
+			distributionMin = -math.MaxFloat64
+		}
+
+		distributionMax, err := fieldCfg.Range.MaxAsFloat64()
+		if err != nil {
+			distributionMax = math.MaxFloat64
+		}
+
+		return makeDistributionFunc(r, *fieldCfg.Distribution, distributionMin, distributionMax)
+	}
+
 	switch {
 	case maxValue > 0:
 		dummyFunc = func() float64 { return minValue + r.Float64()*(maxValue-minValue) }
@@ -293,6 +432,13 @@ func makeIntFunc(r *rand.Rand, fieldCfg ConfigField, field Field) (func() int64,
 		return nil, fmt.Errorf("invalid range: min %d greater than max %d", minValue, maxValue)
 	}
 
+	if fieldCfg.Distribution != nil {
+		distributionFunc := makeDistributionFunc(r, *fieldCfg.Distribution, float64(minValue), float64(maxValue))
+		return func() int64 {
+			return clampFloat64ToInt64(math.Round(distributionFunc()), minValue, maxValue)
+		}, nil
+	}
+
 	// reinterprets bits (two's complement)
 	umin := uint64(minValue)
 	umax := uint64(maxValue)
@@ -303,33 +449,9 @@ func makeIntFunc(r *rand.Rand, fieldCfg ConfigField, field Field) (func() int64,
 
 	switch {
 	case span > 0:
-		// number of distinct values in the range, in uint64
-		n := span + 1
-
-		// uint64 overflow, no rejections needed
-		if n == 0 {
-			dummyFunc = func() int64 {
-				// uniform in [min, max]
-				return int64(r.Uint64())
-			}
-			break
-		}
-
-		// the largest multiple of n that fits in a uint64
-		limit := ^uint64(0) - (^uint64(0) % n)
-
+		uintFunc := makeUint64RangeFunc(r, umin, umax)
 		dummyFunc = func() int64 {
-			for {
-				// uniform in [0, 2^64)
-				u := r.Uint64()
-				// accept only values in a multiple of n
-				if u < limit {
-					// uniform in [0, n)
-					x := u % n
-					// uniform in [min, max]
-					return int64(umin + x)
-				}
-			}
+			return int64(uintFunc())
 		}
 	case len(field.Example) == 0:
 		dummyFunc = func() int64 { return r.Int63n(10) }
@@ -344,6 +466,19 @@ func makeIntFunc(r *rand.Rand, fieldCfg ConfigField, field Field) (func() int64,
 	return dummyFunc, nil
 }
 
+// clampFloat64ToInt64 converts v to int64 within min and max, taking care of float64 not representing exactly the int64 bounds
+func clampFloat64ToInt64(v float64, min, max int64) int64 {
+	if v >= float64(max) {
+		return max
+	}
+
+	if v <= float64(min) {
+		return min
+	}
+
+	return int64(v)
+}
+
 func bindObject(cfg Config, fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
 	if len(field.ObjectType) > 0 {
 		field.Type = field.ObjectType
@@ -436,8 +571,57 @@ func bindConstantKeyword(field Field, fieldMap map[string]any) error {
 	return nil
 }
 
+// makeWeightedEnumFunc returns a function picking the values of the `weighted_enum` of the field according to
+// their weights. The weights are validated here as well, since configs built without LoadConfigFromYaml are not.
+func makeWeightedEnumFunc(fieldCfg ConfigField) (func(r *rand.Rand) string, error) {
+	if err := fieldCfg.ValidWeightedEnum(); err != nil {
+		return nil, err
+	}
+
+	weights := make([]float64, 0, len(fieldCfg.WeightedEnum))
+	for _, weightedEnum := range fieldCfg.WeightedEnum {
+		weights = append(weights, weightedEnum.Weight)
+	}
+
+	table := newAliasTable(weights)
+
+	return func(r *rand.Rand) string {
+		return fieldCfg.WeightedEnum[table.sample(r)].Value
+	}, nil
+}
+
 func bindKeyword(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
-	if len(fieldCfg.Enum) > 0 {
+	if err := fieldCfg.ValidPattern(); err != nil {
+		return err
+	}
+
+	if len(fieldCfg.Pattern) > 0 {
+		patternFunc, err := makePatternFunc(fieldCfg.Pattern)
+		if err != nil {
+			return err
+		}
+
+		var emitFNotReturn emitFNotReturn
+		emitFNotReturn = func(state *genState, buf *bytes.Buffer) error {
+			buf.WriteString(patternFunc(state.rand))
+			return nil
+		}
+
+		fieldMap[field.Name] = emitFNotReturn
+	} else if len(fieldCfg.WeightedEnum) > 0 {
+		weightedEnumFunc, err := makeWeightedEnumFunc(fieldCfg)
+		if err != nil {
+			return err
+		}
+
+		var emitFNotReturn emitFNotReturn
+		emitFNotReturn = func(state *genState, buf *bytes.Buffer) error {
+			buf.WriteString(weightedEnumFunc(state.rand))
+			return nil
+		}
+
+		fieldMap[field.Name] = emitFNotReturn
+	} else if len(fieldCfg.Enum) > 0 {
 		var emitFNotReturn emitFNotReturn
 		emitFNotReturn = func(state *genState, buf *bytes.Buffer) error {
 			idx := state.rand.Intn(len(fieldCfg.Enum))
@@ -526,7 +710,23 @@ func bindBool(field Field, fieldMap map[string]any) error {
 	return nil
 }
 
-func bindGeoPoint(field Field, fieldMap map[string]any) error {
+func bindGeoPoint(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	if fieldCfg.GeoPoint != nil {
+		geoPointFunc, err := makeGeoPointFunc(*fieldCfg.GeoPoint)
+		if err != nil {
+			return err
+		}
+
+		var emitFNotReturn emitFNotReturn
+		emitFNotReturn = func(state *genState, buf *bytes.Buffer) error {
+			_, err := fmt.Fprint(buf, geoPointFunc(state.rand))
+			return err
+		}
+
+		fieldMap[field.Name] = emitFNotReturn
+		return nil
+	}
+
 	var emitFNotReturn emitFNotReturn
 	emitFNotReturn = func(state *genState, buf *bytes.Buffer) error {
 		lat, latD, long, longD := randGeoPoint(state.rand)
@@ -549,22 +749,114 @@ func bindWordN(field Field, n int, fieldMap map[string]any) error {
 	return nil
 }
 
-func bindNearTime(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+func bindNearTime(cfg Config, fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
 	if err := fieldCfg.ValidForDateField(); err != nil {
 		return err
 	}
 
+	if err := fieldCfg.ValidDateFormat(); err != nil {
+		return err
+	}
+
+	timeFunc, err := makeTimeFunc(cfg, fieldCfg, field)
+	if err != nil {
+		return err
+	}
+
 	var emitFNotReturn emitFNotReturn
 	emitFNotReturn = func(state *genState, buf *bytes.Buffer) error {
-		newTime := nearTime(fieldCfg, state)
+		newTime, err := timeFunc(state)
+		if err != nil {
+			return err
+		}
 
-		buf.WriteString(newTime.Format(FieldTypeTimeLayout))
+		state.prevCache[field.Name] = newTime
+
+		buf.Write(appendDate(buf.AvailableBuffer(), fieldCfg, field, newTime))
 		return nil
 	}
 	fieldMap[field.Name] = emitFNotReturn
 	return nil
 }
 
+// dateFunc generates the value of a date field in the current event
+type dateFunc func(state *genState) (time.Time, error)
+
+// makeTimeFunc returns a function generating the values of date fields, according to their `counter`, `arrival`,
+// `relative_to` and `out_of_order`, if any
+func makeTimeFunc(cfg Config, fieldCfg ConfigField, field Field) (dateFunc, error) {
+	var timeFunc dateFunc = func(state *genState) (time.Time, error) {
+		return nearTime(fieldCfg, state), nil
+	}
+
+	var err error
+	switch {
+	case fieldCfg.Counter:
+		timeFunc, err = makeDateCounterFunc(cfg, fieldCfg, field)
+	case fieldCfg.Arrival != nil:
+		timeFunc, err = makeArrivalFunc(fieldCfg)
+	case fieldCfg.RelativeTo != nil:
+		timeFunc, err = makeRelativeTimeFunc(fieldCfg)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if fieldCfg.OutOfOrder != nil {
+		return makeOutOfOrderFunc(*fieldCfg.OutOfOrder, timeFunc)
+	}
+
+	return timeFunc, nil
+}
+
+// makeDateCounterFunc returns a function generating the values of date fields with `counter`, advancing by `period`
+// from the previous value, kept in prevCache, and moved by a random delta of up to `fuzziness` times `period`. The
+// delta does not accumulate, so the values stay evenly spaced. When the field references an entity pool, each entity
+// has its own series of values, advancing only in the events of the entity.
+func makeDateCounterFunc(cfg Config, fieldCfg ConfigField, field Field) (dateFunc, error) {
+	var entity config.Entity
+	if len(fieldCfg.Entity) > 0 {
+		var ok bool
+		entity, ok = cfg.GetEntity(fieldCfg.Entity)
+		if !ok {
+			return nil, fmt.Errorf("field %s: entity %s not defined", field.Name, fieldCfg.Entity)
+		}
+	}
+
+	// the keys in prevCache of the values of each series, without the random delta
+	seriesKeys := make([]string, max(entity.Size, 1))
+	for i := range seriesKeys {
+		seriesKeys[i] = fmt.Sprintf("%s#%d", field.Name, i)
+	}
+
+	maxDelta := fieldCfg.Fuzziness * float64(fieldCfg.Period)
+
+	return func(state *genState) (time.Time, error) {
+		seriesKey := seriesKeys[0]
+		if entity.Size > 0 {
+			seriesKey = seriesKeys[state.entityIndex(entity)]
+		}
+
+		newTime, ok := state.prevCache[seriesKey].(time.Time)
+		if ok {
+			newTime = newTime.Add(fieldCfg.Period)
+		} else if from, err := fieldCfg.Range.FromAsTime(); err == nil {
+			newTime = from
+		} else {
+			newTime = state.originTime
+		}
+
+		state.prevCache[seriesKey] = newTime
+
+		if maxDelta > 0 {
+			newTime = newTime.Add(time.Duration((2*state.rand.Float64() - 1) * maxDelta))
+		}
+
+		return newTime, nil
+	}, nil
+}
+
 func nearTime(fieldCfg ConfigField, state *genState) time.Time {
 	var offset time.Duration
 	from, errFrom := fieldCfg.Range.FromAsTime()
@@ -608,7 +900,23 @@ func nearTime(fieldCfg ConfigField, state *genState) time.Time {
 	return newTime
 }
 
-func bindIP(field Field, fieldMap map[string]any) error {
+func bindIP(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	if fieldCfg.IP != nil {
+		ipFunc, err := makeIPFunc(*fieldCfg.IP)
+		if err != nil {
+			return err
+		}
+
+		var emitFNotReturn emitFNotReturn
+		emitFNotReturn = func(state *genState, buf *bytes.Buffer) error {
+			buf.WriteString(ipFunc(state.rand))
+			return nil
+		}
+
+		fieldMap[field.Name] = emitFNotReturn
+		return nil
+	}
+
 	var emitFNotReturn emitFNotReturn
 	emitFNotReturn = func(state *genState, buf *bytes.Buffer) error {
 		i0, i1, i2, i3 := randIP(state.rand)
@@ -641,6 +949,14 @@ func bindLong(fieldCfg ConfigField, field Field, fieldMap map[string]any) error
 		return err
 	}
 
+	if err := fieldCfg.ValidDistribution(); err != nil {
+		return err
+	}
+
+	if err := fieldCfg.ValidShape(); err != nil {
+		return err
+	}
+
 	if fieldCfg.Counter {
 		var emitFNotReturn emitFNotReturn
 		emitFNotReturn = func(state *genState, buf *bytes.Buffer) error {
@@ -672,6 +988,26 @@ func bindLong(fieldCfg ConfigField, field Field, fieldMap map[string]any) error
 		return nil
 	}
 
+	if fieldCfg.Shape != nil {
+		shapeFunc := makeIntShapeFunc(fieldCfg, field)
+		var emitFNotReturn emitFNotReturn
+		emitFNotReturn = func(state *genState, buf *bytes.Buffer) error {
+			value, err := shapeFunc(state)
+			if err != nil {
+				return err
+			}
+
+			v := make([]byte, 0, 32)
+			v = strconv.AppendInt(v, value, 10)
+			buf.Write(v)
+			return nil
+		}
+
+		fieldMap[field.Name] = emitFNotReturn
+
+		return nil
+	}
+
 	if fieldCfg.Fuzziness <= 0 {
 		var emitFNotReturn emitFNotReturn
 		emitFNotReturn = func(state *genState, buf *bytes.Buffer) error {
@@ -738,6 +1074,14 @@ func bindDouble(fieldCfg ConfigField, field Field, fieldMap map[string]any) erro
 		return err
 	}
 
+	if err := fieldCfg.ValidDistribution(); err != nil {
+		return err
+	}
+
+	if err := fieldCfg.ValidShape(); err != nil {
+		return err
+	}
+
 	if fieldCfg.Counter {
 		var emitFNotReturn emitFNotReturn
 		emitFNotReturn = func(state *genState, buf *bytes.Buffer) error {
@@ -767,6 +1111,24 @@ func bindDouble(fieldCfg ConfigField, field Field, fieldMap map[string]any) erro
 		return nil
 	}
 
+	if fieldCfg.Shape != nil {
+		shapeFunc := makeFloatShapeFunc(fieldCfg)
+		var emitFNotReturn emitFNotReturn
+		emitFNotReturn = func(state *genState, buf *bytes.Buffer) error {
+			value, err := shapeFunc(state)
+			if err != nil {
+				return err
+			}
+
+			_, err = fmt.Fprintf(buf, "%f", value)
+			return err
+		}
+
+		fieldMap[field.Name] = emitFNotReturn
+
+		return nil
+	}
+
 	if fieldCfg.Fuzziness <= 0 {
 		var emitFNotReturn emitFNotReturn
 		emitFNotReturn = func(state *genState, buf *bytes.Buffer) error {
@@ -802,11 +1164,62 @@ func bindDouble(fieldCfg ConfigField, field Field, fieldMap map[string]any) erro
 	return nil
 }
 
+// cardinalityDupeTries returns how many values are generated, at most, looking for one not already cached
+func cardinalityDupeTries(fieldCfg ConfigField) int {
+	// weighted enum values are meant to repeat: without dupe detection the cached
+	// values follow the configured weights instead of being flattened towards an
+	// uniform distribution
+	if len(fieldCfg.WeightedEnum) > 0 {
+		return 1
+	}
+
+	// the same goes for enum values shared by the entities of a pool
+	if len(fieldCfg.Entity) > 0 && len(fieldCfg.Enum) > 0 {
+		return 1
+	}
+
+	return 11 // "These go to 11."
+}
+
+// cardinalityCacheKey returns the key of the cardinality cache for the field in the current event, and
+// the counter to pick the value from the cache: when the cardinality is scoped to a parent field the values
+// are cached and picked in turn for each different value of the parent field, when the field references
+// an entity pool a single value is cached for each entity of the pool
+func cardinalityCacheKey(state *genState, fieldCfg ConfigField, entity config.Entity, field Field) (string, uint64, error) {
+	if entity.Size > 0 {
+		return field.Name + "\x00" + strconv.Itoa(state.entityIndex(entity)), 0, nil
+	}
+
+	if len(fieldCfg.CardinalityPer) == 0 {
+		return field.Name, state.counter, nil
+	}
+
+	parentValue, err := state.fieldValue(fieldCfg.CardinalityPer)
+	if err != nil {
+		return "", 0, err
+	}
+
+	cacheKey := field.Name + "\x00" + fieldValueKey(parentValue)
+	counter := state.prevCacheCardinalityCounter[cacheKey]
+	state.prevCacheCardinalityCounter[cacheKey] = counter + 1
+
+	return cacheKey, counter, nil
+}
+
 func bindCardinality(cfg Config, field Field, fieldMap map[string]any) error {
 
 	fieldCfg, _ := cfg.GetField(field.Name)
 	cardinality := fieldCfg.Cardinality
 
+	entity, err := fieldEntity(cfg, fieldCfg)
+	if err != nil {
+		return err
+	}
+
+	if entity.Size > 0 {
+		cardinality = 1
+	}
+
 	if strings.HasSuffix(field.Name, ".*") {
 		field.Name = replacer.Replace(field.Name)
 	}
@@ -824,12 +1237,18 @@ func bindCardinality(cfg Config, field Field, fieldMap map[string]any) error {
 
 	var emitFNotReturn emitFNotReturn
 	emitFNotReturn = func(state *genState, buf *bytes.Buffer) error {
+		// Values are cached for each value of the parent field, if any
+		cacheKey, counter, err := cardinalityCacheKey(state, fieldCfg, entity, field)
+		if err != nil {
+			return err
+		}
+
 		// Have we rolled over once?  If not, generate a value and cache it.
-		if len(state.prevCacheCardinality[field.Name]) < cardinality {
+		if len(state.prevCacheCardinality[cacheKey]) < cardinality {
 
 			// Do college try dupe detection on value;
 			// Allow dupe if no unique value in nTries.
-			nTries := 11 // "These go to 11."
+			nTries := cardinalityDupeTries(fieldCfg)
 			var tmp bytes.Buffer
 			var value []byte
 			for i := 0; i < nTries; i++ {
@@ -846,17 +1265,17 @@ func bindCardinality(cfg Config, field Field, fieldMap map[string]any) error {
 			}
 
 			state.prevCacheForDup[field.Name][string(value)] = struct{}{}
-			state.prevCacheCardinality[field.Name] = append(state.prevCacheCardinality[field.Name], value)
+			state.prevCacheCardinality[cacheKey] = append(state.prevCacheCardinality[cacheKey], value)
 		}
 
-		idx := int(state.counter % uint64(cardinality))
+		idx := int(counter % uint64(cardinality))
 
 		// Safety check; should be a noop
-		if idx >= len(state.prevCacheCardinality[field.Name]) {
-			idx = len(state.prevCacheCardinality[field.Name]) - 1
+		if idx >= len(state.prevCacheCardinality[cacheKey]) {
+			idx = len(state.prevCacheCardinality[cacheKey]) - 1
 		}
 
-		choice := state.prevCacheCardinality[field.Name][idx].([]byte)
+		choice := state.prevCacheCardinality[cacheKey][idx].([]byte)
 		buf.Write(choice)
 		return nil
 	}
@@ -911,7 +1330,35 @@ func bindConstantKeywordWithReturn(field Field, fieldMap map[string]any) error {
 }
 
 func bindKeywordWithReturn(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
-	if len(fieldCfg.Enum) > 0 {
+	if err := fieldCfg.ValidPattern(); err != nil {
+		return err
+	}
+
+	if len(fieldCfg.Pattern) > 0 {
+		patternFunc, err := makePatternFunc(fieldCfg.Pattern)
+		if err != nil {
+			return err
+		}
+
+		var emitF emitF
+		emitF = func(state *genState) any {
+			return patternFunc(state.rand)
+		}
+
+		fieldMap[field.Name] = emitF
+	} else if len(fieldCfg.WeightedEnum) > 0 {
+		weightedEnumFunc, err := makeWeightedEnumFunc(fieldCfg)
+		if err != nil {
+			return err
+		}
+
+		var emitF emitF
+		emitF = func(state *genState) any {
+			return weightedEnumFunc(state.rand)
+		}
+
+		fieldMap[field.Name] = emitF
+	} else if len(fieldCfg.Enum) > 0 {
 		var emitF emitF
 		emitF = func(state *genState) any {
 			idx := state.rand.Intn(len(fieldCfg.Enum))
@@ -975,7 +1422,22 @@ func bindBoolWithReturn(field Field, fieldMap map[string]any) error {
 	return nil
 }
 
-func bindGeoPointWithReturn(field Field, fieldMap map[string]any) error {
+func bindGeoPointWithReturn(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	if fieldCfg.GeoPoint != nil {
+		geoPointFunc, err := makeGeoPointFunc(*fieldCfg.GeoPoint)
+		if err != nil {
+			return err
+		}
+
+		var emitF emitF
+		emitF = func(state *genState) any {
+			return geoPointFunc(state.rand)
+		}
+
+		fieldMap[field.Name] = emitF
+		return nil
+	}
+
 	var emitF emitF
 	emitF = func(state *genState) any {
 		lat, latD, long, longD := randGeoPoint(state.rand)
@@ -996,21 +1458,52 @@ func bindWordNWithReturn(field Field, n int, fieldMap map[string]any) error {
 	return nil
 }
 
-func bindNearTimeWithReturn(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+func bindNearTimeWithReturn(cfg Config, fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
 	if err := fieldCfg.ValidForDateField(); err != nil {
 		return err
 	}
 
+	if err := fieldCfg.ValidDateFormat(); err != nil {
+		return err
+	}
+
+	timeFunc, err := makeTimeFunc(cfg, fieldCfg, field)
+	if err != nil {
+		return err
+	}
+
 	var emitF emitF
 	emitF = func(state *genState) any {
-		return nearTime(fieldCfg, state)
+		newTime, err := timeFunc(state)
+		if err != nil {
+			panic(err)
+		}
+
+		state.prevCache[field.Name] = newTime
+
+		return newTime
 	}
 
 	fieldMap[field.Name] = emitF
 	return nil
 }
 
-func bindIPWithReturn(field Field, fieldMap map[string]any) error {
+func bindIPWithReturn(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	if fieldCfg.IP != nil {
+		ipFunc, err := makeIPFunc(*fieldCfg.IP)
+		if err != nil {
+			return err
+		}
+
+		var emitF emitF
+		emitF = func(state *genState) any {
+			return ipFunc(state.rand)
+		}
+
+		fieldMap[field.Name] = emitF
+		return nil
+	}
+
 	var emitF emitF
 	emitF = func(state *genState) any {
 		i0, i1, i2, i3 := randIP(state.rand)
@@ -1034,6 +1527,14 @@ func bindLongWithReturn(fieldCfg ConfigField, field Field, fieldMap map[string]a
 		return err
 	}
 
+	if err := fieldCfg.ValidDistribution(); err != nil {
+		return err
+	}
+
+	if err := fieldCfg.ValidShape(); err != nil {
+		return err
+	}
+
 	if err := fieldCfg.ValidateCounterResetStrategy(); err != nil {
 		return err
 	}
@@ -1114,6 +1615,22 @@ func bindLongWithReturn(fieldCfg ConfigField, field Field, fieldMap map[string]a
 		return nil
 	}
 
+	if fieldCfg.Shape != nil {
+		shapeFunc := makeIntShapeFunc(fieldCfg, field)
+		var emitF emitF
+		emitF = func(state *genState) any {
+			value, err := shapeFunc(state)
+			if err != nil {
+				panic(err)
+			}
+
+			return value
+		}
+
+		fieldMap[field.Name] = emitF
+		return nil
+	}
+
 	if fieldCfg.Fuzziness <= 0 {
 		var emitF emitF
 		emitF = func(state *genState) any {
@@ -1193,6 +1710,14 @@ func bindDoubleWithReturn(fieldCfg ConfigField, field Field, fieldMap map[string
 		return err
 	}
 
+	if err := fieldCfg.ValidDistribution(); err != nil {
+		return err
+	}
+
+	if err := fieldCfg.ValidShape(); err != nil {
+		return err
+	}
+
 	if err := fieldCfg.ValidateCounterResetStrategy(); err != nil {
 		return err
 	}
@@ -1273,6 +1798,22 @@ func bindDoubleWithReturn(fieldCfg ConfigField, field Field, fieldMap map[string
 		return nil
 	}
 
+	if fieldCfg.Shape != nil {
+		shapeFunc := makeFloatShapeFunc(fieldCfg)
+		var emitF emitF
+		emitF = func(state *genState) any {
+			value, err := shapeFunc(state)
+			if err != nil {
+				panic(err)
+			}
+
+			return value
+		}
+
+		fieldMap[field.Name] = emitF
+		return nil
+	}
+
 	if fieldCfg.Fuzziness <= 0 {
 		var emitF emitF
 		emitF = func(state *genState) any {
@@ -1306,11 +1847,45 @@ func bindDoubleWithReturn(fieldCfg ConfigField, field Field, fieldMap map[string
 	return nil
 }
 
+// bindMissingWithReturn wraps the bound function of the field so that it returns nil when the field is missing
+func bindMissingWithReturn(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	boundFWithReturn, ok := fieldMap[field.Name].(emitF)
+	if !ok {
+		return nil
+	}
+
+	var emitF emitF
+	emitF = func(state *genState) any {
+		if state.rand.Float64() < fieldCfg.MissingProbability {
+			// the time of the event is generated anyway, for the fields depending on it, see eventTime
+			if isDateField(field) {
+				boundFWithReturn(state)
+			}
+
+			return nil
+		}
+
+		return boundFWithReturn(state)
+	}
+
+	fieldMap[field.Name] = emitF
+	return nil
+}
+
 func bindCardinalityWithReturn(cfg Config, field Field, fieldMap map[string]any) error {
 
 	fieldCfg, _ := cfg.GetField(field.Name)
 	cardinality := fieldCfg.Cardinality
 
+	entity, err := fieldEntity(cfg, fieldCfg)
+	if err != nil {
+		return err
+	}
+
+	if entity.Size > 0 {
+		cardinality = 1
+	}
+
 	if strings.HasSuffix(field.Name, ".*") {
 		field.Name = replacer.Replace(field.Name)
 	}
@@ -1325,31 +1900,38 @@ func bindCardinalityWithReturn(cfg Config, field Field, fieldMap map[string]any)
 	var emitF emitF
 	emitF = func(state *genState) any {
 		var value any
+		// Values are cached for each value of the parent field, if any
+		cacheKey, counter, err := cardinalityCacheKey(state, fieldCfg, entity, field)
+		if err != nil {
+			panic(err)
+		}
+
 		// Have we rolled over once?  If not, generate a value and cache it.
-		if len(state.prevCacheCardinality[field.Name]) < cardinality {
+		if len(state.prevCacheCardinality[cacheKey]) < cardinality {
 			// Do college try dupe detection on value;
 			// Allow dupe if no unique value in nTries.
-			nTries := 11 // "These go to 11."
+			nTries := cardinalityDupeTries(fieldCfg)
 			for i := 0; i < nTries; i++ {
 				value = boundFWithReturn(state)
 
-				if !isDupeAny(state.prevCacheForDup[field.Name], value) {
+				// values like histograms are not comparable, their key is used instead
+				if !isDupeAny(state.prevCacheForDup[field.Name], fieldValueKey(value)) {
 					break
 				}
 			}
 
-			state.prevCacheForDup[field.Name][value] = struct{}{}
-			state.prevCacheCardinality[field.Name] = append(state.prevCacheCardinality[field.Name], value)
+			state.prevCacheForDup[field.Name][fieldValueKey(value)] = struct{}{}
+			state.prevCacheCardinality[cacheKey] = append(state.prevCacheCardinality[cacheKey], value)
 		}
 
-		idx := int(state.counter % uint64(cardinality))
+		idx := int(counter % uint64(cardinality))
 
 		// Safety check; should be a noop
-		if idx >= len(state.prevCacheCardinality[field.Name]) {
-			idx = len(state.prevCacheCardinality[field.Name]) - 1
+		if idx >= len(state.prevCacheCardinality[cacheKey]) {
+			idx = len(state.prevCacheCardinality[cacheKey]) - 1
 		}
 
-		choice := state.prevCacheCardinality[field.Name][idx]
+		choice := state.prevCacheCardinality[cacheKey][idx]
 
 		return choice
 	}
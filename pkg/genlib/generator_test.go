@@ -16,7 +16,7 @@ func Benchmark_GeneratorCustomTemplateJSONContent(b *testing.B) {
 	flds, _, err := fields.LoadFields(ctx, fields.ProductionBaseURL, "endpoint", "process", "8.2.0")
 
 	state := newGenState(rand.Int63(), time.Now())
-	template, objectKeysField := generateCustomTemplateFromField(Config{}, flds, state)
+	template, objectKeysField := generateCustomTemplateFromField(Config{}, flds, false, state)
 	flds = append(flds, objectKeysField...)
 	g, err := NewGenerator(Config{}, flds, uint64(b.N), WithCustomTemplate(template))
 	defer func() {
@@ -44,7 +44,7 @@ func Benchmark_GeneratorTextTemplateJSONContent(b *testing.B) {
 	flds, _, err := fields.LoadFields(ctx, fields.ProductionBaseURL, "endpoint", "process", "8.2.0")
 
 	state := newGenState(rand.Int63(), time.Now())
-	template, objectKeysField := generateTextTemplateFromField(Config{}, flds, state)
+	template, objectKeysField := generateTextTemplateFromField(Config{}, flds, false, state)
 	flds = append(flds, objectKeysField...)
 
 	g, err := NewGenerator(Config{}, flds, uint64(b.N), WithTextTemplate(template))
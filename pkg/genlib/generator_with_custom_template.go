@@ -6,10 +6,13 @@ package genlib
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
 	"io"
-	"regexp"
 )
 
+var missingFieldInCustomTemplate = errors.New("missing_probability is supported only with auto-generated templates in the placeholder engine")
+
 type emitter struct {
 	fieldName string
 	fieldType string
@@ -25,69 +28,44 @@ type GeneratorWithCustomTemplate struct {
 	state            *genState
 }
 
+// parseCustomTemplate returns the names of the placeholders in the template, in order, the text before
+// each placeholder, and the text after the last one
 func parseCustomTemplate(template []byte) ([]string, map[string][]byte, []byte) {
 	if len(template) == 0 {
 		return nil, nil, nil
 	}
 
-	tokenizer := regexp.MustCompile(`([^{]*)({{\.[^}]+}})*`)
-	allIndexes := tokenizer.FindAllSubmatchIndex(template, -1)
-
-	orderedFields := make([]string, 0, len(allIndexes))
-	templateFieldsMap := make(map[string][]byte, len(allIndexes))
-
-	var fieldPrefixBuffer []byte
-	var fieldPrefixPreviousN int
-	var trimTrailingTemplateN int
+	orderedFields := make([]string, 0)
+	templateFieldsMap := make(map[string][]byte)
 
-	for i, loc := range allIndexes {
-		var fieldName []byte
-		var fieldPrefix []byte
-
-		if loc[4] > -1 && loc[5] > -1 {
-			fieldName = template[loc[4]+3 : loc[5]-2]
+	for {
+		start := bytes.Index(template, []byte("{{."))
+		if start < 0 {
+			break
 		}
 
-		if loc[2] > -1 && loc[3] > -1 {
-			fieldPrefix = template[loc[2]:loc[3]]
+		end := bytes.Index(template[start:], []byte("}}"))
+		if end < 0 {
+			break
 		}
 
-		if len(fieldName) == 0 {
-			if template[fieldPrefixPreviousN] == byte(123) {
-				fieldPrefixBuffer = append(fieldPrefixBuffer, byte(123))
-			} else {
-				if i == len(allIndexes)-1 {
-					fieldPrefixBuffer = template[trimTrailingTemplateN:]
-				} else {
-					fieldPrefixBuffer = append(fieldPrefixBuffer, fieldPrefix...)
-					fieldPrefixBufferIdx := bytes.Index(template[trimTrailingTemplateN:], fieldPrefixBuffer)
-					if fieldPrefixBufferIdx > 0 {
-						trimTrailingTemplateN += fieldPrefixBufferIdx
-					}
+		fieldName := string(template[start+3 : start+end])
+		templateFieldsMap[fieldName] = template[:start]
+		orderedFields = append(orderedFields, fieldName)
 
-				}
-			}
-		} else {
-			fieldPrefixBuffer = append(fieldPrefixBuffer, fieldPrefix...)
-			trimTrailingTemplateN = loc[5]
-			templateFieldsMap[string(fieldName)] = fieldPrefixBuffer
-			orderedFields = append(orderedFields, string(fieldName))
-			fieldPrefixBuffer = nil
-		}
-
-		fieldPrefixPreviousN = loc[2]
+		template = template[start+end+2:]
 	}
 
-	return orderedFields, templateFieldsMap, fieldPrefixBuffer
-
+	return orderedFields, templateFieldsMap, template
 }
 
 func newGeneratorWithCustomTemplate(cfg Config, fields Fields, totEvents uint64, opts options) (Generator, error) {
 	state := newGenState(opts.randSeed, opts.startTime)
 
 	// If no template provided, generate one from fields
-	if opts.template == nil {
-		template, objectKeysField := generateCustomTemplateFromField(cfg, fields, state)
+	autoGeneratedTemplate := opts.template == nil
+	if autoGeneratedTemplate {
+		template, objectKeysField := generateCustomTemplateFromField(cfg, fields, opts.nested, state)
 		fields = append(fields, objectKeysField...)
 		opts.template = template
 	}
@@ -108,9 +86,52 @@ func newGeneratorWithCustomTemplate(cfg Config, fields Fields, totEvents uint64,
 		state.prevCacheCardinality[field.Name] = make([]any, 0)
 	}
 
+	if err := bindDependencies(cfg, fields, fieldMap, state); err != nil {
+		return nil, err
+	}
+
+	// Values of quoted fields are escaped, so that the events are valid JSON whatever the values are
+	if !opts.rawValues {
+		for _, field := range fields {
+			// object fields with `object_keys` are bound for each of their keys, see bindObject
+			escapedFields := objectKeysFields(cfg, field)
+			if len(escapedFields) == 0 {
+				escapedFields = Fields{field}
+			}
+
+			for _, escapedField := range escapedFields {
+				if fieldValueWrapByConfig(cfg, escapedField) != "\"" {
+					continue
+				}
+
+				if boundF, ok := fieldMap[escapedField.Name].(emitFNotReturn); ok {
+					fieldMap[escapedField.Name] = makeJSONEscapeFunc(boundF)
+				}
+			}
+		}
+	}
+
+	missingFields := make(map[string]struct{})
+	for _, field := range fields {
+		if !isMissingField(cfg, field) {
+			continue
+		}
+
+		missingFields[field.Name] = struct{}{}
+		if autoGeneratedTemplate {
+			fieldCfg, _ := cfg.GetField(field.Name)
+			fieldMap[field.Name] = makeMissingFieldStub(fieldCfg, state.missingFieldPositions[field.Name], fieldValueWrapByConfig(cfg, field), fieldMap[field.Name])
+		}
+	}
+
 	// Roll into slice of emit functions
 	emitters := make([]emitter, 0, len(fieldMap))
 	for _, fieldName := range orderedFields {
+		// the key of a missing field cannot be omitted from a user provided template
+		if _, ok := missingFields[fieldName]; ok && !autoGeneratedTemplate {
+			return nil, fmt.Errorf("field %s: %w", fieldName, missingFieldInCustomTemplate)
+		}
+
 		emitters = append(emitters, emitter{
 			fieldName: fieldName,
 			emitFunc:  fieldMap[fieldName].(emitFNotReturn),
@@ -124,6 +145,40 @@ func newGeneratorWithCustomTemplate(cfg Config, fields Fields, totEvents uint64,
 	return &GeneratorWithCustomTemplate{emitters: emitters, trailingTemplate: trailingTemplate, totEvents: totEvents, state: state}, nil
 }
 
+// makeMissingFieldStub wraps the bound function of a field that can be missing, emitting the whole key
+// of the field in the auto-generated template only when the field is present, with the separator from
+// the other fields of its object according to its position
+func makeMissingFieldStub(fieldCfg ConfigField, position missingFieldPosition, fieldWrap string, boundF any) emitFNotReturn {
+	return func(state *genState, buf *bytes.Buffer) error {
+		if state.rand.Float64() < fieldCfg.MissingProbability {
+			return nil
+		}
+
+		switch position.separator {
+		case missingSeparatorBefore:
+			buf.WriteByte(',')
+		case missingSeparatorTracked:
+			if counter, ok := state.emittedObjects[position.object]; ok && counter == state.counter {
+				buf.WriteByte(',')
+			}
+
+			state.emittedObjects[position.object] = state.counter
+		}
+
+		buf.WriteString(`"` + position.key + `": ` + fieldWrap)
+		if err := boundF.(emitFNotReturn)(state, buf); err != nil {
+			return err
+		}
+
+		buf.WriteString(fieldWrap)
+		if position.separator == missingSeparatorAfter {
+			buf.WriteByte(',')
+		}
+
+		return nil
+	}
+}
+
 func (gen *GeneratorWithCustomTemplate) Close() error {
 	return nil
 }
@@ -2,9 +2,14 @@ package genlib
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
+	"math"
 	"math/rand"
 	"net"
+	"net/netip"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"testing"
@@ -177,10 +182,61 @@ func Test_ParseTemplate(t *testing.T) {
 	}
 }
 
+func Test_ParseTemplatePrefixes(t *testing.T) {
+	testCases := []struct {
+		scenario                  string
+		template                  []byte
+		expectedOrderFields       []string
+		expectedTemplateFieldsMap map[string]string
+		expectedTrailingTemplate  string
+	}{
+		{
+			scenario:                  "literal braces of nested objects",
+			template:                  []byte(`{"a": {"b": {{.x}}}, "c": {"d": {"e": "{{.y}}"}}}`),
+			expectedOrderFields:       []string{"x", "y"},
+			expectedTemplateFieldsMap: map[string]string{"x": `{"a": {"b": `, "y": `}, "c": {"d": {"e": "`},
+			expectedTrailingTemplate:  `"}}}`,
+		},
+		{
+			scenario:                  "trailing text",
+			template:                  []byte(`{"x": {{.x}}} and {trailing} text`),
+			expectedOrderFields:       []string{"x"},
+			expectedTemplateFieldsMap: map[string]string{"x": `{"x": `},
+			expectedTrailingTemplate:  `} and {trailing} text`,
+		},
+		{
+			scenario:                  "adjacent placeholders",
+			template:                  []byte(`{{.x}}{{.y}}{{{.z}}}`),
+			expectedOrderFields:       []string{"x", "y", "z"},
+			expectedTemplateFieldsMap: map[string]string{"x": "", "y": "", "z": "{"},
+			expectedTrailingTemplate:  "}",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.scenario, func(t *testing.T) {
+			orderedFields, templateFieldsMap, trailingTemplate := parseCustomTemplate(testCase.template)
+			if !slices.Equal(orderedFields, testCase.expectedOrderFields) {
+				t.Errorf("Expected ordered fields %v, got %v", testCase.expectedOrderFields, orderedFields)
+			}
+
+			for k, expectedPrefix := range testCase.expectedTemplateFieldsMap {
+				if prefix := string(templateFieldsMap[k]); prefix != expectedPrefix {
+					t.Errorf("Expected prefix of field `%s` to be `%s`, got `%s`", k, expectedPrefix, prefix)
+				}
+			}
+
+			if string(trailingTemplate) != testCase.expectedTrailingTemplate {
+				t.Errorf("Expected trailing template `%s`, got `%s`", testCase.expectedTrailingTemplate, trailingTemplate)
+			}
+		})
+	}
+}
+
 func Test_EmptyCaseWithCustomTemplate(t *testing.T) {
 	startTime := time.Now().Truncate(time.Microsecond)
 	state := newGenState(rand.Int63(), startTime)
-	template, _ := generateCustomTemplateFromField(Config{}, []Field{}, state)
+	template, _ := generateCustomTemplateFromField(Config{}, []Field{}, false, state)
 	t.Logf("with template: %s", string(template))
 	g := makeGeneratorWithCustomTemplate(t, Config{}, []Field{}, template, 0, WithStartTime(startTime))
 
@@ -335,185 +391,239 @@ func test_CardinalityTWithCustomTemplate[T any](t *testing.T, ty string) {
 	})
 }
 
-func Test_FieldBoolWithCustomTemplate(t *testing.T) {
+func Test_FieldWeightedEnumWithCustomTemplate(t *testing.T) {
 	fld := Field{
 		Name: "alpha",
-		Type: FieldTypeBool,
+		Type: FieldTypeKeyword,
 	}
 
-	template := []byte(`{"alpha":{{.alpha}}}`)
+	template := []byte(`{"alpha":"{{.alpha}}"}`)
+	configYaml := []byte("fields:\n  - name: alpha\n    weighted_enum:\n      - value: rare\n        weight: 1\n      - value: common\n        weight: 9")
 	t.Logf("with template: %s", string(template))
-	// Enough spins, so we can make sure we get at least one true and at least one false
-	var cntTrue int
-	nSpins := 1024
-	for i := 0; i < nSpins; i++ {
-		b := testSingleTWithCustomTemplate[bool](t, fld, nil, template)
 
-		if b {
-			cntTrue += 1
+	cfg, err := config.LoadConfigFromYaml(configYaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nSpins := 10000
+	g := makeGeneratorWithCustomTemplate(t, cfg, []Field{fld}, template, uint64(nSpins))
+
+	vmap := make(map[string]int)
+	for i := 0; i < nSpins; i++ {
+		var buf bytes.Buffer
+		if err := g.Emit(&buf); err != nil {
+			t.Fatal(err)
 		}
+
+		m := unmarshalJSONT[string](t, buf.Bytes())
+		vmap[m[fld.Name]] += 1
 	}
 
-	if cntTrue == 0 {
-		t.Errorf("No true values, really?")
+	if len(vmap) != 2 {
+		t.Fatalf("Expected 2 different values, got %d", len(vmap))
 	}
 
-	if cntTrue == nSpins {
-		t.Errorf("No false values, really?")
+	// 10% expected, leaving some room for randomness
+	if vmap["rare"] < nSpins/20 || vmap["rare"] > nSpins/5 {
+		t.Errorf("Expected rare value around 10%% of the events, got %d over %d", vmap["rare"], nSpins)
 	}
 }
 
-func Test_FieldConstKeywordWithCustomTemplate(t *testing.T) {
+func Test_FieldWeightedEnumInvalidWithCustomTemplate(t *testing.T) {
+	template := []byte(`{"alpha":"{{.alpha}}"}`)
 
-	fld := Field{
-		Name:  "alpha",
-		Type:  FieldTypeConstantKeyword,
-		Value: "constant_keyword",
+	// configs set programmatically are not validated when loaded
+	cfg, err := config.LoadConfigFromYaml([]byte("fields:\n  - name: alpha"))
+	if err != nil {
+		t.Fatal(err)
 	}
 
-	template := []byte(`{"alpha":{{.alpha}}}`)
-	t.Logf("with template: %s", string(template))
-	b := testSingleTWithCustomTemplate[string](t, fld, nil, template)
-	if b != fld.Value {
-		t.Errorf("static value not match")
+	cfg.SetField("alpha", config.ConfigField{WeightedEnum: []config.WeightedEnum{{Value: "rare", Weight: 0}, {Value: "common", Weight: 9}}})
+	if _, err := NewGenerator(cfg, Fields{{Name: "alpha", Type: FieldTypeKeyword}}, 0, WithCustomTemplate(template)); err == nil {
+		t.Fatal("Expected error for a weight not greater than zero")
+	}
+
+	cfg.SetField("alpha", config.ConfigField{WeightedEnum: []config.WeightedEnum{{Value: "1", Weight: 1}, {Value: "2", Weight: 9}}})
+	if _, err := NewGenerator(cfg, Fields{{Name: "alpha", Type: FieldTypeLong}}, 0, WithCustomTemplate(template)); err == nil {
+		t.Fatal("Expected error for weighted_enum on a long field")
 	}
 }
 
-func Test_FieldStaticOverrideStringWithCustomTemplate(t *testing.T) {
+func Test_FieldWeightedEnumAndCardinalityWithCustomTemplate(t *testing.T) {
 	fld := Field{
 		Name: "alpha",
 		Type: FieldTypeKeyword,
 	}
 
-	yaml := []byte("fields:\n  - name: alpha\n    value: beta")
-	template := []byte(`{"alpha":{{.alpha}}}`)
+	template := []byte(`{"alpha":"{{.alpha}}"}`)
+	configYaml := []byte("fields:\n  - name: alpha\n    cardinality: 1000\n    weighted_enum:\n      - value: rare\n        weight: 1\n      - value: common\n        weight: 9")
 	t.Logf("with template: %s", string(template))
-	b := testSingleTWithCustomTemplate[string](t, fld, yaml, template)
-	if b != "beta" {
-		t.Errorf("static value not match")
+
+	cfg, err := config.LoadConfigFromYaml(configYaml)
+	if err != nil {
+		t.Fatal(err)
 	}
-}
 
-func Test_FieldStaticOverrideNumericWithCustomTemplate(t *testing.T) {
-	fld := Field{
+	nSpins := 10000
+	g := makeGeneratorWithCustomTemplate(t, cfg, []Field{fld}, template, uint64(nSpins))
 
-		Name: "alpha",
-		Type: FieldTypeKeyword,
+	values := make([]string, 0, nSpins)
+	vmap := make(map[string]int)
+	for i := 0; i < nSpins; i++ {
+		var buf bytes.Buffer
+		if err := g.Emit(&buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[string](t, buf.Bytes())
+		values = append(values, m[fld.Name])
+		vmap[m[fld.Name]] += 1
 	}
 
-	yaml := []byte("fields:\n  - name: alpha\n    value: 33.")
-	template := []byte(`{"alpha":{{.alpha}}}`)
-	t.Logf("with template: %s", string(template))
-	b := testSingleTWithCustomTemplate[float64](t, fld, yaml, template)
+	// every value in the cardinality cycle is always the same
+	for i := 1000; i < nSpins; i++ {
+		if values[i] != values[i%1000] {
+			t.Fatalf("Expected value at position %d to be the same as at position %d", i, i%1000)
+		}
+	}
 
-	if b != 33.0 {
-		t.Errorf("static value not match")
+	// weights are preserved by the values cached for the cardinality
+	if vmap["rare"] < nSpins/20 || vmap["rare"] > nSpins/5 {
+		t.Errorf("Expected rare value around 10%% of the events, got %d over %d", vmap["rare"], nSpins)
 	}
 }
 
-func Test_FieldStaticOverrideBoolWithCustomTemplate(t *testing.T) {
-	fld := Field{
-		Name: "alpha",
+func Test_FieldCardinalityPerParentWithCustomTemplate(t *testing.T) {
+	fldNamespace := Field{
+		Name: "namespace",
 		Type: FieldTypeKeyword,
 	}
 
-	yaml := []byte("fields:\n  - name: alpha\n    value: true")
-	template := []byte(`{"alpha":{{.alpha}}}`)
-	t.Logf("with template: %s", string(template))
-	b := testSingleTWithCustomTemplate[bool](t, fld, yaml, template)
-
-	if b != true {
-		t.Errorf("static value not match")
-	}
-}
-
-func Test_FieldGeoPointWithCustomTemplate(t *testing.T) {
-	fld := Field{
-		Name: "alpha",
-		Type: FieldTypeGeoPoint,
+	fldPod := Field{
+		Name: "pod",
+		Type: FieldTypeKeyword,
 	}
 
-	template := []byte(`{"alpha":"{{.alpha}}"}`)
+	// the child field is emitted before its parent
+	template := []byte(`{"pod":"{{.pod}}","namespace":"{{.namespace}}"}`)
+	configYaml := []byte("fields:\n  - name: namespace\n    cardinality: 3\n  - name: pod\n    cardinality: 5\n    cardinality_per: namespace")
 	t.Logf("with template: %s", string(template))
-	nSpins := 1024
-	for i := 0; i < nSpins; i++ {
 
-		b := testSingleTWithCustomTemplate[string](t, fld, nil, template)
+	cfg, err := config.LoadConfigFromYaml(configYaml)
+	if err != nil {
+		t.Fatal(err)
+	}
 
-		// Expect geo point in form of lat,long
-		// where lat is [-90.0..90.0]
-		// and long is  [-180.0..180.0]
+	nSpins := 1000
+	g := makeGeneratorWithCustomTemplate(t, cfg, []Field{fldNamespace, fldPod}, template, uint64(nSpins))
 
-		s := strings.Split(b, ",")
-		if len(s) != 2 {
-			t.Fatal("expected comma separated lat,long")
+	podsPerNamespace := make(map[string]map[string]struct{})
+	namespacesPerPod := make(map[string]map[string]struct{})
+	for i := 0; i < nSpins; i++ {
+		var buf bytes.Buffer
+		if err := g.Emit(&buf); err != nil {
+			t.Fatal(err)
 		}
 
-		lat := s[0]
-		long := s[1]
-
-		// no whitespace please
-		if len(lat) != len(strings.TrimSpace(lat)) {
-			t.Errorf("extra whitespace on latitude %s", lat)
+		m := unmarshalJSONT[string](t, buf.Bytes())
+		if _, ok := podsPerNamespace[m[fldNamespace.Name]]; !ok {
+			podsPerNamespace[m[fldNamespace.Name]] = make(map[string]struct{})
 		}
 
-		// no whitespace please
-		if len(long) != len(strings.TrimSpace(long)) {
-			t.Errorf("extra whitespace on longitude %s", long)
+		if _, ok := namespacesPerPod[m[fldPod.Name]]; !ok {
+			namespacesPerPod[m[fldPod.Name]] = make(map[string]struct{})
 		}
 
-		latF, err := strconv.ParseFloat(lat, 64)
-		if err != nil {
-			t.Errorf("Fail parse latitude as float")
-		}
-		longF, err := strconv.ParseFloat(long, 64)
-		if err != nil {
-			t.Errorf("Fail parse longitude as float")
-		}
+		podsPerNamespace[m[fldNamespace.Name]][m[fldPod.Name]] = struct{}{}
+		namespacesPerPod[m[fldPod.Name]][m[fldNamespace.Name]] = struct{}{}
+	}
 
-		if latF < -90.0 || latF > 90.0 {
-			t.Errorf("latitude out of range %v", latF)
+	if len(podsPerNamespace) != 3 {
+		t.Errorf("Expected 3 namespaces, got %d", len(podsPerNamespace))
+	}
+
+	for namespace, pods := range podsPerNamespace {
+		if len(pods) != 5 {
+			t.Errorf("Expected 5 pods for namespace %s, got %d", namespace, len(pods))
 		}
+	}
 
-		if longF < -180.0 || longF > 180.0 {
-			t.Errorf("longitutde out of range %v", longF)
+	for pod, namespaces := range namespacesPerPod {
+		if len(namespaces) != 1 {
+			t.Errorf("Expected pod %s to belong to a single namespace, got %d", pod, len(namespaces))
 		}
 	}
 }
 
-func Test_FieldDateWithCustomTemplate(t *testing.T) {
-	fld := Field{
+func Test_FieldCardinalityPerParentCycleWithCustomTemplate(t *testing.T) {
+	fldAlpha := Field{
 		Name: "alpha",
-		Type: FieldTypeDate,
+		Type: FieldTypeKeyword,
 	}
 
-	template := []byte(`{"alpha":"{{.alpha}}"}`)
+	fldBeta := Field{
+		Name: "beta",
+		Type: FieldTypeKeyword,
+	}
+
+	template := []byte(`{"alpha":"{{.alpha}}","beta":"{{.beta}}"}`)
+	configYaml := []byte("fields:\n  - name: alpha\n    cardinality: 2\n    cardinality_per: beta\n  - name: beta\n    cardinality: 2\n    cardinality_per: alpha")
 	t.Logf("with template: %s", string(template))
-	nSpins := rand.Intn(1024) + 1
-	for i := 0; i < nSpins; i++ {
-		startTime := time.Now().Truncate(time.Microsecond)
-		b := testSingleTWithCustomTemplate[string](t, fld, nil, template, WithStartTime(startTime))
 
-		if ts, err := time.Parse(FieldTypeTimeLayout, b); err != nil {
-			t.Errorf("Fail parse timestamp %v", err)
-		} else {
-			// Timestamp should be from now within a FieldTypeDurationSpan milliseconds of slop
-			diff := ts.Sub(startTime)
-			if diff < 0 || diff > FieldTypeDurationSpan*time.Millisecond {
-				t.Errorf("Data generated before now, diff: %v", diff)
-			}
-		}
+	cfg, err := config.LoadConfigFromYaml(configYaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewGenerator(cfg, []Field{fldAlpha, fldBeta}, 0, WithCustomTemplate(template)); err == nil {
+		t.Fatal("Expected error for a dependency cycle between fields")
+	}
+
+	if _, err := NewGenerator(cfg, []Field{fldAlpha}, 0, WithCustomTemplate([]byte(`{"alpha":"{{.alpha}}"}`))); err == nil {
+		t.Fatal("Expected error for a parent field not present in fields definition")
 	}
 }
 
-func Test_FieldDateAndPeriodPositiveWithCustomTemplate(t *testing.T) {
-	fld := Field{
-		Name: "alpha",
-		Type: FieldTypeDate,
+func Test_FieldDeriveWithCustomTemplate(t *testing.T) {
+	fields := []Field{
+		{Name: "packets", Type: FieldTypeLong},
+		{Name: "bytes", Type: FieldTypeLong},
+		{Name: "ratio", Type: FieldTypeDouble},
+		{Name: "idx", Type: FieldTypeLong},
+		{Name: "types", Type: FieldTypeKeyword},
+		{Name: "type", Type: FieldTypeKeyword},
+		{Name: "state", Type: FieldTypeKeyword},
+		{Name: "code", Type: FieldTypeLong},
+		{Name: "label", Type: FieldTypeKeyword},
 	}
 
-	template := []byte(`{"alpha":"{{.alpha}}"}`)
-	configYaml := []byte("fields:\n  - name: alpha\n    period: 10s")
+	// derived fields are emitted before the fields they depend on
+	template := []byte(`{"bytes":{{.bytes}},"ratio":{{.ratio}},"type":"{{.type}}","code":{{.code}},"label":"{{.label}}","packets":{{.packets}},"idx":{{.idx}},"state":"{{.state}}"}`)
+	configYaml := []byte(`fields:
+  - name: packets
+    range:
+      min: 1
+      max: 100
+  - name: bytes
+    derive: "$packets * 15"
+  - name: ratio
+    derive: "$bytes / 2.0"
+  - name: idx
+    range:
+      min: 0
+      max: 2
+  - name: types
+    value: ["t2.micro", "t2.small", "t2.medium"]
+  - name: type
+    derive: "$types[$idx]"
+  - name: state
+    enum: ["running", "stopped"]
+  - name: code
+    derive: '{"running": 16, "stopped": 80}[$state]'
+  - name: label
+    derive: "$type + '-' + $idx"
+`)
 	t.Logf("with template: %s", string(template))
 
 	cfg, err := config.LoadConfigFromYaml(configYaml)
@@ -521,219 +631,310 @@ func Test_FieldDateAndPeriodPositiveWithCustomTemplate(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	startTime := time.Now().Truncate(time.Microsecond)
-	g := makeGeneratorWithCustomTemplate(t, cfg, []Field{fld}, template, 10, WithStartTime(startTime))
-
-	var buf bytes.Buffer
+	g := makeGeneratorWithCustomTemplate(t, cfg, fields, template, 0)
 
-	nSpins := 10
-	for i := 0; i < nSpins; i++ {
+	types := []string{"t2.micro", "t2.small", "t2.medium"}
+	codes := map[string]float64{"running": 16, "stopped": 80}
+	for i := 0; i < 100; i++ {
+		var buf bytes.Buffer
 		if err := g.Emit(&buf); err != nil {
 			t.Fatal(err)
 		}
 
-		m := unmarshalJSONT[string](t, buf.Bytes())
-		buf.Reset()
+		m := unmarshalJSONT[any](t, buf.Bytes())
+		packets := m["packets"].(float64)
+		idx := int(m["idx"].(float64))
 
-		if len(m) != 1 {
-			t.Errorf("Expected map size 1, got %d", len(m))
+		if m["bytes"] != packets*15 {
+			t.Errorf("Expected bytes to be %f, got %v", packets*15, m["bytes"])
 		}
 
-		v, ok := m[fld.Name]
+		if m["ratio"] != packets*15/2 {
+			t.Errorf("Expected ratio to be %f, got %v", packets*15/2, m["ratio"])
+		}
 
-		if !ok {
-			t.Errorf("Missing key %v", fld.Name)
+		if m["type"] != types[idx] {
+			t.Errorf("Expected type to be %s, got %v", types[idx], m["type"])
 		}
 
-		if ts, err := time.Parse(FieldTypeTimeLayout, v); err != nil {
-			t.Errorf("Fail parse timestamp %v", err)
-		} else {
-			// Timestamp should be +1s for every iteration
-			expectedTime := startTime.Add(time.Second * time.Duration(i))
+		if m["code"] != codes[m["state"].(string)] {
+			t.Errorf("Expected code to be %f for state %v, got %v", codes[m["state"].(string)], m["state"], m["code"])
+		}
 
-			diff := expectedTime.Truncate(time.Millisecond).Sub(ts.Truncate(time.Millisecond))
-			if diff != 0 {
-				t.Errorf("Date generated out of period range %v", diff)
-			}
+		if expected := fmt.Sprintf("%s-%d", types[idx], idx); m["label"] != expected {
+			t.Errorf("Expected label to be %s, got %v", expected, m["label"])
 		}
 	}
 }
 
-func Test_FieldDateAndPeriodNegativeWithCustomTemplate(t *testing.T) {
-	fld := Field{
-		Name: "alpha",
-		Type: FieldTypeDate,
+func Test_FieldDeriveHardcodedValueWithCustomTemplate(t *testing.T) {
+	fields := []Field{
+		{Name: "name", Type: FieldTypeKeyword, Value: "abc"},
+		{Name: "label", Type: FieldTypeKeyword},
+		{Name: "size", Type: FieldTypeLong, Value: "5"},
+		{Name: "total", Type: FieldTypeLong},
 	}
 
-	template := []byte(`{"alpha":"{{.alpha}}"}`)
-	configYaml := []byte("fields:\n  - name: alpha\n    period: -10s")
-	t.Logf("with template: %s", string(template))
+	template := []byte(`{"label":"{{.label}}","total":{{.total}}}`)
+	configYaml := []byte("fields:\n  - name: label\n    derive: \"$name + '-x'\"\n  - name: total\n    derive: \"$size + 1\"")
 
 	cfg, err := config.LoadConfigFromYaml(configYaml)
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	startTime := time.Now().Truncate(time.Microsecond)
-	g := makeGeneratorWithCustomTemplate(t, cfg, []Field{fld}, template, 10, WithStartTime(startTime))
+	g := makeGeneratorWithCustomTemplate(t, cfg, fields, template, 0)
 
 	var buf bytes.Buffer
+	if err := g.Emit(&buf); err != nil {
+		t.Fatal(err)
+	}
 
-	nSpins := 10
-	for i := 0; i < nSpins; i++ {
-		if err := g.Emit(&buf); err != nil {
-			t.Fatal(err)
-		}
+	m := unmarshalJSONT[any](t, buf.Bytes())
+	if m["label"] != "abc-x" {
+		t.Errorf("Expected label to be abc-x, got %v", m["label"])
+	}
 
-		m := unmarshalJSONT[string](t, buf.Bytes())
-		buf.Reset()
+	if m["total"] != float64(6) {
+		t.Errorf("Expected total to be 6, got %v", m["total"])
+	}
+}
 
-		if len(m) != 1 {
-			t.Errorf("Expected map size 1, got %d", len(m))
-		}
+func Test_FieldDeriveUnsignedLongWithCustomTemplate(t *testing.T) {
+	fields := []Field{
+		{Name: "bytes", Type: FieldTypeUnsignedLong},
+		{Name: "total", Type: FieldTypeUnsignedLong},
+	}
 
-		v, ok := m[fld.Name]
+	template := []byte(`{"bytes":{{.bytes}},"total":{{.total}}}`)
+	configYaml := []byte(`fields:
+  - name: bytes
+    range:
+      min: 10000000000000000000
+      max: 12000000000000000000
+  - name: total
+    derive: "$bytes + 1"
+`)
 
-		if !ok {
-			t.Errorf("Missing key %v", fld.Name)
+	cfg, err := config.LoadConfigFromYaml(configYaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := makeGeneratorWithCustomTemplate(t, cfg, fields, template, 0)
+
+	for i := 0; i < 100; i++ {
+		var buf bytes.Buffer
+		if err := g.Emit(&buf); err != nil {
+			t.Fatal(err)
 		}
 
-		if ts, err := time.Parse(FieldTypeTimeLayout, v); err != nil {
-			t.Errorf("Fail parse timestamp %v", err)
-		} else {
-			// Timestamp should be +1s for every iteration
-			expectedTime := startTime.Add(-10*time.Second + time.Second*time.Duration(i))
+		m := unmarshalJSONT[uint64](t, buf.Bytes())
+		if m["total"] < 10000000000000000000 {
+			t.Errorf("Expected total above math.MaxInt64, got %d", m["total"])
+		}
+	}
+}
 
-			diff := expectedTime.Truncate(time.Millisecond).Sub(ts.Truncate(time.Millisecond))
-			if diff != 0 {
-				t.Errorf("Date generated out of period range %v", diff)
+func Test_FieldDeriveInvalidWithCustomTemplate(t *testing.T) {
+	testCases := []struct {
+		scenario   string
+		configYaml string
+	}{
+		{
+			scenario:   "dependency cycle",
+			configYaml: "fields:\n  - name: alpha\n    derive: \"$beta + 1\"\n  - name: beta\n    derive: \"$alpha + 1\"",
+		},
+		{
+			scenario:   "self reference",
+			configYaml: "fields:\n  - name: alpha\n    derive: \"$alpha + 1\"",
+		},
+		{
+			scenario:   "unknown field",
+			configYaml: "fields:\n  - name: alpha\n    derive: \"$gamma + 1\"",
+		},
+		{
+			scenario:   "invalid expression",
+			configYaml: "fields:\n  - name: alpha\n    derive: \"$beta +\"",
+		},
+		{
+			scenario:   "derive with enum",
+			configYaml: "fields:\n  - name: alpha\n    derive: \"$beta + 1\"\n    enum: [\"a\"]",
+		},
+	}
+
+	fields := []Field{
+		{Name: "alpha", Type: FieldTypeLong},
+		{Name: "beta", Type: FieldTypeLong},
+	}
+
+	template := []byte(`{"alpha":{{.alpha}},"beta":{{.beta}}}`)
+
+	for _, testCase := range testCases {
+		t.Run(testCase.scenario, func(t *testing.T) {
+			cfg, err := config.LoadConfigFromYaml([]byte(testCase.configYaml))
+			if err != nil {
+				t.Fatal(err)
 			}
-		}
+
+			if _, err := NewGenerator(cfg, fields, 0, WithCustomTemplate(template)); err == nil {
+				t.Fatal("Expected error")
+			}
+		})
 	}
 }
 
-func Test_FieldDateAndRangeFromInThePastWithCustomTemplate(t *testing.T) {
-	fld := Field{
-		Name: "alpha",
-		Type: FieldTypeDate,
+func Test_FieldDeriveMissingReferenceWithCustomTemplate(t *testing.T) {
+	fields := []Field{
+		{Name: "alpha", Type: FieldTypeLong},
+		{Name: "beta", Type: FieldTypeLong},
 	}
 
-	startTime := time.Now().Truncate(time.Microsecond)
-	from := startTime.Add(-10 * time.Second)
-	template := []byte(`{"alpha":"{{.alpha}}"}`)
-	configYaml := []byte(fmt.Sprintf("fields:\n  - name: alpha\n    range:\n      from: %s", from.Format("2006-01-02T15:04:05.999999999-07:00")))
-	t.Logf("with template: %s", string(template))
+	configYaml := []byte("fields:\n  - name: alpha\n    derive: \"$beta + 1\"\n  - name: beta\n    missing_probability: 0.5")
 
 	cfg, err := config.LoadConfigFromYaml(configYaml)
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	g := makeGeneratorWithCustomTemplate(t, cfg, []Field{fld}, template, 10, WithStartTime(startTime))
+	// missing fields are supported only in the auto-generated template
+	if _, err := NewGenerator(cfg, fields, 0); err == nil {
+		t.Fatal("Expected error for a derive referencing a field with missing probability")
+	}
+}
 
-	var buf bytes.Buffer
+func Test_FieldEntityWithCustomTemplate(t *testing.T) {
+	fields := []Field{
+		{Name: "host.name", Type: FieldTypeKeyword},
+		{Name: "host.ip", Type: FieldTypeIP},
+		{Name: "host.os", Type: FieldTypeKeyword},
+		{Name: "user.name", Type: FieldTypeKeyword},
+	}
 
-	period := startTime.UTC().Sub(from.UTC())
+	template := []byte(`{"host.name":"{{.host.name}}","host.ip":"{{.host.ip}}","host.os":"{{.host.os}}","user.name":"{{.user.name}}"}`)
+	configYaml := []byte(`entities:
+  - name: hosts
+    size: 10
+  - name: users
+    size: 3
+fields:
+  - name: host.name
+    entity: hosts
+  - name: host.ip
+    entity: hosts
+  - name: host.os
+    entity: hosts
+    enum: ["linux", "windows"]
+  - name: user.name
+    entity: users
+`)
+	t.Logf("with template: %s", string(template))
 
-	nSpins := int64(10)
-	for i := int64(0); i < nSpins; i++ {
+	cfg, err := config.LoadConfigFromYaml(configYaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nSpins := 1000
+	g := makeGeneratorWithCustomTemplate(t, cfg, fields, template, uint64(nSpins))
+
+	hosts := make(map[string][2]string)
+	ips := make(map[string]string)
+	users := make(map[string]struct{})
+	for i := 0; i < nSpins; i++ {
+		var buf bytes.Buffer
 		if err := g.Emit(&buf); err != nil {
 			t.Fatal(err)
 		}
 
 		m := unmarshalJSONT[string](t, buf.Bytes())
-		buf.Reset()
-
-		if len(m) != 1 {
-			t.Errorf("Expected map size 1, got %d", len(m))
+		host := [2]string{m["host.ip"], m["host.os"]}
+		if previous, ok := hosts[m["host.name"]]; ok && previous != host {
+			t.Errorf("Expected host %s to always have ip and os %v, got %v", m["host.name"], previous, host)
 		}
 
-		v, ok := m[fld.Name]
-
-		if !ok {
-			t.Errorf("Missing key %v", fld.Name)
+		if previous, ok := ips[m["host.ip"]]; ok && previous != m["host.name"] {
+			t.Errorf("Expected ip %s to always belong to host %s, got %s", m["host.ip"], previous, m["host.name"])
 		}
 
-		if ts, err := time.Parse(FieldTypeTimeLayout, v); err != nil {
-			t.Errorf("Fail parse timestamp %v", err)
-		} else {
-			// Timestamp should be +(period.Nanoseconds() / nSpins) * i) for every iteration
-			expectedTime := startTime.Add(time.Duration((period.Nanoseconds() / nSpins) * i))
+		hosts[m["host.name"]] = host
+		ips[m["host.ip"]] = m["host.name"]
+		users[m["user.name"]] = struct{}{}
+	}
 
-			diff := expectedTime.Truncate(time.Millisecond).Sub(ts.Truncate(time.Millisecond))
-			if diff != 0 {
-				t.Errorf("Date generated out of period range %v", diff)
-			}
-		}
+	if len(hosts) != 10 {
+		t.Errorf("Expected 10 hosts, got %d", len(hosts))
+	}
+
+	if len(users) != 3 {
+		t.Errorf("Expected 3 users, got %d", len(users))
 	}
 }
 
-func Test_FieldDateAndRangeFromInTheFutureWithCustomTemplate(t *testing.T) {
-	fld := Field{
-		Name: "alpha",
-		Type: FieldTypeDate,
+func Test_FieldPatternWithCustomTemplate(t *testing.T) {
+	patterns := map[string]string{
+		"instance": `i-[0-9a-f]{17}`,
+		"pod":      `[a-z]+-[a-z0-9]{8,10}-[a-z0-9]{5}`,
+		"arn":      `arn:aws:(ec2|s3|lambda):us-(east|west)-[12]:\d{12}:[a-z]+/\w+`,
 	}
 
-	startTime := time.Now().Truncate(time.Microsecond)
-	from := startTime.Add(10 * time.Second)
-	template := []byte(`{"alpha":"{{.alpha}}"}`)
-	configYaml := []byte(fmt.Sprintf("fields:\n  - name: alpha\n    range:\n      from: %s", from.Format("2006-01-02T15:04:05.999999999-07:00")))
+	fields := make([]Field, 0, len(patterns))
+	configYaml := "fields:"
+	for name, pattern := range patterns {
+		fields = append(fields, Field{Name: name, Type: FieldTypeKeyword})
+		configYaml += fmt.Sprintf("\n  - name: %s\n    pattern: '%s'", name, pattern)
+	}
+
+	template := []byte(`{"instance":"{{.instance}}","pod":"{{.pod}}","arn":"{{.arn}}"}`)
 	t.Logf("with template: %s", string(template))
 
-	cfg, err := config.LoadConfigFromYaml(configYaml)
+	cfg, err := config.LoadConfigFromYaml([]byte(configYaml))
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	g := makeGeneratorWithCustomTemplate(t, cfg, []Field{fld}, template, 10, WithStartTime(startTime))
-
-	var buf bytes.Buffer
-
-	period := from.UTC().Sub(startTime.UTC())
+	g := makeGeneratorWithCustomTemplate(t, cfg, fields, template, 0)
 
-	nSpins := int64(10)
-	for i := int64(0); i < nSpins; i++ {
+	for i := 0; i < 100; i++ {
+		var buf bytes.Buffer
 		if err := g.Emit(&buf); err != nil {
 			t.Fatal(err)
 		}
 
 		m := unmarshalJSONT[string](t, buf.Bytes())
-		buf.Reset()
-
-		if len(m) != 1 {
-			t.Errorf("Expected map size 1, got %d", len(m))
-		}
-
-		v, ok := m[fld.Name]
-
-		if !ok {
-			t.Errorf("Missing key %v", fld.Name)
-		}
-
-		if ts, err := time.Parse(FieldTypeTimeLayout, v); err != nil {
-			t.Errorf("Fail parse timestamp %v", err)
-		} else {
-			// Timestamp should be +(period.Nanoseconds() / nSpins) * i) for every iteration
-			expectedTime := startTime.Add(time.Duration((period.Nanoseconds() / nSpins) * i))
-
-			diff := expectedTime.Truncate(time.Millisecond).Sub(ts.Truncate(time.Millisecond))
-			if diff != 0 {
-				t.Errorf("Date generated out of period range %v", diff)
+		for name, pattern := range patterns {
+			if !regexp.MustCompile("^" + pattern + "$").MatchString(m[name]) {
+				t.Errorf("Expected %s to match %s, got %s", name, pattern, m[name])
 			}
 		}
 	}
 }
 
-func Test_FieldDateAndRangeToInThePastWithCustomTemplate(t *testing.T) {
-	fld := Field{
-		Name: "alpha",
-		Type: FieldTypeDate,
+func Test_FieldFakerWithCustomTemplate(t *testing.T) {
+	fields := []Field{
+		{Name: "user.email", Type: FieldTypeKeyword},
+		{Name: "user_agent.original", Type: FieldTypeKeyword},
+		{Name: "http.response.status_code", Type: FieldTypeLong},
+		{Name: "source.ip", Type: FieldTypeIP},
+		{Name: "event.id", Type: FieldTypeKeyword},
+		{Name: "file.path", Type: FieldTypeKeyword},
 	}
 
-	startTime := time.Now().Truncate(time.Microsecond)
-	to := startTime.Add(-10 * time.Second)
-	template := []byte(`{"alpha":"{{.alpha}}"}`)
-	configYaml := []byte(fmt.Sprintf("fields:\n  - name: alpha\n    range:\n      to: %s", to.Format("2006-01-02T15:04:05.999999999-07:00")))
+	template := []byte(`{"user.email":"{{.user.email}}","user_agent.original":"{{.user_agent.original}}","http.response.status_code":{{.http.response.status_code}},"source.ip":"{{.source.ip}}","event.id":"{{.event.id}}","file.path":"{{.file.path}}"}`)
+	configYaml := []byte(`fields:
+  - name: user.email
+    faker: email
+  - name: user_agent.original
+    faker: user_agent
+  - name: http.response.status_code
+    faker: http_status
+  - name: source.ip
+    faker: ipv6
+  - name: event.id
+    faker: uuid
+  - name: file.path
+    faker: file_path
+`)
 	t.Logf("with template: %s", string(template))
 
 	cfg, err := config.LoadConfigFromYaml(configYaml)
@@ -741,112 +942,179 @@ func Test_FieldDateAndRangeToInThePastWithCustomTemplate(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	g := makeGeneratorWithCustomTemplate(t, cfg, []Field{fld}, template, 10, WithStartTime(startTime))
-
-	var buf bytes.Buffer
-
-	period := startTime.UTC().Sub(to.UTC())
+	g := makeGeneratorWithCustomTemplate(t, cfg, fields, template, 0)
 
-	nSpins := int64(10)
-	for i := int64(0); i < nSpins; i++ {
+	uuidRegex := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+	for i := 0; i < 100; i++ {
+		var buf bytes.Buffer
 		if err := g.Emit(&buf); err != nil {
 			t.Fatal(err)
 		}
 
-		m := unmarshalJSONT[string](t, buf.Bytes())
-		buf.Reset()
+		m := unmarshalJSONT[any](t, buf.Bytes())
+		if email := m["user.email"].(string); !strings.Contains(email, "@") {
+			t.Errorf("Expected an email, got %s", email)
+		}
 
-		if len(m) != 1 {
-			t.Errorf("Expected map size 1, got %d", len(m))
+		if len(m["user_agent.original"].(string)) == 0 {
+			t.Errorf("Expected an user agent, got an empty string")
 		}
 
-		v, ok := m[fld.Name]
+		if status := m["http.response.status_code"].(float64); status < 100 || status > 599 {
+			t.Errorf("Expected an http status, got %v", status)
+		}
 
-		if !ok {
-			t.Errorf("Missing key %v", fld.Name)
+		if ip := net.ParseIP(m["source.ip"].(string)); ip == nil || ip.To4() != nil {
+			t.Errorf("Expected an ipv6 address, got %v", m["source.ip"])
 		}
 
-		if ts, err := time.Parse(FieldTypeTimeLayout, v); err != nil {
-			t.Errorf("Fail parse timestamp %v", err)
-		} else {
-			// Timestamp should be +(period.Nanoseconds() / nSpins) * i) for every iteration
-			expectedTime := startTime.Add(time.Duration((period.Nanoseconds() / nSpins) * i))
+		if id := m["event.id"].(string); !uuidRegex.MatchString(id) {
+			t.Errorf("Expected an uuid, got %s", id)
+		}
 
-			diff := expectedTime.Truncate(time.Millisecond).Sub(ts.Truncate(time.Millisecond))
-			if diff != 0 {
-				t.Errorf("Date generated out of period range %v", diff)
-			}
+		if path := m["file.path"].(string); !strings.HasPrefix(path, "/") {
+			t.Errorf("Expected an absolute file path, got %s", path)
 		}
 	}
-}
 
-func Test_FieldDateAndRangeToInTheFutureWithCustomTemplate(t *testing.T) {
-	fld := Field{
-		Name: "alpha",
-		Type: FieldTypeDate,
+	configYaml = []byte("fields:\n  - name: user.email\n    faker: not_a_provider")
+	cfg, err = config.LoadConfigFromYaml(configYaml)
+	if err != nil {
+		t.Fatal(err)
 	}
 
-	startTime := time.Now().Truncate(time.Microsecond)
-	to := startTime.Add(10 * time.Second)
-	template := []byte(`{"alpha":"{{.alpha}}"}`)
-	configYaml := []byte(fmt.Sprintf("fields:\n  - name: alpha\n    range:\n      to: %s", to.Format("2006-01-02T15:04:05.999999999-07:00")))
-	t.Logf("with template: %s", string(template))
+	if _, err := NewGenerator(cfg, fields[:1], 0, WithCustomTemplate([]byte(`{"user.email":"{{.user.email}}"}`))); err == nil {
+		t.Fatal("Expected error for an unsupported faker provider")
+	}
 
-	cfg, err := config.LoadConfigFromYaml(configYaml)
+	configYaml = []byte("fields:\n  - name: http.response.status_code\n    faker: email")
+	cfg, err = config.LoadConfigFromYaml(configYaml)
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	g := makeGeneratorWithCustomTemplate(t, cfg, []Field{fld}, template, 10, WithStartTime(startTime))
+	if _, err := NewGenerator(cfg, fields[2:3], 0, WithCustomTemplate([]byte(`{"http.response.status_code":{{.http.response.status_code}}}`))); err == nil {
+		t.Fatal("Expected error for a faker provider not supported for the field type")
+	}
+}
 
-	var buf bytes.Buffer
+func Test_FieldDistributionWithCustomTemplate(t *testing.T) {
+	testCases := []struct {
+		scenario     string
+		fieldType    string
+		distribution string
+		expectedMean float64
+		min          float64
+		max          float64
+	}{
+		{
+			scenario:     "normal long",
+			fieldType:    FieldTypeLong,
+			distribution: "type: normal\n      mean: 100\n      stddev: 10",
+			expectedMean: 100,
+			min:          0,
+			max:          math.MaxInt64,
+		},
+		{
+			scenario:     "normal byte clamped to type bounds",
+			fieldType:    FieldTypeByte,
+			distribution: "type: normal\n      mean: 1000\n      stddev: 10",
+			expectedMean: math.MaxInt8,
+			min:          math.MaxInt8,
+			max:          math.MaxInt8,
+		},
+		{
+			scenario:     "exponential double",
+			fieldType:    FieldTypeDouble,
+			distribution: "type: exponential\n      rate: 0.5",
+			expectedMean: 2,
+			min:          0,
+			max:          math.MaxFloat64,
+		},
+		{
+			scenario:     "poisson long",
+			fieldType:    FieldTypeLong,
+			distribution: "type: poisson\n      lambda: 4",
+			expectedMean: 4,
+			min:          0,
+			max:          math.MaxInt64,
+		},
+	}
 
-	period := to.UTC().Sub(startTime.UTC())
+	for _, testCase := range testCases {
+		t.Run(testCase.scenario, func(t *testing.T) {
+			fld := Field{
+				Name: "alpha",
+				Type: testCase.fieldType,
+			}
 
-	nSpins := int64(10)
-	for i := int64(0); i < nSpins; i++ {
-		if err := g.Emit(&buf); err != nil {
-			t.Fatal(err)
-		}
+			template := []byte(`{"alpha":{{.alpha}}}`)
+			configYaml := []byte("fields:\n  - name: alpha\n    distribution:\n      " + testCase.distribution)
+			t.Logf("with template: %s", string(template))
 
-		m := unmarshalJSONT[string](t, buf.Bytes())
-		buf.Reset()
+			cfg, err := config.LoadConfigFromYaml(configYaml)
+			if err != nil {
+				t.Fatal(err)
+			}
 
-		if len(m) != 1 {
-			t.Errorf("Expected map size 1, got %d", len(m))
-		}
+			nSpins := 10000
+			g := makeGeneratorWithCustomTemplate(t, cfg, []Field{fld}, template, uint64(nSpins))
 
-		v, ok := m[fld.Name]
+			var sum float64
+			for i := 0; i < nSpins; i++ {
+				var buf bytes.Buffer
+				if err := g.Emit(&buf); err != nil {
+					t.Fatal(err)
+				}
 
-		if !ok {
-			t.Errorf("Missing key %v", fld.Name)
-		}
+				m := unmarshalJSONT[float64](t, buf.Bytes())
+				v := m[fld.Name]
+				if v < testCase.min || v > testCase.max {
+					t.Fatalf("Value %v out of bounds [%v, %v]", v, testCase.min, testCase.max)
+				}
 
-		if ts, err := time.Parse(FieldTypeTimeLayout, v); err != nil {
-			t.Errorf("Fail parse timestamp %v", err)
-		} else {
-			// Timestamp should be +(period.Nanoseconds() / nSpins) * i) for every iteration
-			expectedTime := startTime.Add(time.Duration((period.Nanoseconds() / nSpins) * i))
+				sum += v
+			}
 
-			diff := expectedTime.Truncate(time.Millisecond).Sub(ts.Truncate(time.Millisecond))
-			if diff != 0 {
-				t.Errorf("Date generated out of period range %v", diff)
+			mean := sum / float64(nSpins)
+			if math.Abs(mean-testCase.expectedMean) > testCase.expectedMean*0.1 {
+				t.Errorf("Expected mean around %v, got %v", testCase.expectedMean, mean)
 			}
-		}
+		})
 	}
 }
 
-func Test_FieldDateAndRangeFromAndToPositiveWithCustomTemplate(t *testing.T) {
-	fld := Field{
-		Name: "alpha",
+func Test_FieldShapeWithCustomTemplate(t *testing.T) {
+	fldTimestamp := Field{
+		Name: "@timestamp",
 		Type: FieldTypeDate,
 	}
 
-	startTime := time.Now().Truncate(time.Microsecond)
-	from := startTime.Add(-10 * time.Second)
-	to := startTime.Add(10 * time.Second)
-	template := []byte(`{"alpha":"{{.alpha}}"}`)
-	configYaml := []byte(fmt.Sprintf("fields:\n  - name: alpha\n    range:\n      from: %s\n      to: %s", from.Format("2006-01-02T15:04:05.999999999-07:00"), to.Format("2006-01-02T15:04:05.999999999-07:00")))
+	fldAlpha := Field{
+		Name: "alpha",
+		Type: FieldTypeDouble,
+	}
+
+	fldBeta := Field{
+		Name: "beta",
+		Type: FieldTypeLong,
+	}
+
+	template := []byte(`{"@timestamp":"{{.@timestamp}}","alpha":{{.alpha}},"beta":{{.beta}}}`)
+	configYaml := []byte(`fields:
+  - name: "@timestamp"
+    period: 24h
+  - name: alpha
+    shape:
+      base: 50
+      amplitude: 10
+  - name: beta
+    range:
+      max: 100
+    shape:
+      base: 50
+      trend: 10
+`)
 	t.Logf("with template: %s", string(template))
 
 	cfg, err := config.LoadConfigFromYaml(configYaml)
@@ -854,56 +1122,244 @@ func Test_FieldDateAndRangeFromAndToPositiveWithCustomTemplate(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	g := makeGeneratorWithCustomTemplate(t, cfg, []Field{fld}, template, 10, WithStartTime(startTime))
-
-	var buf bytes.Buffer
-
-	period := to.UTC().Sub(from.UTC())
+	startTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	nSpins := 24
+	g := makeGeneratorWithCustomTemplate(t, cfg, []Field{fldTimestamp, fldAlpha, fldBeta}, template, uint64(nSpins), WithStartTime(startTime))
 
-	nSpins := int64(10)
-	for i := int64(0); i < nSpins; i++ {
+	for i := 0; i < nSpins; i++ {
+		var buf bytes.Buffer
 		if err := g.Emit(&buf); err != nil {
 			t.Fatal(err)
 		}
 
-		m := unmarshalJSONT[string](t, buf.Bytes())
-		buf.Reset()
-
-		if len(m) != 1 {
-			t.Errorf("Expected map size 1, got %d", len(m))
-		}
-
-		v, ok := m[fld.Name]
+		m := unmarshalJSONT[any](t, buf.Bytes())
 
-		if !ok {
-			t.Errorf("Missing key %v", fld.Name)
+		expectedAlpha := 50 + 10*math.Sin(2*math.Pi*float64(i)/24)
+		if alpha := m[fldAlpha.Name].(float64); math.Abs(alpha-expectedAlpha) > 0.000001 {
+			t.Errorf("Expected alpha %v at hour %d, got %v", expectedAlpha, i, alpha)
 		}
 
-		if ts, err := time.Parse(FieldTypeTimeLayout, v); err != nil {
-			t.Errorf("Fail parse timestamp %v", err)
-		} else {
-			// Timestamp should be +(period.Nanoseconds() / nSpins) * i) for every iteration
-			expectedTime := from.Add(time.Duration((period.Nanoseconds() / nSpins) * i))
-
-			diff := expectedTime.Truncate(time.Millisecond).Sub(ts.Truncate(time.Millisecond))
-			if diff != 0 {
-				t.Errorf("Date generated out of period range %v", diff)
-			}
+		expectedBeta := math.Min(float64(50+10*i), 100)
+		if beta := m[fldBeta.Name].(float64); beta != expectedBeta {
+			t.Errorf("Expected beta %v at hour %d, got %v", expectedBeta, i, beta)
 		}
 	}
 }
 
-func Test_FieldDateAndRangeFromAndToNegativeWithCustomTemplate(t *testing.T) {
-	fld := Field{
+func Test_FieldShapeBeforeTimestampWithCustomTemplate(t *testing.T) {
+	fields := Fields{
+		{Name: "@timestamp", Type: FieldTypeDate},
+		{Name: "alpha", Type: FieldTypeLong},
+	}
+
+	// alpha is emitted before the timestamp it depends on
+	template := []byte(`{"alpha":{{.alpha}},"@timestamp":"{{.@timestamp}}"}`)
+	configYaml := []byte(`fields:
+  - name: "@timestamp"
+    period: 24h
+  - name: alpha
+    shape:
+      trend: 10
+`)
+
+	cfg, err := config.LoadConfigFromYaml(configYaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	startTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	g := makeGeneratorWithCustomTemplate(t, cfg, fields, template, 24, WithStartTime(startTime))
+
+	for i := 0; i < 24; i++ {
+		var buf bytes.Buffer
+		if err := g.Emit(&buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[any](t, buf.Bytes())
+		if alpha := m["alpha"].(float64); alpha != float64(10*i) {
+			t.Errorf("Expected alpha %d at hour %d, got %v", 10*i, i, alpha)
+		}
+	}
+}
+
+func Test_FieldShapeInvalidTimestampWithCustomTemplate(t *testing.T) {
+	template := []byte(`{"alpha":{{.alpha}}}`)
+	configYaml := []byte(`fields:
+  - name: alpha
+    shape:
+      trend: 10
+      timestamp_field: beta
+`)
+
+	cfg, err := config.LoadConfigFromYaml(configYaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewGenerator(cfg, Fields{{Name: "alpha", Type: FieldTypeLong}}, 0, WithCustomTemplate(template)); err == nil {
+		t.Fatal("Expected error for a timestamp field not present in fields definition")
+	}
+
+	if _, err := NewGenerator(cfg, Fields{{Name: "alpha", Type: FieldTypeLong}, {Name: "beta", Type: FieldTypeKeyword}}, 0, WithCustomTemplate(template)); err == nil {
+		t.Fatal("Expected error for a timestamp field that is not a date field")
+	}
+}
+func Test_FieldMissingProbabilityWithCustomTemplate(t *testing.T) {
+	fldAlpha := Field{
 		Name: "alpha",
+		Type: FieldTypeKeyword,
+	}
+
+	fldBeta := Field{
+		Name: "beta",
+		Type: FieldTypeLong,
+	}
+
+	fldGamma := Field{
+		Name: "gamma",
 		Type: FieldTypeDate,
 	}
 
-	startTime := time.Now().Truncate(time.Microsecond)
-	from := startTime.Add(10 * time.Second)
-	to := startTime.Add(-10 * time.Second)
+	testCases := []struct {
+		scenario string
+		fields   Fields
+		config   string
+	}{
+		{
+			scenario: "with fields always present",
+			fields:   Fields{fldAlpha, fldBeta, fldGamma},
+			config:   "fields:\n  - name: alpha\n    missing_probability: 0.5\n  - name: gamma\n    missing_probability: 0.5",
+		},
+		{
+			scenario: "without fields always present",
+			fields:   Fields{fldAlpha, fldBeta, fldGamma},
+			config:   "fields:\n  - name: alpha\n    missing_probability: 0.5\n  - name: beta\n    missing_probability: 0.5\n  - name: gamma\n    missing_probability: 0.5",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.scenario, func(t *testing.T) {
+			cfg, err := config.LoadConfigFromYaml([]byte(testCase.config))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			g, err := NewGenerator(cfg, testCase.fields, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			nSpins := 1000
+			vmap := make(map[string]int)
+			for i := 0; i < nSpins; i++ {
+				var buf bytes.Buffer
+				if err := g.Emit(&buf); err != nil {
+					t.Fatal(err)
+				}
+
+				m := unmarshalJSONT[any](t, buf.Bytes())
+				for k := range m {
+					vmap[k] += 1
+				}
+			}
+
+			for _, fld := range testCase.fields {
+				fieldCfg, _ := cfg.GetField(fld.Name)
+				if fieldCfg.MissingProbability == 0 && vmap[fld.Name] != nSpins {
+					t.Errorf("Expected field %s to be always present, got it %d times over %d", fld.Name, vmap[fld.Name], nSpins)
+				}
+
+				// 50% expected, leaving some room for randomness
+				if fieldCfg.MissingProbability > 0 && (vmap[fld.Name] < nSpins/4 || vmap[fld.Name] > nSpins*3/4) {
+					t.Errorf("Expected field %s to be present around half of the times, got it %d times over %d", fld.Name, vmap[fld.Name], nSpins)
+				}
+			}
+		})
+	}
+}
+
+func Test_FieldMissingProbabilityKeyOrderWithCustomTemplate(t *testing.T) {
+	fields := Fields{
+		{Name: "alpha", Type: FieldTypeKeyword},
+		{Name: "beta", Type: FieldTypeLong},
+		{Name: "gamma", Type: FieldTypeDate},
+		{Name: "delta", Type: FieldTypeKeyword},
+		{Name: "epsilon", Type: FieldTypeLong},
+	}
+
+	testCases := []struct {
+		scenario string
+		config   string
+	}{
+		{
+			scenario: "with fields always present",
+			config:   "fields:\n  - name: alpha\n    missing_probability: 0.5\n  - name: gamma\n    missing_probability: 0.5\n  - name: delta\n    missing_probability: 0.5",
+		},
+		{
+			scenario: "without fields always present",
+			config:   "fields:\n  - name: alpha\n    missing_probability: 0.5\n  - name: beta\n    missing_probability: 0.5\n  - name: gamma\n    missing_probability: 0.5\n  - name: delta\n    missing_probability: 0.5\n  - name: epsilon\n    missing_probability: 0.5",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.scenario, func(t *testing.T) {
+			cfg, err := config.LoadConfigFromYaml([]byte(testCase.config))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			g, err := NewGenerator(cfg, fields, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// the buffer is not empty, as when emitting several events in the same buffer
+			var buf bytes.Buffer
+			for i := 0; i < 100; i++ {
+				buf.WriteString(`{"previous": {`)
+				previous := buf.Len()
+				if err := g.Emit(&buf); err != nil {
+					t.Fatal(err)
+				}
+
+				event := buf.Bytes()[previous:]
+				if !json.Valid(event) {
+					t.Fatalf("Expected valid JSON, got %s", event)
+				}
+
+				// the keys are in the order of the fields definition
+				var keys []string
+				decoder := json.NewDecoder(bytes.NewReader(event))
+				_, _ = decoder.Token()
+				for decoder.More() {
+					key, _ := decoder.Token()
+					keys = append(keys, key.(string))
+
+					var value any
+					if err := decoder.Decode(&value); err != nil {
+						t.Fatal(err)
+					}
+				}
+
+				if !slices.IsSortedFunc(keys, func(a, b string) int {
+					return slices.IndexFunc(fields, func(f Field) bool { return f.Name == a }) - slices.IndexFunc(fields, func(f Field) bool { return f.Name == b })
+				}) {
+					t.Errorf("Expected keys in the order of the fields definition, got %s", event)
+				}
+			}
+		})
+	}
+}
+
+func Test_FieldMissingProbabilityInTemplateWithCustomTemplate(t *testing.T) {
+	fld := Field{
+		Name: "alpha",
+		Type: FieldTypeKeyword,
+	}
+
 	template := []byte(`{"alpha":"{{.alpha}}"}`)
-	configYaml := []byte(fmt.Sprintf("fields:\n  - name: alpha\n    range:\n      from: %s\n      to: %s", from.Format("2006-01-02T15:04:05.999999999-07:00"), to.Format("2006-01-02T15:04:05.999999999-07:00")))
+	configYaml := []byte("fields:\n  - name: alpha\n    missing_probability: 0.5")
 	t.Logf("with template: %s", string(template))
 
 	cfg, err := config.LoadConfigFromYaml(configYaml)
@@ -911,60 +1367,2334 @@ func Test_FieldDateAndRangeFromAndToNegativeWithCustomTemplate(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	g := makeGeneratorWithCustomTemplate(t, cfg, []Field{fld}, template, 10, WithStartTime(startTime))
+	if _, err := NewGenerator(cfg, []Field{fld}, 0, WithCustomTemplate(template)); err == nil {
+		t.Fatal("Expected error for a field that can be missing in a user provided template")
+	}
+}
 
-	var buf bytes.Buffer
+func Test_FieldBoolWithCustomTemplate(t *testing.T) {
+	fld := Field{
+		Name: "alpha",
+		Type: FieldTypeBool,
+	}
 
-	period := to.UTC().Sub(from.UTC())
+	template := []byte(`{"alpha":{{.alpha}}}`)
+	t.Logf("with template: %s", string(template))
+	// Enough spins, so we can make sure we get at least one true and at least one false
+	var cntTrue int
+	nSpins := 1024
+	for i := 0; i < nSpins; i++ {
+		b := testSingleTWithCustomTemplate[bool](t, fld, nil, template)
 
-	nSpins := int64(10)
-	for i := int64(0); i < nSpins; i++ {
-		if err := g.Emit(&buf); err != nil {
-			t.Fatal(err)
+		if b {
+			cntTrue += 1
 		}
+	}
 
-		m := unmarshalJSONT[string](t, buf.Bytes())
-		buf.Reset()
+	if cntTrue == 0 {
+		t.Errorf("No true values, really?")
+	}
 
-		if len(m) != 1 {
-			t.Errorf("Expected map size 1, got %d", len(m))
-		}
+	if cntTrue == nSpins {
+		t.Errorf("No false values, really?")
+	}
+}
 
-		v, ok := m[fld.Name]
+func Test_FieldConstKeywordWithCustomTemplate(t *testing.T) {
 
-		if !ok {
-			t.Errorf("Missing key %v", fld.Name)
-		}
+	fld := Field{
+		Name:  "alpha",
+		Type:  FieldTypeConstantKeyword,
+		Value: "constant_keyword",
+	}
 
-		if ts, err := time.Parse(FieldTypeTimeLayout, v); err != nil {
-			t.Errorf("Fail parse timestamp %v", err)
-		} else {
-			// Timestamp should be +(period.Nanoseconds() / nSpins) * i) for every iteration
-			expectedTime := from.Add(time.Duration((period.Nanoseconds() / nSpins) * (nSpins - i)))
+	template := []byte(`{"alpha":{{.alpha}}}`)
+	t.Logf("with template: %s", string(template))
+	b := testSingleTWithCustomTemplate[string](t, fld, nil, template)
+	if b != fld.Value {
+		t.Errorf("static value not match")
+	}
+}
 
-			diff := expectedTime.Truncate(time.Millisecond).Sub(ts.Truncate(time.Millisecond))
-			if diff != 0 {
-				t.Errorf("Date generated out of period range %v", diff)
-			}
-		}
+func Test_FieldStaticOverrideStringWithCustomTemplate(t *testing.T) {
+	fld := Field{
+		Name: "alpha",
+		Type: FieldTypeKeyword,
+	}
+
+	yaml := []byte("fields:\n  - name: alpha\n    value: beta")
+	template := []byte(`{"alpha":{{.alpha}}}`)
+	t.Logf("with template: %s", string(template))
+	b := testSingleTWithCustomTemplate[string](t, fld, yaml, template)
+	if b != "beta" {
+		t.Errorf("static value not match")
 	}
 }
 
-func Test_FieldIPWithCustomTemplate(t *testing.T) {
+func Test_FieldStaticOverrideNumericWithCustomTemplate(t *testing.T) {
 	fld := Field{
+
 		Name: "alpha",
-		Type: FieldTypeIP,
+		Type: FieldTypeKeyword,
+	}
+
+	yaml := []byte("fields:\n  - name: alpha\n    value: 33.")
+	template := []byte(`{"alpha":{{.alpha}}}`)
+	t.Logf("with template: %s", string(template))
+	b := testSingleTWithCustomTemplate[float64](t, fld, yaml, template)
+
+	if b != 33.0 {
+		t.Errorf("static value not match")
+	}
+}
+
+func Test_FieldStaticOverrideBoolWithCustomTemplate(t *testing.T) {
+	fld := Field{
+		Name: "alpha",
+		Type: FieldTypeKeyword,
+	}
+
+	yaml := []byte("fields:\n  - name: alpha\n    value: true")
+	template := []byte(`{"alpha":{{.alpha}}}`)
+	t.Logf("with template: %s", string(template))
+	b := testSingleTWithCustomTemplate[bool](t, fld, yaml, template)
+
+	if b != true {
+		t.Errorf("static value not match")
+	}
+}
+
+func Test_FieldGeoPointWithCustomTemplate(t *testing.T) {
+	fld := Field{
+		Name: "alpha",
+		Type: FieldTypeGeoPoint,
 	}
 
 	template := []byte(`{"alpha":"{{.alpha}}"}`)
 	t.Logf("with template: %s", string(template))
-	nSpins := rand.Intn(1024) + 1
+	nSpins := 1024
 	for i := 0; i < nSpins; i++ {
 
 		b := testSingleTWithCustomTemplate[string](t, fld, nil, template)
 
-		if ip := net.ParseIP(b); ip == nil {
-			t.Errorf("Fail parse ip %s", b)
+		// Expect geo point in form of lat,long
+		// where lat is [-90.0..90.0]
+		// and long is  [-180.0..180.0]
+
+		s := strings.Split(b, ",")
+		if len(s) != 2 {
+			t.Fatal("expected comma separated lat,long")
+		}
+
+		lat := s[0]
+		long := s[1]
+
+		// no whitespace please
+		if len(lat) != len(strings.TrimSpace(lat)) {
+			t.Errorf("extra whitespace on latitude %s", lat)
+		}
+
+		// no whitespace please
+		if len(long) != len(strings.TrimSpace(long)) {
+			t.Errorf("extra whitespace on longitude %s", long)
+		}
+
+		latF, err := strconv.ParseFloat(lat, 64)
+		if err != nil {
+			t.Errorf("Fail parse latitude as float")
+		}
+		longF, err := strconv.ParseFloat(long, 64)
+		if err != nil {
+			t.Errorf("Fail parse longitude as float")
+		}
+
+		if latF < -90.0 || latF > 90.0 {
+			t.Errorf("latitude out of range %v", latF)
+		}
+
+		if longF < -180.0 || longF > 180.0 {
+			t.Errorf("longitutde out of range %v", longF)
+		}
+	}
+}
+
+func Test_FieldDateWithCustomTemplate(t *testing.T) {
+	fld := Field{
+		Name: "alpha",
+		Type: FieldTypeDate,
+	}
+
+	template := []byte(`{"alpha":"{{.alpha}}"}`)
+	t.Logf("with template: %s", string(template))
+	nSpins := rand.Intn(1024) + 1
+	for i := 0; i < nSpins; i++ {
+		startTime := time.Now().Truncate(time.Microsecond)
+		b := testSingleTWithCustomTemplate[string](t, fld, nil, template, WithStartTime(startTime))
+
+		if ts, err := time.Parse(FieldTypeTimeLayout, b); err != nil {
+			t.Errorf("Fail parse timestamp %v", err)
+		} else {
+			// Timestamp should be from now within a FieldTypeDurationSpan milliseconds of slop
+			diff := ts.Sub(startTime)
+			if diff < 0 || diff > FieldTypeDurationSpan*time.Millisecond {
+				t.Errorf("Data generated before now, diff: %v", diff)
+			}
+		}
+	}
+}
+
+func Test_FieldDateAndPeriodPositiveWithCustomTemplate(t *testing.T) {
+	fld := Field{
+		Name: "alpha",
+		Type: FieldTypeDate,
+	}
+
+	template := []byte(`{"alpha":"{{.alpha}}"}`)
+	configYaml := []byte("fields:\n  - name: alpha\n    period: 10s")
+	t.Logf("with template: %s", string(template))
+
+	cfg, err := config.LoadConfigFromYaml(configYaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	startTime := time.Now().Truncate(time.Microsecond)
+	g := makeGeneratorWithCustomTemplate(t, cfg, []Field{fld}, template, 10, WithStartTime(startTime))
+
+	var buf bytes.Buffer
+
+	nSpins := 10
+	for i := 0; i < nSpins; i++ {
+		if err := g.Emit(&buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[string](t, buf.Bytes())
+		buf.Reset()
+
+		if len(m) != 1 {
+			t.Errorf("Expected map size 1, got %d", len(m))
+		}
+
+		v, ok := m[fld.Name]
+
+		if !ok {
+			t.Errorf("Missing key %v", fld.Name)
+		}
+
+		if ts, err := time.Parse(FieldTypeTimeLayout, v); err != nil {
+			t.Errorf("Fail parse timestamp %v", err)
+		} else {
+			// Timestamp should be +1s for every iteration
+			expectedTime := startTime.Add(time.Second * time.Duration(i))
+
+			diff := expectedTime.Truncate(time.Millisecond).Sub(ts.Truncate(time.Millisecond))
+			if diff != 0 {
+				t.Errorf("Date generated out of period range %v", diff)
+			}
+		}
+	}
+}
+
+func Test_FieldDateAndPeriodNegativeWithCustomTemplate(t *testing.T) {
+	fld := Field{
+		Name: "alpha",
+		Type: FieldTypeDate,
+	}
+
+	template := []byte(`{"alpha":"{{.alpha}}"}`)
+	configYaml := []byte("fields:\n  - name: alpha\n    period: -10s")
+	t.Logf("with template: %s", string(template))
+
+	cfg, err := config.LoadConfigFromYaml(configYaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	startTime := time.Now().Truncate(time.Microsecond)
+	g := makeGeneratorWithCustomTemplate(t, cfg, []Field{fld}, template, 10, WithStartTime(startTime))
+
+	var buf bytes.Buffer
+
+	nSpins := 10
+	for i := 0; i < nSpins; i++ {
+		if err := g.Emit(&buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[string](t, buf.Bytes())
+		buf.Reset()
+
+		if len(m) != 1 {
+			t.Errorf("Expected map size 1, got %d", len(m))
+		}
+
+		v, ok := m[fld.Name]
+
+		if !ok {
+			t.Errorf("Missing key %v", fld.Name)
+		}
+
+		if ts, err := time.Parse(FieldTypeTimeLayout, v); err != nil {
+			t.Errorf("Fail parse timestamp %v", err)
+		} else {
+			// Timestamp should be +1s for every iteration
+			expectedTime := startTime.Add(-10*time.Second + time.Second*time.Duration(i))
+
+			diff := expectedTime.Truncate(time.Millisecond).Sub(ts.Truncate(time.Millisecond))
+			if diff != 0 {
+				t.Errorf("Date generated out of period range %v", diff)
+			}
+		}
+	}
+}
+
+func Test_FieldDateAndRangeFromInThePastWithCustomTemplate(t *testing.T) {
+	fld := Field{
+		Name: "alpha",
+		Type: FieldTypeDate,
+	}
+
+	startTime := time.Now().Truncate(time.Microsecond)
+	from := startTime.Add(-10 * time.Second)
+	template := []byte(`{"alpha":"{{.alpha}}"}`)
+	configYaml := []byte(fmt.Sprintf("fields:\n  - name: alpha\n    range:\n      from: %s", from.Format("2006-01-02T15:04:05.999999999-07:00")))
+	t.Logf("with template: %s", string(template))
+
+	cfg, err := config.LoadConfigFromYaml(configYaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := makeGeneratorWithCustomTemplate(t, cfg, []Field{fld}, template, 10, WithStartTime(startTime))
+
+	var buf bytes.Buffer
+
+	period := startTime.UTC().Sub(from.UTC())
+
+	nSpins := int64(10)
+	for i := int64(0); i < nSpins; i++ {
+		if err := g.Emit(&buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[string](t, buf.Bytes())
+		buf.Reset()
+
+		if len(m) != 1 {
+			t.Errorf("Expected map size 1, got %d", len(m))
+		}
+
+		v, ok := m[fld.Name]
+
+		if !ok {
+			t.Errorf("Missing key %v", fld.Name)
+		}
+
+		if ts, err := time.Parse(FieldTypeTimeLayout, v); err != nil {
+			t.Errorf("Fail parse timestamp %v", err)
+		} else {
+			// Timestamp should be +(period.Nanoseconds() / nSpins) * i) for every iteration
+			expectedTime := startTime.Add(time.Duration((period.Nanoseconds() / nSpins) * i))
+
+			diff := expectedTime.Truncate(time.Millisecond).Sub(ts.Truncate(time.Millisecond))
+			if diff != 0 {
+				t.Errorf("Date generated out of period range %v", diff)
+			}
+		}
+	}
+}
+
+func Test_FieldDateAndRangeFromInTheFutureWithCustomTemplate(t *testing.T) {
+	fld := Field{
+		Name: "alpha",
+		Type: FieldTypeDate,
+	}
+
+	startTime := time.Now().Truncate(time.Microsecond)
+	from := startTime.Add(10 * time.Second)
+	template := []byte(`{"alpha":"{{.alpha}}"}`)
+	configYaml := []byte(fmt.Sprintf("fields:\n  - name: alpha\n    range:\n      from: %s", from.Format("2006-01-02T15:04:05.999999999-07:00")))
+	t.Logf("with template: %s", string(template))
+
+	cfg, err := config.LoadConfigFromYaml(configYaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := makeGeneratorWithCustomTemplate(t, cfg, []Field{fld}, template, 10, WithStartTime(startTime))
+
+	var buf bytes.Buffer
+
+	period := from.UTC().Sub(startTime.UTC())
+
+	nSpins := int64(10)
+	for i := int64(0); i < nSpins; i++ {
+		if err := g.Emit(&buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[string](t, buf.Bytes())
+		buf.Reset()
+
+		if len(m) != 1 {
+			t.Errorf("Expected map size 1, got %d", len(m))
+		}
+
+		v, ok := m[fld.Name]
+
+		if !ok {
+			t.Errorf("Missing key %v", fld.Name)
+		}
+
+		if ts, err := time.Parse(FieldTypeTimeLayout, v); err != nil {
+			t.Errorf("Fail parse timestamp %v", err)
+		} else {
+			// Timestamp should be +(period.Nanoseconds() / nSpins) * i) for every iteration
+			expectedTime := startTime.Add(time.Duration((period.Nanoseconds() / nSpins) * i))
+
+			diff := expectedTime.Truncate(time.Millisecond).Sub(ts.Truncate(time.Millisecond))
+			if diff != 0 {
+				t.Errorf("Date generated out of period range %v", diff)
+			}
+		}
+	}
+}
+
+func Test_FieldDateAndRangeToInThePastWithCustomTemplate(t *testing.T) {
+	fld := Field{
+		Name: "alpha",
+		Type: FieldTypeDate,
+	}
+
+	startTime := time.Now().Truncate(time.Microsecond)
+	to := startTime.Add(-10 * time.Second)
+	template := []byte(`{"alpha":"{{.alpha}}"}`)
+	configYaml := []byte(fmt.Sprintf("fields:\n  - name: alpha\n    range:\n      to: %s", to.Format("2006-01-02T15:04:05.999999999-07:00")))
+	t.Logf("with template: %s", string(template))
+
+	cfg, err := config.LoadConfigFromYaml(configYaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := makeGeneratorWithCustomTemplate(t, cfg, []Field{fld}, template, 10, WithStartTime(startTime))
+
+	var buf bytes.Buffer
+
+	period := startTime.UTC().Sub(to.UTC())
+
+	nSpins := int64(10)
+	for i := int64(0); i < nSpins; i++ {
+		if err := g.Emit(&buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[string](t, buf.Bytes())
+		buf.Reset()
+
+		if len(m) != 1 {
+			t.Errorf("Expected map size 1, got %d", len(m))
+		}
+
+		v, ok := m[fld.Name]
+
+		if !ok {
+			t.Errorf("Missing key %v", fld.Name)
+		}
+
+		if ts, err := time.Parse(FieldTypeTimeLayout, v); err != nil {
+			t.Errorf("Fail parse timestamp %v", err)
+		} else {
+			// Timestamp should be +(period.Nanoseconds() / nSpins) * i) for every iteration
+			expectedTime := startTime.Add(time.Duration((period.Nanoseconds() / nSpins) * i))
+
+			diff := expectedTime.Truncate(time.Millisecond).Sub(ts.Truncate(time.Millisecond))
+			if diff != 0 {
+				t.Errorf("Date generated out of period range %v", diff)
+			}
+		}
+	}
+}
+
+func Test_FieldDateAndRangeToInTheFutureWithCustomTemplate(t *testing.T) {
+	fld := Field{
+		Name: "alpha",
+		Type: FieldTypeDate,
+	}
+
+	startTime := time.Now().Truncate(time.Microsecond)
+	to := startTime.Add(10 * time.Second)
+	template := []byte(`{"alpha":"{{.alpha}}"}`)
+	configYaml := []byte(fmt.Sprintf("fields:\n  - name: alpha\n    range:\n      to: %s", to.Format("2006-01-02T15:04:05.999999999-07:00")))
+	t.Logf("with template: %s", string(template))
+
+	cfg, err := config.LoadConfigFromYaml(configYaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := makeGeneratorWithCustomTemplate(t, cfg, []Field{fld}, template, 10, WithStartTime(startTime))
+
+	var buf bytes.Buffer
+
+	period := to.UTC().Sub(startTime.UTC())
+
+	nSpins := int64(10)
+	for i := int64(0); i < nSpins; i++ {
+		if err := g.Emit(&buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[string](t, buf.Bytes())
+		buf.Reset()
+
+		if len(m) != 1 {
+			t.Errorf("Expected map size 1, got %d", len(m))
+		}
+
+		v, ok := m[fld.Name]
+
+		if !ok {
+			t.Errorf("Missing key %v", fld.Name)
+		}
+
+		if ts, err := time.Parse(FieldTypeTimeLayout, v); err != nil {
+			t.Errorf("Fail parse timestamp %v", err)
+		} else {
+			// Timestamp should be +(period.Nanoseconds() / nSpins) * i) for every iteration
+			expectedTime := startTime.Add(time.Duration((period.Nanoseconds() / nSpins) * i))
+
+			diff := expectedTime.Truncate(time.Millisecond).Sub(ts.Truncate(time.Millisecond))
+			if diff != 0 {
+				t.Errorf("Date generated out of period range %v", diff)
+			}
+		}
+	}
+}
+
+func Test_FieldDateAndRangeFromAndToPositiveWithCustomTemplate(t *testing.T) {
+	fld := Field{
+		Name: "alpha",
+		Type: FieldTypeDate,
+	}
+
+	startTime := time.Now().Truncate(time.Microsecond)
+	from := startTime.Add(-10 * time.Second)
+	to := startTime.Add(10 * time.Second)
+	template := []byte(`{"alpha":"{{.alpha}}"}`)
+	configYaml := []byte(fmt.Sprintf("fields:\n  - name: alpha\n    range:\n      from: %s\n      to: %s", from.Format("2006-01-02T15:04:05.999999999-07:00"), to.Format("2006-01-02T15:04:05.999999999-07:00")))
+	t.Logf("with template: %s", string(template))
+
+	cfg, err := config.LoadConfigFromYaml(configYaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := makeGeneratorWithCustomTemplate(t, cfg, []Field{fld}, template, 10, WithStartTime(startTime))
+
+	var buf bytes.Buffer
+
+	period := to.UTC().Sub(from.UTC())
+
+	nSpins := int64(10)
+	for i := int64(0); i < nSpins; i++ {
+		if err := g.Emit(&buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[string](t, buf.Bytes())
+		buf.Reset()
+
+		if len(m) != 1 {
+			t.Errorf("Expected map size 1, got %d", len(m))
+		}
+
+		v, ok := m[fld.Name]
+
+		if !ok {
+			t.Errorf("Missing key %v", fld.Name)
+		}
+
+		if ts, err := time.Parse(FieldTypeTimeLayout, v); err != nil {
+			t.Errorf("Fail parse timestamp %v", err)
+		} else {
+			// Timestamp should be +(period.Nanoseconds() / nSpins) * i) for every iteration
+			expectedTime := from.Add(time.Duration((period.Nanoseconds() / nSpins) * i))
+
+			diff := expectedTime.Truncate(time.Millisecond).Sub(ts.Truncate(time.Millisecond))
+			if diff != 0 {
+				t.Errorf("Date generated out of period range %v", diff)
+			}
+		}
+	}
+}
+
+func Test_FieldDateAndRangeFromAndToNegativeWithCustomTemplate(t *testing.T) {
+	fld := Field{
+		Name: "alpha",
+		Type: FieldTypeDate,
+	}
+
+	startTime := time.Now().Truncate(time.Microsecond)
+	from := startTime.Add(10 * time.Second)
+	to := startTime.Add(-10 * time.Second)
+	template := []byte(`{"alpha":"{{.alpha}}"}`)
+	configYaml := []byte(fmt.Sprintf("fields:\n  - name: alpha\n    range:\n      from: %s\n      to: %s", from.Format("2006-01-02T15:04:05.999999999-07:00"), to.Format("2006-01-02T15:04:05.999999999-07:00")))
+	t.Logf("with template: %s", string(template))
+
+	cfg, err := config.LoadConfigFromYaml(configYaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := makeGeneratorWithCustomTemplate(t, cfg, []Field{fld}, template, 10, WithStartTime(startTime))
+
+	var buf bytes.Buffer
+
+	period := to.UTC().Sub(from.UTC())
+
+	nSpins := int64(10)
+	for i := int64(0); i < nSpins; i++ {
+		if err := g.Emit(&buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[string](t, buf.Bytes())
+		buf.Reset()
+
+		if len(m) != 1 {
+			t.Errorf("Expected map size 1, got %d", len(m))
+		}
+
+		v, ok := m[fld.Name]
+
+		if !ok {
+			t.Errorf("Missing key %v", fld.Name)
+		}
+
+		if ts, err := time.Parse(FieldTypeTimeLayout, v); err != nil {
+			t.Errorf("Fail parse timestamp %v", err)
+		} else {
+			// Timestamp should be +(period.Nanoseconds() / nSpins) * i) for every iteration
+			expectedTime := from.Add(time.Duration((period.Nanoseconds() / nSpins) * (nSpins - i)))
+
+			diff := expectedTime.Truncate(time.Millisecond).Sub(ts.Truncate(time.Millisecond))
+			if diff != 0 {
+				t.Errorf("Date generated out of period range %v", diff)
+			}
+		}
+	}
+}
+
+func Test_FieldIPWithCustomTemplate(t *testing.T) {
+	fld := Field{
+		Name: "alpha",
+		Type: FieldTypeIP,
+	}
+
+	template := []byte(`{"alpha":"{{.alpha}}"}`)
+	t.Logf("with template: %s", string(template))
+	nSpins := rand.Intn(1024) + 1
+	for i := 0; i < nSpins; i++ {
+
+		b := testSingleTWithCustomTemplate[string](t, fld, nil, template)
+
+		if ip := net.ParseIP(b); ip == nil {
+			t.Errorf("Fail parse ip %s", b)
+		}
+	}
+}
+
+func Test_FieldIPConfigWithCustomTemplate(t *testing.T) {
+	fields := []Field{
+		{Name: "source.ip", Type: FieldTypeIP},
+		{Name: "destination.ip", Type: FieldTypeIP},
+		{Name: "host.ip", Type: FieldTypeIP},
+	}
+
+	template := []byte(`{"source.ip":"{{.source.ip}}","destination.ip":"{{.destination.ip}}","host.ip":"{{.host.ip}}"}`)
+	configYaml := []byte(`fields:
+  - name: source.ip
+    ip:
+      cidrs: ["10.0.0.0/8", "192.168.1.0/28", "2001:db8::/32"]
+      ipv6_ratio: 0.5
+  - name: destination.ip
+    ip:
+      private: true
+      ipv6_ratio: 1
+  - name: host.ip
+    cardinality: 5
+    ip:
+      cidrs: ["172.16.0.0/12"]
+`)
+	t.Logf("with template: %s", string(template))
+
+	cfg, err := config.LoadConfigFromYaml(configYaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nSpins := 1000
+	g := makeGeneratorWithCustomTemplate(t, cfg, fields, template, uint64(nSpins))
+
+	sourcePrefixes := []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("192.168.1.0/28"),
+		netip.MustParsePrefix("2001:db8::/32"),
+	}
+
+	var sourceIPv6 int
+	hostIPs := make(map[string]struct{})
+	for i := 0; i < nSpins; i++ {
+		var buf bytes.Buffer
+		if err := g.Emit(&buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[string](t, buf.Bytes())
+
+		sourceIP := netip.MustParseAddr(m["source.ip"])
+		inPrefix := false
+		for _, prefix := range sourcePrefixes {
+			inPrefix = inPrefix || prefix.Contains(sourceIP)
+		}
+
+		if !inPrefix {
+			t.Errorf("Expected source.ip %s to be in the configured cidrs", sourceIP)
+		}
+
+		if sourceIP.Is6() {
+			sourceIPv6++
+		}
+
+		if destinationIP := netip.MustParseAddr(m["destination.ip"]); !destinationIP.Is6() || !destinationIP.IsPrivate() {
+			t.Errorf("Expected destination.ip %s to be a private ipv6 address", destinationIP)
+		}
+
+		if hostIP := netip.MustParseAddr(m["host.ip"]); !netip.MustParsePrefix("172.16.0.0/12").Contains(hostIP) {
+			t.Errorf("Expected host.ip %s to be in 172.16.0.0/12", hostIP)
+		}
+
+		hostIPs[m["host.ip"]] = struct{}{}
+	}
+
+	if sourceIPv6 < nSpins*4/10 || sourceIPv6 > nSpins*6/10 {
+		t.Errorf("Expected around 50%% of ipv6 source.ip, got %d over %d", sourceIPv6, nSpins)
+	}
+
+	if len(hostIPs) != 5 {
+		t.Errorf("Expected 5 different host.ip, got %d", len(hostIPs))
+	}
+}
+
+func Test_FieldGeoPointConfigWithCustomTemplate(t *testing.T) {
+	fields := Fields{
+		{Name: "box", Type: FieldTypeGeoPoint},
+		{Name: "city", Type: FieldTypeGeoPoint},
+		{Name: "polygon", Type: FieldTypeGeoPoint},
+		{Name: "wkt", Type: FieldTypeGeoPoint},
+	}
+
+	configYaml := []byte(`fields:
+  - name: box
+    geo_point:
+      bounding_boxes:
+        - {min_lat: 40, max_lat: 41, min_lon: -75, max_lon: -73}
+  - name: city
+    geo_point:
+      format: object
+      cities:
+        - name: paris
+          radius: 10
+        - lat: -33.8688
+          lon: 151.2093
+          radius: 10
+  - name: polygon
+    geo_point:
+      format: geojson
+      polygons:
+        - [[0, 0], [10, 0], [0, 10]]
+  - name: wkt
+    geo_point:
+      format: wkt
+      bounding_boxes:
+        - {min_lat: -1, max_lat: 1, min_lon: -1, max_lon: 1}
+`)
+
+	cfg, err := config.LoadConfigFromYaml(configYaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the template is auto-generated, so that the wrap of the values depends on the format
+	g, err := NewGenerator(cfg, fields, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wktRegex := regexp.MustCompile(`^POINT \((-?[0-9.]+) (-?[0-9.]+)\)$`)
+	for i := 0; i < 100; i++ {
+		var buf bytes.Buffer
+		if err := g.Emit(&buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[any](t, buf.Bytes())
+
+		var lat, lon float64
+		if _, err := fmt.Sscanf(m["box"].(string), "%f,%f", &lat, &lon); err != nil || lat < 40 || lat > 41 || lon < -75 || lon > -73 {
+			t.Errorf("Expected box point in the bounding box, got %v", m["box"])
+		}
+
+		city := m["city"].(map[string]any)
+		lat, lon = city["lat"].(float64), city["lon"].(float64)
+		inParis := math.Abs(lat-48.8566) < 0.1 && math.Abs(lon-2.3522) < 0.15
+		inSydney := math.Abs(lat+33.8688) < 0.1 && math.Abs(lon-151.2093) < 0.15
+		if !inParis && !inSydney {
+			t.Errorf("Expected city point close to Paris or Sydney, got %v", city)
+		}
+
+		polygon := m["polygon"].(map[string]any)
+		coordinates := polygon["coordinates"].([]any)
+		lon, lat = coordinates[0].(float64), coordinates[1].(float64)
+		if polygon["type"] != "Point" || lat < 0 || lon < 0 || lat+lon > 10 {
+			t.Errorf("Expected polygon point in the polygon, got %v", polygon)
+		}
+
+		if matches := wktRegex.FindStringSubmatch(m["wkt"].(string)); matches == nil {
+			t.Errorf("Expected wkt point, got %v", m["wkt"])
+		}
+	}
+}
+
+func Test_FieldTextWithCustomTemplate(t *testing.T) {
+	fields := []Field{
+		{Name: "message", Type: FieldTypeMatchOnlyText},
+		{Name: "lorem", Type: FieldTypeText},
+		{Name: "log", Type: FieldTypeWildcard},
+		{Name: "short", Type: FieldTypeText},
+		{Name: "long", Type: FieldTypeText},
+	}
+
+	template := []byte(`{"message":"{{.message}}","lorem":"{{.lorem}}","log":"{{.log}}","short":"{{.short}}","long":"{{.long}}"}`)
+	configYaml := []byte(`fields:
+  - name: lorem
+    text:
+      style: lorem
+      min_words: 3
+      max_words: 3
+      min_sentences: 2
+      max_sentences: 2
+  - name: log
+    text:
+      style: log
+  - name: short
+    text:
+      max_length: 20
+  - name: long
+    text:
+      style: words
+      min_length: 200
+`)
+	t.Logf("with template: %s", string(template))
+
+	cfg, err := config.LoadConfigFromYaml(configYaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := makeGeneratorWithCustomTemplate(t, cfg, fields, template, 0)
+
+	logRegex := regexp.MustCompile(`^(DEBUG|INFO|WARN|ERROR) \[\S+\.\S+\] .+ \S+=\d+$`)
+	for i := 0; i < 100; i++ {
+		var buf bytes.Buffer
+		if err := g.Emit(&buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[string](t, buf.Bytes())
+
+		words := len(strings.Fields(m["message"]))
+		if words < 5 || words > 15 || !strings.HasSuffix(m["message"], ".") {
+			t.Errorf("Expected a sentence of 5 to 15 words, got %s", m["message"])
+		}
+
+		if words := len(strings.Fields(m["lorem"])); words != 6 {
+			t.Errorf("Expected two sentences of 3 words, got %s", m["lorem"])
+		}
+
+		if !logRegex.MatchString(m["log"]) {
+			t.Errorf("Expected a log line, got %s", m["log"])
+		}
+
+		if len(m["short"]) > 20 {
+			t.Errorf("Expected at most 20 characters, got %s", m["short"])
+		}
+
+		if len(m["long"]) < 200 {
+			t.Errorf("Expected at least 200 characters, got %s", m["long"])
+		}
+	}
+}
+
+func Test_FieldVersionWithCustomTemplate(t *testing.T) {
+	fields := []Field{
+		{Name: "agent.version", Type: FieldTypeVersion},
+		{Name: "package.version", Type: FieldTypeVersion},
+		{Name: "service.version", Type: FieldTypeVersion},
+	}
+
+	template := []byte(`{"agent.version":"{{.agent.version}}","package.version":"{{.package.version}}","service.version":"{{.service.version}}"}`)
+	configYaml := []byte(`fields:
+  - name: agent.version
+    cardinality: 5
+    version:
+      major: {min: 8, max: 8}
+      minor: {max: 17}
+      prerelease_probability: 0.5
+  - name: package.version
+    enum: ["1.0.0", "1.1.0"]
+  - name: service.version
+    version:
+      major: {min: 2}
+`)
+	t.Logf("with template: %s", string(template))
+
+	cfg, err := config.LoadConfigFromYaml(configYaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nSpins := 1000
+	g := makeGeneratorWithCustomTemplate(t, cfg, fields, template, uint64(nSpins))
+
+	agentRegex := regexp.MustCompile(`^8\.(\d|1[0-7])\.\d+(-(alpha|beta|rc)\.[1-5]|-SNAPSHOT)?$`)
+	serviceRegex := regexp.MustCompile(`^[2-9]\.\d+\.\d+$`)
+	agentVersions := make(map[string]struct{})
+	for i := 0; i < nSpins; i++ {
+		var buf bytes.Buffer
+		if err := g.Emit(&buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[string](t, buf.Bytes())
+
+		if !agentRegex.MatchString(m["agent.version"]) {
+			t.Errorf("Expected agent.version to be a 8.x version, got %s", m["agent.version"])
+		}
+
+		agentVersions[m["agent.version"]] = struct{}{}
+
+		if m["package.version"] != "1.0.0" && m["package.version"] != "1.1.0" {
+			t.Errorf("Expected package.version to be in the enum, got %s", m["package.version"])
+		}
+
+		if !serviceRegex.MatchString(m["service.version"]) {
+			t.Errorf("Expected service.version to have a major version of at least 2, got %s", m["service.version"])
+		}
+	}
+
+	if len(agentVersions) != 5 {
+		t.Errorf("Expected 5 different agent.version values, got %d", len(agentVersions))
+	}
+}
+
+func Test_FieldUnsignedLongWithCustomTemplate(t *testing.T) {
+	fields := []Field{
+		{Name: "bytes", Type: FieldTypeUnsignedLong},
+		{Name: "ranged", Type: FieldTypeUnsignedLong},
+		{Name: "fuzzy", Type: FieldTypeUnsignedLong},
+		{Name: "packets", Type: FieldTypeUnsignedLong},
+		{Name: "requests", Type: FieldTypeUnsignedLong},
+	}
+
+	template := []byte(`{"bytes":{{.bytes}},"ranged":{{.ranged}},"fuzzy":{{.fuzzy}},"packets":{{.packets}},"requests":{{.requests}}}`)
+	configYaml := []byte(`fields:
+  - name: bytes
+    counter: true
+    fuzziness: 0.5
+  - name: ranged
+    range:
+      min: 10000000000000000000
+      max: 12000000000000000000
+  - name: fuzzy
+    fuzziness: 0.1
+    range:
+      min: 10000000000000000000
+  - name: packets
+    counter: true
+    counter_reset:
+      strategy: after_n
+      reset_after_n: 10
+  - name: requests
+    counter: true
+    fuzziness: 0.5
+    counter_reset:
+      strategy: after_n
+      reset_after_n: 100
+`)
+	t.Logf("with template: %s", string(template))
+
+	cfg, err := config.LoadConfigFromYaml(configYaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nSpins := 2000
+	g := makeGeneratorWithCustomTemplate(t, cfg, fields, template, uint64(nSpins))
+
+	var overflowed bool
+	var previousBytes uint64
+	for i := 0; i < nSpins; i++ {
+		var buf bytes.Buffer
+		if err := g.Emit(&buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[uint64](t, buf.Bytes())
+
+		if m["bytes"] > math.MaxInt64 {
+			overflowed = true
+		}
+
+		// the counter wraps around to zero only past math.MaxUint64
+		if m["bytes"] < previousBytes && previousBytes < math.MaxUint64/2 {
+			t.Errorf("Expected bytes counter to increase, got %d after %d", m["bytes"], previousBytes)
+		}
+
+		previousBytes = m["bytes"]
+
+		if m["ranged"] < 10000000000000000000 || m["ranged"] > 12000000000000000000 {
+			t.Errorf("Expected ranged to be in range, got %d", m["ranged"])
+		}
+
+		if m["fuzzy"] < 10000000000000000000 {
+			t.Errorf("Expected fuzzy to be greater than the range min, got %d", m["fuzzy"])
+		}
+
+		if i%10 == 0 && m["packets"] != 0 {
+			t.Errorf("Expected packets counter to reset to 0, got %d", m["packets"])
+		}
+
+		// the fuzzy counter must grow again after each reset to 0
+		if i%100 == 0 && m["requests"] != 0 {
+			t.Errorf("Expected requests counter to reset to 0, got %d", m["requests"])
+		}
+
+		if i%100 == 99 && m["requests"] == 0 {
+			t.Errorf("Expected requests counter to grow after the reset, got %d", m["requests"])
+		}
+	}
+
+	if !overflowed {
+		t.Errorf("Expected bytes counter to grow past %d", int64(math.MaxInt64))
+	}
+}
+
+func Test_FieldHistogramAndAggregateMetricDoubleWithCustomTemplate(t *testing.T) {
+	fields := []Field{
+		{Name: "latency", Type: FieldTypeHistogram},
+		{Name: "cpu", Type: FieldTypeAggregateMetricDouble},
+	}
+
+	template := []byte(`{"latency":{{.latency}},"cpu":{{.cpu}}}`)
+	configYaml := []byte(`fields:
+  - name: latency
+    buckets: 5
+    samples:
+      min: 5
+      max: 50
+    distribution:
+      type: normal
+      mean: 100
+      stddev: 10
+    range:
+      min: 0
+  - name: cpu
+    cardinality: 3
+    range:
+      min: 0
+      max: 1
+`)
+	t.Logf("with template: %s", string(template))
+
+	cfg, err := config.LoadConfigFromYaml(configYaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nSpins := 100
+	g := makeGeneratorWithCustomTemplate(t, cfg, fields, template, uint64(nSpins))
+
+	type event struct {
+		Latency struct {
+			Values []float64 `json:"values"`
+			Counts []int64   `json:"counts"`
+		} `json:"latency"`
+		CPU struct {
+			Min        float64 `json:"min"`
+			Max        float64 `json:"max"`
+			Sum        float64 `json:"sum"`
+			ValueCount int64   `json:"value_count"`
+		} `json:"cpu"`
+	}
+
+	cpuValues := make(map[float64]struct{})
+	for i := 0; i < nSpins; i++ {
+		var buf bytes.Buffer
+		if err := g.Emit(&buf); err != nil {
+			t.Fatal(err)
+		}
+
+		var e event
+		if err := json.Unmarshal(buf.Bytes(), &e); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(e.Latency.Values) == 0 || len(e.Latency.Values) > 5 || len(e.Latency.Values) != len(e.Latency.Counts) {
+			t.Errorf("Expected up to 5 buckets with a count each, got %v", e.Latency)
+		}
+
+		var totCount int64
+		for j, count := range e.Latency.Counts {
+			if j > 0 && e.Latency.Values[j] <= e.Latency.Values[j-1] {
+				t.Errorf("Expected histogram values to be strictly increasing, got %v", e.Latency.Values)
+			}
+
+			if count <= 0 {
+				t.Errorf("Expected histogram counts to be greater than zero, got %v", e.Latency.Counts)
+			}
+
+			totCount += count
+		}
+
+		if totCount < 5 || totCount > 50 {
+			t.Errorf("Expected between 5 and 50 samples, got %d", totCount)
+		}
+
+		if e.CPU.Min < 0 || e.CPU.Max > 1 || e.CPU.Min > e.CPU.Max {
+			t.Errorf("Expected cpu min and max to be in range, got %v", e.CPU)
+		}
+
+		if e.CPU.ValueCount < 1 || e.CPU.ValueCount > 100 {
+			t.Errorf("Expected cpu value_count to be between 1 and 100, got %d", e.CPU.ValueCount)
+		}
+
+		if e.CPU.Sum < e.CPU.Min*float64(e.CPU.ValueCount)-1e-9 || e.CPU.Sum > e.CPU.Max*float64(e.CPU.ValueCount)+1e-9 {
+			t.Errorf("Expected cpu sum to be consistent with min, max and value_count, got %v", e.CPU)
+		}
+
+		cpuValues[e.CPU.Sum] = struct{}{}
+	}
+
+	if len(cpuValues) != 3 {
+		t.Errorf("Expected 3 different cpu values, got %d", len(cpuValues))
+	}
+}
+
+func Test_FieldVectorWithCustomTemplate(t *testing.T) {
+	fields := []Field{
+		{Name: "embedding", Type: FieldTypeDenseVector},
+		{Name: "quantized", Type: FieldTypeDenseVector},
+		{Name: "binary", Type: FieldTypeDenseVector},
+		{Name: "tokens", Type: FieldTypeSparseVector},
+	}
+
+	template := []byte(`{"embedding":{{.embedding}},"quantized":{{.quantized}},"binary":{{.binary}},"tokens":{{.tokens}}}`)
+	configYaml := []byte(`fields:
+  - name: embedding
+    vector:
+      dims: 8
+      normalize: true
+      centroids: 2
+      spread: 0.01
+  - name: quantized
+    vector:
+      dims: 16
+      element_type: byte
+  - name: binary
+    vector:
+      dims: 32
+      element_type: bit
+  - name: tokens
+    vector:
+      vocabulary_size: 100
+      min_tokens: 5
+      max_tokens: 10
+`)
+	t.Logf("with template: %s", string(template))
+
+	cfg, err := config.LoadConfigFromYaml(configYaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nSpins := 100
+	g := makeGeneratorWithCustomTemplate(t, cfg, fields, template, uint64(nSpins))
+
+	type event struct {
+		Embedding []float64          `json:"embedding"`
+		Quantized []int8             `json:"quantized"`
+		Binary    []int8             `json:"binary"`
+		Tokens    map[string]float64 `json:"tokens"`
+	}
+
+	var clusters [][]float64
+	for i := 0; i < nSpins; i++ {
+		var buf bytes.Buffer
+		if err := g.Emit(&buf); err != nil {
+			t.Fatal(err)
+		}
+
+		var e event
+		if err := json.Unmarshal(buf.Bytes(), &e); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(e.Embedding) != 8 {
+			t.Fatalf("Expected embedding to have 8 dims, got %v", e.Embedding)
+		}
+
+		var norm float64
+		for _, component := range e.Embedding {
+			norm += component * component
+		}
+
+		if math.Abs(math.Sqrt(norm)-1) > 1e-4 {
+			t.Errorf("Expected embedding to be normalised, got norm %f", math.Sqrt(norm))
+		}
+
+		// vectors generated around the same centroid point in the same direction
+		inCluster := false
+		for _, cluster := range clusters {
+			var dot float64
+			for j := range cluster {
+				dot += cluster[j] * e.Embedding[j]
+			}
+
+			inCluster = inCluster || dot > 0.95
+		}
+
+		if !inCluster {
+			clusters = append(clusters, e.Embedding)
+		}
+
+		if len(e.Quantized) != 16 {
+			t.Errorf("Expected quantized to have 16 dims, got %v", e.Quantized)
+		}
+
+		if len(e.Binary) != 4 {
+			t.Errorf("Expected binary to have 4 bytes, got %v", e.Binary)
+		}
+
+		if len(e.Tokens) < 5 || len(e.Tokens) > 10 {
+			t.Errorf("Expected between 5 and 10 tokens, got %v", e.Tokens)
+		}
+
+		for token, weight := range e.Tokens {
+			if weight <= 0 {
+				t.Errorf("Expected token %s to have a positive weight, got %f", token, weight)
+			}
+		}
+	}
+
+	if len(clusters) > 2 {
+		t.Errorf("Expected embeddings around 2 centroids, got %d clusters", len(clusters))
+	}
+}
+
+func Test_FieldRangeWithCustomTemplate(t *testing.T) {
+	fields := []Field{
+		{Name: "ports", Type: FieldTypeIntegerRange},
+		{Name: "ratio", Type: FieldTypeDoubleRange},
+		{Name: "window", Type: FieldTypeDateRange},
+		{Name: "network", Type: FieldTypeIPRange},
+	}
+
+	template := []byte(`{"ports":{{.ports}},"ratio":{{.ratio}},"window":{{.window}},"network":{{.network}}}`)
+	configYaml := []byte(`fields:
+  - name: ports
+    range:
+      min: 1024
+      max: 2048
+  - name: ratio
+    range:
+      min: 0
+      max: 1
+  - name: window
+    period: 24h
+  - name: network
+    ip:
+      cidrs: ["10.0.0.0/8", "2001:db8::/32"]
+`)
+	t.Logf("with template: %s", string(template))
+
+	cfg, err := config.LoadConfigFromYaml(configYaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nSpins := 100
+	now := time.Now()
+	g := makeGeneratorWithCustomTemplate(t, cfg, fields, template, uint64(nSpins))
+
+	type event struct {
+		Ports struct {
+			Gte int64 `json:"gte"`
+			Lte int64 `json:"lte"`
+		} `json:"ports"`
+		Ratio struct {
+			Gte float64 `json:"gte"`
+			Lte float64 `json:"lte"`
+		} `json:"ratio"`
+		Window struct {
+			Gte time.Time `json:"gte"`
+			Lte time.Time `json:"lte"`
+		} `json:"window"`
+		Network struct {
+			Gte string `json:"gte"`
+			Lte string `json:"lte"`
+		} `json:"network"`
+	}
+
+	var previousWindow time.Time
+	for i := 0; i < nSpins; i++ {
+		var buf bytes.Buffer
+		if err := g.Emit(&buf); err != nil {
+			t.Fatal(err)
+		}
+
+		var e event
+		if err := json.Unmarshal(buf.Bytes(), &e); err != nil {
+			t.Fatal(err)
+		}
+
+		if e.Ports.Gte < 1024 || e.Ports.Lte > 2048 || e.Ports.Gte > e.Ports.Lte {
+			t.Errorf("Expected ordered ports range between 1024 and 2048, got %v", e.Ports)
+		}
+
+		if e.Ratio.Gte < 0 || e.Ratio.Lte > 1 || e.Ratio.Gte > e.Ratio.Lte {
+			t.Errorf("Expected ordered ratio range between 0 and 1, got %v", e.Ratio)
+		}
+
+		if e.Window.Gte.After(e.Window.Lte) || e.Window.Lte.After(now.Add(24*time.Hour+time.Second)) {
+			t.Errorf("Expected ordered window range within the period, got %v", e.Window)
+		}
+
+		if e.Window.Gte.Before(previousWindow) {
+			t.Errorf("Expected window ranges to be progressive, got %v after %v", e.Window.Gte, previousWindow)
+		}
+
+		previousWindow = e.Window.Gte
+
+		gte, lte := netip.MustParseAddr(e.Network.Gte), netip.MustParseAddr(e.Network.Lte)
+		if gte.Is4() != lte.Is4() || lte.Less(gte) {
+			t.Errorf("Expected ordered network range of the same ip family, got %v", e.Network)
+		}
+
+		if !netip.MustParsePrefix("10.0.0.0/8").Contains(gte) && !netip.MustParsePrefix("2001:db8::/32").Contains(gte) {
+			t.Errorf("Expected network range in the configured cidrs, got %v", e.Network)
+		}
+	}
+}
+
+func Test_FieldArrayWithCustomTemplate(t *testing.T) {
+	fields := []Field{
+		{Name: "tags", Type: FieldTypeKeyword},
+		{Name: "related.ip", Type: FieldTypeIP},
+		{Name: "event.created", Type: FieldTypeDate},
+		{Name: "event.category", Type: FieldTypeKeyword},
+	}
+
+	configYaml := []byte(`fields:
+  - name: tags
+    enum: ["alpha", "beta", "gamma"]
+    array:
+      min_length: 3
+      max_length: 3
+      unique: true
+  - name: related.ip
+    array:
+      min_length: 1
+      max_length: 4
+  - name: event.created
+    array:
+      min_length: 2
+  - name: event.category
+    enum: ["network"]
+    array:
+      min_length: 0
+      max_length: 2
+`)
+
+	cfg, err := config.LoadConfigFromYaml(configYaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the template is auto-generated, so that the values are not wrapped
+	g, err := NewGenerator(cfg, fields, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type event struct {
+		Tags          []string    `json:"tags"`
+		RelatedIP     []string    `json:"related.ip"`
+		EventCreated  []time.Time `json:"event.created"`
+		EventCategory []string    `json:"event.category"`
+	}
+
+	for i := 0; i < 100; i++ {
+		var buf bytes.Buffer
+		if err := g.Emit(&buf); err != nil {
+			t.Fatal(err)
+		}
+
+		var e event
+		if err := json.Unmarshal(buf.Bytes(), &e); err != nil {
+			t.Fatal(err)
+		}
+
+		// unique arrays can be shorter than their min length, when not enough different values are generated
+		if len(e.Tags) == 0 || len(e.Tags) > 3 {
+			t.Errorf("Expected between 1 and 3 tags, got %v", e.Tags)
+		}
+
+		tags := make(map[string]struct{})
+		for _, tag := range e.Tags {
+			if _, ok := tags[tag]; ok {
+				t.Errorf("Expected tags to be unique, got %v", e.Tags)
+			}
+
+			if tag != "alpha" && tag != "beta" && tag != "gamma" {
+				t.Errorf("Expected tags to be enum values, got %v", e.Tags)
+			}
+
+			tags[tag] = struct{}{}
+		}
+
+		if len(e.RelatedIP) < 1 || len(e.RelatedIP) > 4 {
+			t.Errorf("Expected between 1 and 4 related.ip, got %v", e.RelatedIP)
+		}
+
+		for _, ip := range e.RelatedIP {
+			if _, err := netip.ParseAddr(ip); err != nil {
+				t.Errorf("Expected related.ip to be ip addresses, got %v", e.RelatedIP)
+			}
+		}
+
+		if len(e.EventCreated) != 2 {
+			t.Errorf("Expected 2 event.created, got %v", e.EventCreated)
+		}
+
+		if len(e.EventCategory) > 2 || (len(e.EventCategory) > 0 && e.EventCategory[0] != "network") {
+			t.Errorf("Expected up to 2 event.category, got %v", e.EventCategory)
+		}
+	}
+}
+
+func Test_FieldJSONEscapeWithCustomTemplate(t *testing.T) {
+	fields := Fields{
+		{Name: "message", Type: FieldTypeKeyword},
+		{Name: "tags", Type: FieldTypeKeyword},
+	}
+
+	configYaml := []byte(`fields:
+  - name: message
+    enum: ["a \"quoted\" value", "a back\\slash", "a new\nline", "a \ttab", "a \x01control", "plain"]
+  - name: tags
+    enum: ["\"", "\\"]
+    array:
+      min_length: 2
+`)
+
+	cfg, err := config.LoadConfigFromYaml(configYaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		scenario string
+		template []byte
+	}{
+		{
+			scenario: "auto-generated template",
+		},
+		{
+			scenario: "custom template",
+			template: []byte(`{"message": "{{.message}}", "tags": {{.tags}}}`),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.scenario, func(t *testing.T) {
+			var g Generator
+			if testCase.template == nil {
+				g, err = NewGenerator(cfg, fields, 0)
+				if err != nil {
+					t.Fatal(err)
+				}
+			} else {
+				g = makeGeneratorWithCustomTemplate(t, cfg, fields, testCase.template, 0)
+			}
+
+			for i := 0; i < 100; i++ {
+				var buf bytes.Buffer
+				if err := g.Emit(&buf); err != nil {
+					t.Fatal(err)
+				}
+
+				var e struct {
+					Message string   `json:"message"`
+					Tags    []string `json:"tags"`
+				}
+				if err := json.Unmarshal(buf.Bytes(), &e); err != nil {
+					t.Fatalf("Expected valid JSON, got %s: %v", buf.String(), err)
+				}
+
+				fieldCfg, _ := cfg.GetField("message")
+				if !slices.Contains(fieldCfg.Enum, e.Message) {
+					t.Errorf("Expected message to be one of the enum values, got %q", e.Message)
+				}
+
+				for _, tag := range e.Tags {
+					if tag != `"` && tag != `\` {
+						t.Errorf("Expected tags to be one of the enum values, got %q", tag)
+					}
+				}
+			}
+		})
+	}
+}
+
+func Test_FieldJSONEscapeObjectKeysWithCustomTemplate(t *testing.T) {
+	fields := Fields{
+		{Name: "process", Type: FieldTypeObject, ObjectType: FieldTypeKeyword},
+	}
+
+	configYaml := []byte(`fields:
+  - name: process
+    object_keys: ["name", "title"]
+  - name: process.title
+    enum: ["a \"quoted\" title"]
+`)
+
+	cfg, err := config.LoadConfigFromYaml(configYaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := []byte(`{"process.name": "{{.process.name}}", "process.title": "{{.process.title}}"}`)
+	g := makeGeneratorWithCustomTemplate(t, cfg, fields, template, 0)
+
+	for i := 0; i < 10; i++ {
+		var buf bytes.Buffer
+		if err := g.Emit(&buf); err != nil {
+			t.Fatal(err)
+		}
+
+		var e map[string]string
+		if err := json.Unmarshal(buf.Bytes(), &e); err != nil {
+			t.Fatalf("Expected valid JSON, got %s: %v", buf.String(), err)
+		}
+
+		if len(e["process.name"]) == 0 || e["process.title"] != `a "quoted" title` {
+			t.Errorf("Expected the object keys of process to be escaped, got %s", buf.String())
+		}
+	}
+}
+
+func Test_FieldRawValuesWithCustomTemplate(t *testing.T) {
+	fields := Fields{
+		{Name: "message", Type: FieldTypeKeyword},
+	}
+
+	configYaml := []byte(`fields:
+  - name: message
+    enum: ["a \"quoted\" value"]
+`)
+
+	cfg, err := config.LoadConfigFromYaml(configYaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := []byte(`message={{.message}}`)
+	g := makeGeneratorWithCustomTemplate(t, cfg, fields, template, 0, WithRawValues())
+
+	var buf bytes.Buffer
+	if err := g.Emit(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if buf.String() != `message=a "quoted" value` {
+		t.Errorf("Expected raw value, got %s", buf.String())
+	}
+}
+
+func Test_AutoGeneratedTemplateIsValidJSONWithCustomTemplate(t *testing.T) {
+	fields := Fields{
+		{Name: "boolean", Type: FieldTypeBool},
+		{Name: "keyword", Type: FieldTypeKeyword},
+		{Name: "keyword_enum", Type: FieldTypeKeyword},
+		{Name: "text", Type: FieldTypeText},
+		{Name: "match_only_text", Type: FieldTypeMatchOnlyText},
+		{Name: "wildcard", Type: FieldTypeWildcard},
+		{Name: "version", Type: FieldTypeVersion},
+		{Name: "constant_keyword", Type: FieldTypeConstantKeyword},
+		{Name: "date", Type: FieldTypeDate},
+		{Name: "ip", Type: FieldTypeIP},
+		{Name: "double", Type: FieldTypeDouble},
+		{Name: "float", Type: FieldTypeFloat},
+		{Name: "half_float", Type: FieldTypeHalfFloat},
+		{Name: "scaled_float", Type: FieldTypeScaledFloat},
+		{Name: "byte", Type: FieldTypeByte},
+		{Name: "short", Type: FieldTypeShort},
+		{Name: "integer", Type: FieldTypeInteger},
+		{Name: "long", Type: FieldTypeLong},
+		{Name: "unsigned_long", Type: FieldTypeUnsignedLong},
+		{Name: "object", Type: FieldTypeObject, ObjectType: FieldTypeKeyword},
+		{Name: "geo_point", Type: FieldTypeGeoPoint},
+		{Name: "histogram", Type: FieldTypeHistogram},
+		{Name: "aggregate_metric_double", Type: FieldTypeAggregateMetricDouble},
+		{Name: "dense_vector", Type: FieldTypeDenseVector},
+		{Name: "sparse_vector", Type: FieldTypeSparseVector},
+		{Name: "integer_range", Type: FieldTypeIntegerRange},
+		{Name: "date_range", Type: FieldTypeDateRange},
+		{Name: "ip_range", Type: FieldTypeIPRange},
+		{Name: "array", Type: FieldTypeKeyword},
+		{Name: "static", Type: FieldTypeKeyword},
+		{Name: "missing", Type: FieldTypeKeyword},
+	}
+
+	configYaml := []byte(`fields:
+  - name: keyword_enum
+    enum: ["\"", "\\", "\n", "\u00e8"]
+  - name: text
+    text:
+      style: log
+  - name: dense_vector
+    vector:
+      dims: 8
+  - name: array
+    enum: ["\"", "\\"]
+    array:
+      min_length: 2
+  - name: static
+    value: "a \"static\" value"
+  - name: missing
+    missing_probability: 0.5
+`)
+
+	cfg, err := config.LoadConfigFromYaml(configYaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g, err := NewGenerator(cfg, fields, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 1000; i++ {
+		var buf bytes.Buffer
+		if err := g.Emit(&buf); err != nil {
+			t.Fatal(err)
+		}
+
+		if !json.Valid(buf.Bytes()) {
+			t.Fatalf("Expected valid JSON, got %s", buf.String())
+		}
+	}
+}
+
+func Test_NestedObjectsWithCustomTemplate(t *testing.T) {
+	fields := Fields{
+		{Name: "host.name", Type: FieldTypeKeyword},
+		{Name: "host.ip", Type: FieldTypeIP},
+		{Name: "event.created", Type: FieldTypeDate},
+		{Name: "event.duration", Type: FieldTypeLong},
+		{Name: "labels.*", Type: FieldTypeKeyword},
+		{Name: "process", Type: FieldTypeObject, ObjectType: FieldTypeKeyword},
+		{Name: "http.request", Type: FieldTypeKeyword},
+		{Name: "http.request.method", Type: FieldTypeKeyword},
+		{Name: "message", Type: FieldTypeKeyword},
+	}
+
+	configYaml := []byte(`fields:
+  - name: event.duration
+    missing_probability: 0.5
+  - name: process
+    object_keys: ["name", "title"]
+  - name: http.request.method
+    enum: ["GET"]
+`)
+
+	cfg, err := config.LoadConfigFromYaml(configYaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g, err := NewGenerator(cfg, fields, 0, WithNestedObjects())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 100; i++ {
+		var buf bytes.Buffer
+		if err := g.Emit(&buf); err != nil {
+			t.Fatal(err)
+		}
+
+		var e map[string]any
+		if err := json.Unmarshal(buf.Bytes(), &e); err != nil {
+			t.Fatalf("Expected valid JSON, got %s: %v", buf.String(), err)
+		}
+
+		host, ok := e["host"].(map[string]any)
+		if !ok {
+			t.Fatalf("Expected host to be an object, got %s", buf.String())
+		}
+
+		if _, ok := host["name"].(string); !ok {
+			t.Errorf("Expected host.name to be nested, got %s", buf.String())
+		}
+
+		if _, err := netip.ParseAddr(host["ip"].(string)); err != nil {
+			t.Errorf("Expected host.ip to be nested, got %s", buf.String())
+		}
+
+		event, ok := e["event"].(map[string]any)
+		if !ok {
+			t.Fatalf("Expected event to be an object, got %s", buf.String())
+		}
+
+		if _, err := time.Parse(time.RFC3339Nano, event["created"].(string)); err != nil {
+			t.Errorf("Expected event.created to be nested, got %s", buf.String())
+		}
+
+		if duration, ok := event["duration"]; ok {
+			if _, ok := duration.(float64); !ok {
+				t.Errorf("Expected event.duration to be nested, got %s", buf.String())
+			}
+		}
+
+		if labels, ok := e["labels"]; ok {
+			if _, ok := labels.(map[string]any); !ok {
+				t.Errorf("Expected labels to be an object, got %s", buf.String())
+			}
+		}
+
+		process, ok := e["process"].(map[string]any)
+		if !ok || len(process) != 2 || process["name"] == nil || process["title"] == nil {
+			t.Errorf("Expected process to be an object with the object keys, got %s", buf.String())
+		}
+
+		// http.request is a field, so the method cannot be nested in it
+		http, ok := e["http"].(map[string]any)
+		if !ok || http["request.method"] != "GET" {
+			t.Errorf("Expected http.request.method to be a dotted key in http, got %s", buf.String())
+		}
+
+		if _, ok := e["message"].(string); !ok {
+			t.Errorf("Expected message at the root, got %s", buf.String())
+		}
+	}
+}
+
+func Test_FieldDateFormatWithCustomTemplate(t *testing.T) {
+	fields := Fields{
+		{Name: "epoch_millis", Type: FieldTypeDate},
+		{Name: "epoch_second", Type: FieldTypeDate},
+		{Name: "rfc3164", Type: FieldTypeDate},
+		{Name: "common_log", Type: FieldTypeDate},
+		{Name: "custom", Type: FieldTypeDate},
+		{Name: "nanos", Type: FieldTypeDateNanos},
+		{Name: "nanos_array", Type: FieldTypeDateNanos},
+	}
+
+	configYaml := []byte(`fields:
+  - name: epoch_millis
+    format: epoch_millis
+  - name: epoch_second
+    format: epoch_second
+  - name: rfc3164
+    format: rfc3164
+  - name: common_log
+    format: common_log
+  - name: custom
+    format: "2006/01/02 15:04"
+  - name: nanos_array
+    format: epoch_second
+    array:
+      min_length: 2
+`)
+
+	cfg, err := config.LoadConfigFromYaml(configYaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	startTime := time.Date(2024, 3, 5, 7, 8, 9, 123456789, time.UTC)
+	g, err := NewGenerator(cfg, fields, 0, WithStartTime(startTime))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := g.Emit(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	var e struct {
+		EpochMillis int64   `json:"epoch_millis"`
+		EpochSecond int64   `json:"epoch_second"`
+		RFC3164     string  `json:"rfc3164"`
+		CommonLog   string  `json:"common_log"`
+		Custom      string  `json:"custom"`
+		Nanos       string  `json:"nanos"`
+		NanosArray  []int64 `json:"nanos_array"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &e); err != nil {
+		t.Fatalf("Expected valid JSON, got %s: %v", buf.String(), err)
+	}
+
+	// without totEvents and period the dates are at most a second after the start time
+	if e.EpochMillis < startTime.UnixMilli() || e.EpochMillis > startTime.Add(time.Second).UnixMilli() {
+		t.Errorf("Expected epoch_millis near %d, got %d", startTime.UnixMilli(), e.EpochMillis)
+	}
+
+	if e.EpochSecond < startTime.Unix() || e.EpochSecond > startTime.Add(5*time.Second).Unix() {
+		t.Errorf("Expected epoch_second near %d, got %d", startTime.Unix(), e.EpochSecond)
+	}
+
+	if !strings.HasPrefix(e.RFC3164, "Mar  5 07:") {
+		t.Errorf("Expected rfc3164 date, got %s", e.RFC3164)
+	}
+
+	if !strings.HasPrefix(e.CommonLog, "05/Mar/2024:07:") || !strings.HasSuffix(e.CommonLog, " +0000") {
+		t.Errorf("Expected common log date, got %s", e.CommonLog)
+	}
+
+	if !strings.HasPrefix(e.Custom, "2024/03/05 07:") || len(e.Custom) != len("2024/03/05 07:08") {
+		t.Errorf("Expected custom layout date, got %s", e.Custom)
+	}
+
+	if !regexp.MustCompile(`^2024-03-05T07:\d{2}:\d{2}\.\d{9}Z$`).MatchString(e.Nanos) {
+		t.Errorf("Expected date with nanoseconds, got %s", e.Nanos)
+	}
+
+	if len(e.NanosArray) != 2 || e.NanosArray[0] < startTime.Unix() {
+		t.Errorf("Expected array of epoch seconds, got %v", e.NanosArray)
+	}
+}
+
+func Test_FieldDateFormatRawValuesWithCustomTemplate(t *testing.T) {
+	fields := Fields{
+		{Name: "timestamp", Type: FieldTypeDate},
+		{Name: "message", Type: FieldTypeKeyword},
+	}
+
+	configYaml := []byte(`fields:
+  - name: timestamp
+    format: rfc3164
+  - name: message
+    enum: ["started"]
+`)
+
+	cfg, err := config.LoadConfigFromYaml(configYaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	startTime := time.Date(2024, 3, 5, 7, 8, 9, 0, time.UTC)
+	template := []byte(`<13>{{.timestamp}} host app: {{.message}}`)
+	g := makeGeneratorWithCustomTemplate(t, cfg, fields, template, 0, WithStartTime(startTime), WithRawValues())
+
+	var buf bytes.Buffer
+	if err := g.Emit(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if !regexp.MustCompile(`^<13>Mar  5 07:08:(09|10) host app: started$`).MatchString(buf.String()) {
+		t.Errorf("Expected syslog line, got %s", buf.String())
+	}
+}
+
+func Test_FieldDateFormatInvalidWithCustomTemplate(t *testing.T) {
+	fields := Fields{
+		{Name: "timestamp", Type: FieldTypeDate},
+	}
+
+	configYaml := []byte(`fields:
+  - name: timestamp
+    format: not a layout
+`)
+
+	cfg, err := config.LoadConfigFromYaml(configYaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewGenerator(cfg, fields, 0); err == nil {
+		t.Fatal("Expected error for invalid format")
+	}
+}
+
+func Test_FieldDateArrivalWithCustomTemplate(t *testing.T) {
+	// a Monday
+	startTime := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		scenario  string
+		config    string
+		totEvents uint64
+		check     func(t *testing.T, timestamps []time.Time)
+	}{
+		{
+			scenario: "uniform",
+			config: `
+    period: 1000s
+    arrival:
+      process: uniform`,
+			totEvents: 1000,
+			check: func(t *testing.T, timestamps []time.Time) {
+				for i, timestamp := range timestamps {
+					// the timestamps are formatted to the microsecond
+					expected := startTime.Add(time.Duration(i) * time.Second)
+					if timestamp.Sub(expected).Abs() > time.Microsecond {
+						t.Fatalf("Expected event %d at %s, got %s", i, expected, timestamp)
+					}
+				}
+			},
+		},
+		{
+			scenario: "poisson",
+			config: `
+    period: 24h
+    arrival:
+      process: poisson`,
+			totEvents: 1000,
+			check: func(t *testing.T, timestamps []time.Time) {
+				// the intervals of a poisson process have the same standard deviation as their mean
+				var sum, sumSquares float64
+				for i := 1; i < len(timestamps); i++ {
+					interval := timestamps[i].Sub(timestamps[i-1]).Seconds()
+					sum += interval
+					sumSquares += interval * interval
+				}
+
+				n := float64(len(timestamps) - 1)
+				mean := sum / n
+				stdDev := math.Sqrt(sumSquares/n - mean*mean)
+				if stdDev/mean < 0.8 || stdDev/mean > 1.2 {
+					t.Errorf("Expected intervals with coefficient of variation near 1, got %f", stdDev/mean)
+				}
+			},
+		},
+		{
+			scenario: "business hours",
+			config: `
+    period: 168h
+    arrival:
+      profile: business_hours`,
+			totEvents: 5000,
+			check: func(t *testing.T, timestamps []time.Time) {
+				var weekend, businessHours int
+				for _, timestamp := range timestamps {
+					if timestamp.Weekday() == time.Saturday || timestamp.Weekday() == time.Sunday {
+						weekend++
+					}
+
+					if timestamp.Hour() >= 9 && timestamp.Hour() < 18 {
+						businessHours++
+					}
+				}
+
+				// 0.4 of the 5.4 days load is on the weekend
+				if weekend > 600 {
+					t.Errorf("Expected quiet weekends, got %d events out of 5000", weekend)
+				}
+
+				if businessHours < 3000 {
+					t.Errorf("Expected most events in business hours, got %d events out of 5000", businessHours)
+				}
+			},
+		},
+		{
+			scenario: "bursts",
+			config: `
+    period: 24h
+    arrival:
+      bursts:
+        probability: 0.05
+        factor: 100`,
+			totEvents: 2000,
+			check: func(t *testing.T, timestamps []time.Time) {
+				meanInterval := 24 * time.Hour / 2000
+
+				// about 5% of the intervals of a poisson process are shorter than a twentieth of the mean
+				var short int
+				for i := 1; i < len(timestamps); i++ {
+					if timestamps[i].Sub(timestamps[i-1]) < meanInterval/20 {
+						short++
+					}
+				}
+
+				if short < 600 {
+					t.Errorf("Expected bursts of events, got %d short intervals out of 2000", short)
+				}
+			},
+		},
+		{
+			scenario: "unbounded",
+			config: `
+    arrival:
+      process: poisson`,
+			totEvents: 0,
+			check: func(t *testing.T, timestamps []time.Time) {
+				meanInterval := timestamps[len(timestamps)-1].Sub(timestamps[0]) / time.Duration(len(timestamps)-1)
+				if meanInterval < 400*time.Millisecond || meanInterval > 600*time.Millisecond {
+					t.Errorf("Expected a mean interval of half a second, got %s", meanInterval)
+				}
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.scenario, func(t *testing.T) {
+			fields := Fields{
+				{Name: "timestamp", Type: FieldTypeDate},
+			}
+
+			cfg, err := config.LoadConfigFromYaml([]byte("fields:\n  - name: timestamp" + testCase.config))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			template := []byte(`{"timestamp": "{{.timestamp}}"}`)
+			g := makeGeneratorWithCustomTemplate(t, cfg, fields, template, testCase.totEvents, WithStartTime(startTime))
+
+			nEvents := int(testCase.totEvents)
+			if nEvents == 0 {
+				nEvents = 1000
+			}
+
+			fieldCfg, _ := cfg.GetField("timestamp")
+			end := startTime.Add(fieldCfg.Period)
+
+			timestamps := make([]time.Time, 0, nEvents)
+			for i := 0; i < nEvents; i++ {
+				var buf bytes.Buffer
+				if err := g.Emit(&buf); err != nil {
+					t.Fatal(err)
+				}
+
+				var e struct {
+					Timestamp time.Time `json:"timestamp"`
+				}
+				if err := json.Unmarshal(buf.Bytes(), &e); err != nil {
+					t.Fatal(err)
+				}
+
+				if e.Timestamp.Before(startTime) || (testCase.totEvents > 0 && e.Timestamp.After(end)) {
+					t.Fatalf("Expected timestamp within the period, got %s", e.Timestamp)
+				}
+
+				if len(timestamps) > 0 && e.Timestamp.Before(timestamps[len(timestamps)-1]) {
+					t.Fatalf("Expected ordered timestamps, got %s after %s", e.Timestamp, timestamps[len(timestamps)-1])
+				}
+
+				timestamps = append(timestamps, e.Timestamp)
+			}
+
+			testCase.check(t, timestamps)
+		})
+	}
+}
+
+func Test_FieldDateOutOfOrderWithCustomTemplate(t *testing.T) {
+	fields := Fields{
+		{Name: "@timestamp", Type: FieldTypeDate},
+		{Name: "event.ingested", Type: FieldTypeDate},
+	}
+
+	configYaml := []byte(`fields:
+  - name: "@timestamp"
+    period: 1000s
+    out_of_order:
+      probability: 0.2
+      max_lateness: 10m
+      max_earliness: 2m
+  - name: event.ingested
+    period: 1000s
+`)
+
+	cfg, err := config.LoadConfigFromYaml(configYaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := []byte(`{"@timestamp": "{{.@timestamp}}", "event.ingested": "{{.event.ingested}}"}`)
+	g := makeGeneratorWithCustomTemplate(t, cfg, fields, template, 1000)
+
+	var moved, late, early, outOfOrder int
+	var previous time.Time
+	for i := 0; i < 1000; i++ {
+		var buf bytes.Buffer
+		if err := g.Emit(&buf); err != nil {
+			t.Fatal(err)
+		}
+
+		var e struct {
+			Timestamp time.Time `json:"@timestamp"`
+			Ingested  time.Time `json:"event.ingested"`
+		}
+		if err := json.Unmarshal(buf.Bytes(), &e); err != nil {
+			t.Fatal(err)
+		}
+
+		lag := e.Ingested.Sub(e.Timestamp)
+		if lag > 10*time.Minute || lag < -2*time.Minute {
+			t.Fatalf("Expected lag between -2m and 10m, got %s", lag)
+		}
+
+		switch {
+		case lag > 0:
+			moved++
+			late++
+		case lag < 0:
+			moved++
+			early++
+		}
+
+		if e.Timestamp.Before(previous) {
+			outOfOrder++
+		}
+
+		previous = e.Timestamp
+	}
+
+	if moved < 100 || moved > 300 {
+		t.Errorf("Expected about 200 events out of order, got %d", moved)
+	}
+
+	if early == 0 || late <= early {
+		t.Errorf("Expected mostly late events, got %d late and %d early", late, early)
+	}
+
+	if outOfOrder == 0 {
+		t.Errorf("Expected timestamps out of order")
+	}
+}
+
+func Test_FieldDateRelativeToWithCustomTemplate(t *testing.T) {
+	fields := Fields{
+		{Name: "@timestamp", Type: FieldTypeDate},
+		{Name: "event.ingested", Type: FieldTypeDate},
+		{Name: "event.start", Type: FieldTypeDate},
+		{Name: "event.end", Type: FieldTypeDate},
+	}
+
+	configYaml := []byte(`fields:
+  - name: "@timestamp"
+    out_of_order:
+      probability: 0.5
+  - name: event.ingested
+    relative_to:
+      field: "@timestamp"
+      min: 1s
+      max: 10s
+  - name: event.start
+    relative_to:
+      field: "@timestamp"
+  - name: event.end
+    relative_to:
+      field: event.start
+      unit: 1ms
+      distribution:
+        type: lognormal
+        mu: 5
+        sigma: 1
+`)
+
+	cfg, err := config.LoadConfigFromYaml(configYaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// event.end is before event.start in the template
+	template := []byte(`{"event.end": "{{.event.end}}", "@timestamp": "{{.@timestamp}}", "event.start": "{{.event.start}}", "event.ingested": "{{.event.ingested}}"}`)
+	g := makeGeneratorWithCustomTemplate(t, cfg, fields, template, 0)
+
+	for i := 0; i < 1000; i++ {
+		var buf bytes.Buffer
+		if err := g.Emit(&buf); err != nil {
+			t.Fatal(err)
+		}
+
+		var e struct {
+			Timestamp time.Time `json:"@timestamp"`
+			Ingested  time.Time `json:"event.ingested"`
+			Start     time.Time `json:"event.start"`
+			End       time.Time `json:"event.end"`
+		}
+		if err := json.Unmarshal(buf.Bytes(), &e); err != nil {
+			t.Fatal(err)
+		}
+
+		if lag := e.Ingested.Sub(e.Timestamp); lag < time.Second || lag > 10*time.Second {
+			t.Fatalf("Expected ingestion lag between 1s and 10s, got %s", lag)
+		}
+
+		if !e.Start.Equal(e.Timestamp) {
+			t.Fatalf("Expected event.start equal to @timestamp, got %s and %s", e.Start, e.Timestamp)
+		}
+
+		if e.End.Before(e.Start) {
+			t.Fatalf("Expected event.end after event.start, got %s and %s", e.End, e.Start)
+		}
+	}
+}
+
+func Test_FieldDateRelativeToInvalidWithCustomTemplate(t *testing.T) {
+	testCases := []struct {
+		scenario string
+		fields   Fields
+		config   string
+	}{
+		{
+			scenario: "field not in fields definition",
+			fields:   Fields{{Name: "event.end", Type: FieldTypeDate}},
+			config: `fields:
+  - name: event.end
+    relative_to:
+      field: event.start`,
+		},
+		{
+			scenario: "cycle",
+			fields:   Fields{{Name: "event.start", Type: FieldTypeDate}, {Name: "event.end", Type: FieldTypeDate}},
+			config: `fields:
+  - name: event.start
+    relative_to:
+      field: event.end
+  - name: event.end
+    relative_to:
+      field: event.start`,
+		},
+		{
+			scenario: "period",
+			fields:   Fields{{Name: "event.start", Type: FieldTypeDate}, {Name: "event.end", Type: FieldTypeDate}},
+			config: `fields:
+  - name: event.end
+    period: 1h
+    relative_to:
+      field: event.start`,
+		},
+		{
+			scenario: "not a date field",
+			fields:   Fields{{Name: "event.start", Type: FieldTypeKeyword}, {Name: "event.end", Type: FieldTypeDate}},
+			config: `fields:
+  - name: event.end
+    relative_to:
+      field: event.start`,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.scenario, func(t *testing.T) {
+			cfg, err := config.LoadConfigFromYaml([]byte(testCase.config))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			template := []byte(`{"event.end": "{{.event.end}}"}`)
+			if _, err := NewGenerator(cfg, testCase.fields, 0, WithCustomTemplate(template)); err == nil {
+				t.Fatal("Expected error")
+			}
+		})
+	}
+}
+
+func Test_FieldDateCounterWithCustomTemplate(t *testing.T) {
+	fields := Fields{
+		{Name: "@timestamp", Type: FieldTypeDate},
+		{Name: "host.name", Type: FieldTypeKeyword},
+	}
+
+	configYaml := []byte(`entities:
+  - name: hosts
+    size: 3
+fields:
+  - name: "@timestamp"
+    counter: true
+    period: 10s
+    fuzziness: 0.02
+    entity: hosts
+  - name: host.name
+    entity: hosts
+`)
+
+	cfg, err := config.LoadConfigFromYaml(configYaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	startTime := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	template := []byte(`{"@timestamp": "{{.@timestamp}}", "host.name": "{{.host.name}}"}`)
+	g := makeGeneratorWithCustomTemplate(t, cfg, fields, template, 0, WithStartTime(startTime))
+
+	samples := make(map[string]int)
+	for i := 0; i < 300; i++ {
+		var buf bytes.Buffer
+		if err := g.Emit(&buf); err != nil {
+			t.Fatal(err)
+		}
+
+		var e struct {
+			Timestamp time.Time `json:"@timestamp"`
+			HostName  string    `json:"host.name"`
+		}
+		if err := json.Unmarshal(buf.Bytes(), &e); err != nil {
+			t.Fatal(err)
+		}
+
+		// each host has its own series of samples every 10s ± 200ms
+		expected := startTime.Add(time.Duration(samples[e.HostName]) * 10 * time.Second)
+		if delta := e.Timestamp.Sub(expected); delta.Abs() > 200*time.Millisecond {
+			t.Fatalf("Expected sample %d of host %s at %s ± 200ms, got %s", samples[e.HostName], e.HostName, expected, e.Timestamp)
+		}
+
+		samples[e.HostName]++
+	}
+
+	if len(samples) != 3 {
+		t.Errorf("Expected samples of 3 hosts, got %d", len(samples))
+	}
+}
+
+func Test_FieldDateCounterInvalidWithCustomTemplate(t *testing.T) {
+	fields := Fields{
+		{Name: "@timestamp", Type: FieldTypeDate},
+		{Name: "metric", Type: FieldTypeLong},
+	}
+
+	for _, configYaml := range []string{
+		"fields:\n  - name: \"@timestamp\"\n    counter: true",
+		"fields:\n  - name: \"@timestamp\"\n    counter: true\n    period: 10s\n    fuzziness: 2",
+		"fields:\n  - name: \"@timestamp\"\n    counter: true\n    period: 10s\n    arrival:\n      process: poisson",
+		"entities:\n  - name: hosts\n    size: 3\nfields:\n  - name: metric\n    counter: true\n    period: 10s\n    entity: hosts",
+	} {
+		cfg, err := config.LoadConfigFromYaml([]byte(configYaml))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		template := []byte(`{"@timestamp": "{{.@timestamp}}"}`)
+		if _, err := NewGenerator(cfg, fields, 0, WithCustomTemplate(template)); err == nil {
+			t.Fatalf("Expected error for config %s", configYaml)
 		}
 	}
 }
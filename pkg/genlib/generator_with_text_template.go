@@ -61,6 +61,10 @@ func newGeneratorWithTextTemplate(cfg Config, fields Fields, totEvents uint64, o
 		state.prevCacheCardinality[field.Name] = make([]any, 0)
 	}
 
+	if err := bindDependencies(cfg, fields, fieldMap, state); err != nil {
+		return nil, err
+	}
+
 	errChan := make(chan error)
 
 	templateFns := sprig.TxtFuncMap()
@@ -2,9 +2,13 @@ package genlib
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
+	"math"
 	"math/rand"
 	"net"
+	"net/netip"
+	"regexp"
 	"strconv"
 	"strings"
 	"testing"
@@ -16,7 +20,7 @@ import (
 func Test_EmptyCaseWithTextTemplate(t *testing.T) {
 	startTime := time.Now().Truncate(time.Microsecond)
 	state := newGenState(rand.Int63(), startTime)
-	template, _ := generateTextTemplateFromField(Config{}, []Field{}, state)
+	template, _ := generateTextTemplateFromField(Config{}, []Field{}, false, state)
 	t.Logf("with template: %s", string(template))
 	g := makeGeneratorWithTextTemplate(t, Config{}, []Field{}, template, 0, WithStartTime(startTime))
 
@@ -173,185 +177,1721 @@ func test_CardinalityTWithTextTemplate[T any](t *testing.T, ty string) {
 	})
 }
 
-func Test_FieldBoolWithTextTemplate(t *testing.T) {
+func Test_FieldWeightedEnumWithTextTemplate(t *testing.T) {
 	fld := Field{
 		Name: "alpha",
-		Type: FieldTypeBool,
+		Type: FieldTypeKeyword,
 	}
 
-	template := []byte(`{"alpha":{{generate "alpha"}}}`)
+	template := []byte(`{"alpha":"{{generate "alpha"}}"}`)
+	configYaml := []byte("fields:\n  - name: alpha\n    weighted_enum:\n      - value: rare\n        weight: 1\n      - value: common\n        weight: 9")
 	t.Logf("with template: %s", string(template))
-	// Enough spins, so we can make sure we get at least one true and at least one false
-	var cntTrue int
-	nSpins := 1024
+
+	cfg, err := config.LoadConfigFromYaml(configYaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nSpins := 10000
+	g := makeGeneratorWithTextTemplate(t, cfg, []Field{fld}, template, uint64(nSpins))
+
+	vmap := make(map[string]int)
 	for i := 0; i < nSpins; i++ {
-		b := testSingleTWithTextTemplate[bool](t, fld, nil, template)
+		var buf bytes.Buffer
+		if err := g.Emit(&buf); err != nil {
+			t.Fatal(err)
+		}
 
-		if b {
-			cntTrue += 1
+		m := unmarshalJSONT[string](t, buf.Bytes())
+		vmap[m[fld.Name]] += 1
+	}
+
+	if len(vmap) != 2 {
+		t.Fatalf("Expected 2 different values, got %d", len(vmap))
+	}
+
+	// 10% expected, leaving some room for randomness
+	if vmap["rare"] < nSpins/20 || vmap["rare"] > nSpins/5 {
+		t.Errorf("Expected rare value around 10%% of the events, got %d over %d", vmap["rare"], nSpins)
+	}
+}
+
+func Test_FieldCardinalityPerParentWithTextTemplate(t *testing.T) {
+	fldNamespace := Field{
+		Name: "namespace",
+		Type: FieldTypeKeyword,
+	}
+
+	fldPod := Field{
+		Name: "pod",
+		Type: FieldTypeKeyword,
+	}
+
+	// the child field is emitted before its parent
+	template := []byte(`{"pod":"{{generate "pod"}}","namespace":"{{generate "namespace"}}"}`)
+	configYaml := []byte("fields:\n  - name: namespace\n    cardinality: 3\n  - name: pod\n    cardinality: 5\n    cardinality_per: namespace")
+	t.Logf("with template: %s", string(template))
+
+	cfg, err := config.LoadConfigFromYaml(configYaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nSpins := 1000
+	g := makeGeneratorWithTextTemplate(t, cfg, []Field{fldNamespace, fldPod}, template, uint64(nSpins))
+
+	podsPerNamespace := make(map[string]map[string]struct{})
+	namespacesPerPod := make(map[string]map[string]struct{})
+	for i := 0; i < nSpins; i++ {
+		var buf bytes.Buffer
+		if err := g.Emit(&buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[string](t, buf.Bytes())
+		if _, ok := podsPerNamespace[m[fldNamespace.Name]]; !ok {
+			podsPerNamespace[m[fldNamespace.Name]] = make(map[string]struct{})
+		}
+
+		if _, ok := namespacesPerPod[m[fldPod.Name]]; !ok {
+			namespacesPerPod[m[fldPod.Name]] = make(map[string]struct{})
 		}
+
+		podsPerNamespace[m[fldNamespace.Name]][m[fldPod.Name]] = struct{}{}
+		namespacesPerPod[m[fldPod.Name]][m[fldNamespace.Name]] = struct{}{}
 	}
 
-	if cntTrue == 0 {
-		t.Errorf("No true values, really?")
+	if len(podsPerNamespace) != 3 {
+		t.Errorf("Expected 3 namespaces, got %d", len(podsPerNamespace))
 	}
 
-	if cntTrue == nSpins {
-		t.Errorf("No false values, really?")
+	for namespace, pods := range podsPerNamespace {
+		if len(pods) != 5 {
+			t.Errorf("Expected 5 pods for namespace %s, got %d", namespace, len(pods))
+		}
+	}
+
+	for pod, namespaces := range namespacesPerPod {
+		if len(namespaces) != 1 {
+			t.Errorf("Expected pod %s to belong to a single namespace, got %d", pod, len(namespaces))
+		}
 	}
 }
 
-func Test_FieldConstKeywordWithTextTemplate(t *testing.T) {
+func Test_FieldDeriveWithTextTemplate(t *testing.T) {
+	fields := []Field{
+		{Name: "packets", Type: FieldTypeLong},
+		{Name: "bytes", Type: FieldTypeLong},
+		{Name: "idx", Type: FieldTypeLong},
+		{Name: "types", Type: FieldTypeKeyword},
+		{Name: "type", Type: FieldTypeKeyword},
+		{Name: "label", Type: FieldTypeKeyword},
+	}
 
-	fld := Field{
-		Name:  "alpha",
-		Type:  FieldTypeConstantKeyword,
-		Value: "constant_keyword",
+	// derived fields are generated before the fields they depend on
+	template := []byte(`{"bytes":{{generate "bytes"}},"type":"{{generate "type"}}","label":"{{generate "label"}}","packets":{{generate "packets"}},"idx":{{generate "idx"}}}`)
+	configYaml := []byte(`fields:
+  - name: packets
+    range:
+      min: 1
+      max: 100
+  - name: bytes
+    derive: "$packets * 15"
+  - name: idx
+    range:
+      min: 0
+      max: 2
+  - name: types
+    value: ["t2.micro", "t2.small", "t2.medium"]
+  - name: type
+    derive: "$types[$idx]"
+  - name: label
+    derive: "$type + '-' + $idx"
+`)
+	t.Logf("with template: %s", string(template))
+
+	cfg, err := config.LoadConfigFromYaml(configYaml)
+	if err != nil {
+		t.Fatal(err)
 	}
 
-	template := []byte(`{"alpha":"{{generate "alpha"}}"}`)
+	g := makeGeneratorWithTextTemplate(t, cfg, fields, template, 0)
+
+	types := []string{"t2.micro", "t2.small", "t2.medium"}
+	for i := 0; i < 100; i++ {
+		var buf bytes.Buffer
+		if err := g.Emit(&buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[any](t, buf.Bytes())
+		packets := m["packets"].(float64)
+		idx := int(m["idx"].(float64))
+
+		if m["bytes"] != packets*15 {
+			t.Errorf("Expected bytes to be %f, got %v", packets*15, m["bytes"])
+		}
+
+		if m["type"] != types[idx] {
+			t.Errorf("Expected type to be %s, got %v", types[idx], m["type"])
+		}
+
+		if expected := fmt.Sprintf("%s-%d", types[idx], idx); m["label"] != expected {
+			t.Errorf("Expected label to be %s, got %v", expected, m["label"])
+		}
+	}
+}
+
+func Test_FieldDeriveCycleWithTextTemplate(t *testing.T) {
+	fields := []Field{
+		{Name: "alpha", Type: FieldTypeLong},
+		{Name: "beta", Type: FieldTypeLong},
+	}
+
+	template := []byte(`{"alpha":{{generate "alpha"}},"beta":{{generate "beta"}}}`)
+	configYaml := []byte("fields:\n  - name: alpha\n    derive: \"$beta + 1\"\n  - name: beta\n    derive: \"$alpha * 2\"")
+
+	cfg, err := config.LoadConfigFromYaml(configYaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewGenerator(cfg, fields, 0, WithTextTemplate(template)); err == nil {
+		t.Fatal("Expected error for a dependency cycle between fields")
+	}
+}
+
+func Test_FieldDeriveMissingReferenceWithTextTemplate(t *testing.T) {
+	fields := []Field{
+		{Name: "alpha", Type: FieldTypeLong},
+		{Name: "beta", Type: FieldTypeLong},
+	}
+
+	template := []byte(`{"alpha":{{generate "alpha"}},"beta":{{generate "beta"}}}`)
+	configYaml := []byte("fields:\n  - name: alpha\n    derive: \"$beta + 1\"\n  - name: beta\n    missing_probability: 0.5")
+
+	cfg, err := config.LoadConfigFromYaml(configYaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewGenerator(cfg, fields, 0, WithTextTemplate(template)); err == nil {
+		t.Fatal("Expected error for a derive referencing a field with missing probability")
+	}
+}
+
+func Test_FieldEntityWithTextTemplate(t *testing.T) {
+	fields := []Field{
+		{Name: "host.name", Type: FieldTypeKeyword},
+		{Name: "host.ip", Type: FieldTypeIP},
+		{Name: "host.os", Type: FieldTypeKeyword},
+		{Name: "user.name", Type: FieldTypeKeyword},
+	}
+
+	template := []byte(`{"host.name":"{{generate "host.name"}}","host.ip":"{{generate "host.ip"}}","host.os":"{{generate "host.os"}}","user.name":"{{generate "user.name"}}"}`)
+	configYaml := []byte(`entities:
+  - name: hosts
+    size: 10
+  - name: users
+    size: 3
+fields:
+  - name: host.name
+    entity: hosts
+  - name: host.ip
+    entity: hosts
+  - name: host.os
+    entity: hosts
+    enum: ["linux", "windows"]
+  - name: user.name
+    entity: users
+`)
 	t.Logf("with template: %s", string(template))
-	b := testSingleTWithTextTemplate[string](t, fld, nil, template)
-	if b != fld.Value {
-		t.Errorf("static value not match")
+
+	cfg, err := config.LoadConfigFromYaml(configYaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nSpins := 1000
+	g := makeGeneratorWithTextTemplate(t, cfg, fields, template, uint64(nSpins))
+
+	hosts := make(map[string][2]string)
+	ips := make(map[string]string)
+	users := make(map[string]struct{})
+	for i := 0; i < nSpins; i++ {
+		var buf bytes.Buffer
+		if err := g.Emit(&buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[string](t, buf.Bytes())
+		host := [2]string{m["host.ip"], m["host.os"]}
+		if previous, ok := hosts[m["host.name"]]; ok && previous != host {
+			t.Errorf("Expected host %s to always have ip and os %v, got %v", m["host.name"], previous, host)
+		}
+
+		if previous, ok := ips[m["host.ip"]]; ok && previous != m["host.name"] {
+			t.Errorf("Expected ip %s to always belong to host %s, got %s", m["host.ip"], previous, m["host.name"])
+		}
+
+		hosts[m["host.name"]] = host
+		ips[m["host.ip"]] = m["host.name"]
+		users[m["user.name"]] = struct{}{}
+	}
+
+	if len(hosts) != 10 {
+		t.Errorf("Expected 10 hosts, got %d", len(hosts))
+	}
+
+	if len(users) != 3 {
+		t.Errorf("Expected 3 users, got %d", len(users))
 	}
 }
 
-func Test_FieldStaticOverrideStringWithTextTemplate(t *testing.T) {
-	fld := Field{
+func Test_FieldPatternWithTextTemplate(t *testing.T) {
+	patterns := map[string]string{
+		"instance": `i-[0-9a-f]{17}`,
+		"pod":      `[a-z]+-[a-z0-9]{8,10}-[a-z0-9]{5}`,
+		"arn":      `arn:aws:(ec2|s3|lambda):us-(east|west)-[12]:\d{12}:[a-z]+/\w+`,
+	}
+
+	fields := make([]Field, 0, len(patterns))
+	configYaml := "fields:"
+	for name, pattern := range patterns {
+		fields = append(fields, Field{Name: name, Type: FieldTypeKeyword})
+		configYaml += fmt.Sprintf("\n  - name: %s\n    pattern: '%s'", name, pattern)
+	}
+
+	template := []byte(`{"instance":"{{generate "instance"}}","pod":"{{generate "pod"}}","arn":"{{generate "arn"}}"}`)
+	t.Logf("with template: %s", string(template))
+
+	cfg, err := config.LoadConfigFromYaml([]byte(configYaml))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := makeGeneratorWithTextTemplate(t, cfg, fields, template, 0)
+
+	for i := 0; i < 100; i++ {
+		var buf bytes.Buffer
+		if err := g.Emit(&buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[string](t, buf.Bytes())
+		for name, pattern := range patterns {
+			if !regexp.MustCompile("^" + pattern + "$").MatchString(m[name]) {
+				t.Errorf("Expected %s to match %s, got %s", name, pattern, m[name])
+			}
+		}
+	}
+}
+
+func Test_FieldFakerWithTextTemplate(t *testing.T) {
+	fields := []Field{
+		{Name: "user.email", Type: FieldTypeKeyword},
+		{Name: "user_agent.original", Type: FieldTypeKeyword},
+		{Name: "http.response.status_code", Type: FieldTypeLong},
+		{Name: "source.ip", Type: FieldTypeIP},
+		{Name: "event.id", Type: FieldTypeKeyword},
+		{Name: "file.path", Type: FieldTypeKeyword},
+	}
+
+	template := []byte(`{"user.email":"{{generate "user.email"}}","user_agent.original":"{{generate "user_agent.original"}}","http.response.status_code":{{generate "http.response.status_code"}},"source.ip":"{{generate "source.ip"}}","event.id":"{{generate "event.id"}}","file.path":"{{generate "file.path"}}"}`)
+	configYaml := []byte(`fields:
+  - name: user.email
+    faker: email
+  - name: user_agent.original
+    faker: user_agent
+  - name: http.response.status_code
+    faker: http_status
+  - name: source.ip
+    faker: ipv6
+  - name: event.id
+    faker: uuid
+  - name: file.path
+    faker: file_path
+`)
+	t.Logf("with template: %s", string(template))
+
+	cfg, err := config.LoadConfigFromYaml(configYaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := makeGeneratorWithTextTemplate(t, cfg, fields, template, 0)
+
+	uuidRegex := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+	for i := 0; i < 100; i++ {
+		var buf bytes.Buffer
+		if err := g.Emit(&buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[any](t, buf.Bytes())
+		if email := m["user.email"].(string); !strings.Contains(email, "@") {
+			t.Errorf("Expected an email, got %s", email)
+		}
+
+		if len(m["user_agent.original"].(string)) == 0 {
+			t.Errorf("Expected an user agent, got an empty string")
+		}
+
+		if status := m["http.response.status_code"].(float64); status < 100 || status > 599 {
+			t.Errorf("Expected an http status, got %v", status)
+		}
+
+		if ip := net.ParseIP(m["source.ip"].(string)); ip == nil || ip.To4() != nil {
+			t.Errorf("Expected an ipv6 address, got %v", m["source.ip"])
+		}
+
+		if id := m["event.id"].(string); !uuidRegex.MatchString(id) {
+			t.Errorf("Expected an uuid, got %s", id)
+		}
+
+		if path := m["file.path"].(string); !strings.HasPrefix(path, "/") {
+			t.Errorf("Expected an absolute file path, got %s", path)
+		}
+	}
+
+	configYaml = []byte("fields:\n  - name: user.email\n    faker: not_a_provider")
+	cfg, err = config.LoadConfigFromYaml(configYaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewGenerator(cfg, fields[:1], 0, WithTextTemplate([]byte(`{"user.email":"{{generate "user.email"}}"}`))); err == nil {
+		t.Fatal("Expected error for an unsupported faker provider")
+	}
+}
+
+func Test_FieldDistributionWithTextTemplate(t *testing.T) {
+	testCases := []struct {
+		scenario     string
+		fieldType    string
+		config       string
+		expectedMode float64
+		min          float64
+		max          float64
+	}{
+		{
+			scenario:     "zipf long",
+			fieldType:    FieldTypeLong,
+			config:       "distribution:\n      type: zipf\n      s: 2\n    range:\n      min: 10\n      max: 1000",
+			expectedMode: 10,
+			min:          10,
+			max:          1000,
+		},
+		{
+			scenario:     "lognormal double clamped to range",
+			fieldType:    FieldTypeDouble,
+			config:       "distribution:\n      type: lognormal\n      mu: 5\n      sigma: 1\n    range:\n      max: 1",
+			expectedMode: 1,
+			min:          0,
+			max:          1,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.scenario, func(t *testing.T) {
+			fld := Field{
+				Name: "alpha",
+				Type: testCase.fieldType,
+			}
+
+			template := []byte(`{"alpha":{{generate "alpha"}}}`)
+			configYaml := []byte("fields:\n  - name: alpha\n    " + testCase.config)
+			t.Logf("with template: %s", string(template))
+
+			cfg, err := config.LoadConfigFromYaml(configYaml)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			nSpins := 10000
+			g := makeGeneratorWithTextTemplate(t, cfg, []Field{fld}, template, uint64(nSpins))
+
+			vmap := make(map[float64]int)
+			for i := 0; i < nSpins; i++ {
+				var buf bytes.Buffer
+				if err := g.Emit(&buf); err != nil {
+					t.Fatal(err)
+				}
+
+				m := unmarshalJSONT[float64](t, buf.Bytes())
+				v := m[fld.Name]
+				if v < testCase.min || v > testCase.max {
+					t.Fatalf("Value %v out of bounds [%v, %v]", v, testCase.min, testCase.max)
+				}
+
+				vmap[v] += 1
+			}
+
+			var mode float64
+			for v, count := range vmap {
+				if count > vmap[mode] {
+					mode = v
+				}
+			}
+
+			if mode != testCase.expectedMode {
+				t.Errorf("Expected most frequent value %v, got %v", testCase.expectedMode, mode)
+			}
+		})
+	}
+}
+
+func Test_FieldShapeWithTextTemplate(t *testing.T) {
+	fldTimestamp := Field{
+		Name: "@timestamp",
+		Type: FieldTypeDate,
+	}
+
+	fldAlpha := Field{
+		Name: "alpha",
+		Type: FieldTypeDouble,
+	}
+
+	template := []byte(`{{ $timestamp := generate "@timestamp" }}{"@timestamp":"{{ $timestamp.Format "2006-01-02T15:04:05.999999Z07:00" }}","alpha":{{generate "alpha"}}}`)
+	configYaml := []byte(`fields:
+  - name: "@timestamp"
+    period: 1h
+  - name: alpha
+    shape:
+      base: 50
+      period: 1h
+      amplitude: 10
+      phase: 15m
+      noise: 0.1
+`)
+	t.Logf("with template: %s", string(template))
+
+	cfg, err := config.LoadConfigFromYaml(configYaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	startTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	nSpins := 60
+	g := makeGeneratorWithTextTemplate(t, cfg, []Field{fldTimestamp, fldAlpha}, template, uint64(nSpins), WithStartTime(startTime))
+
+	for i := 0; i < nSpins; i++ {
+		var buf bytes.Buffer
+		if err := g.Emit(&buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[any](t, buf.Bytes())
+
+		expectedAlpha := 50 + 10*math.Sin(2*math.Pi*float64(i-15)/60)
+		if alpha := m[fldAlpha.Name].(float64); math.Abs(alpha-expectedAlpha) > expectedAlpha*0.1 {
+			t.Errorf("Expected alpha %v at minute %d, got %v", expectedAlpha, i, alpha)
+		}
+	}
+}
+
+func Test_FieldMissingProbabilityWithTextTemplate(t *testing.T) {
+	fldAlpha := Field{
 		Name: "alpha",
 		Type: FieldTypeKeyword,
 	}
 
-	yaml := []byte("fields:\n  - name: alpha\n    value: beta")
-	template := []byte(`{"alpha":"{{generate "alpha"}}"}`)
-	t.Logf("with template: %s", string(template))
-	b := testSingleTWithTextTemplate[string](t, fld, yaml, template)
-	if b != "beta" {
-		t.Errorf("static value not match")
+	fldBeta := Field{
+		Name: "beta",
+		Type: FieldTypeDate,
+	}
+
+	configYaml := []byte("fields:\n  - name: alpha\n    missing_probability: 0.5\n  - name: beta\n    missing_probability: 0.5")
+
+	cfg, err := config.LoadConfigFromYaml(configYaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state := newGenState(rand.Int63(), time.Now())
+	template, _ := generateTextTemplateFromField(cfg, []Field{fldAlpha, fldBeta}, false, state)
+	t.Logf("with template: %s", string(template))
+
+	nSpins := 1000
+	g := makeGeneratorWithTextTemplate(t, cfg, []Field{fldAlpha, fldBeta}, template, uint64(nSpins))
+
+	vmap := make(map[string]int)
+	for i := 0; i < nSpins; i++ {
+		var buf bytes.Buffer
+		if err := g.Emit(&buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[any](t, buf.Bytes())
+		for k := range m {
+			vmap[k] += 1
+		}
+	}
+
+	// 50% expected, leaving some room for randomness
+	for _, fld := range []Field{fldAlpha, fldBeta} {
+		if vmap[fld.Name] < nSpins/4 || vmap[fld.Name] > nSpins*3/4 {
+			t.Errorf("Expected field %s to be present around half of the times, got it %d times over %d", fld.Name, vmap[fld.Name], nSpins)
+		}
+	}
+}
+
+func Test_FieldMissingProbabilityKeyOrderWithTextTemplate(t *testing.T) {
+	fields := Fields{
+		{Name: "alpha", Type: FieldTypeKeyword},
+		{Name: "beta", Type: FieldTypeLong},
+		{Name: "gamma", Type: FieldTypeDate},
+		{Name: "delta", Type: FieldTypeKeyword},
+	}
+
+	testCases := []struct {
+		scenario string
+		config   string
+	}{
+		{
+			scenario: "with fields always present",
+			config:   "fields:\n  - name: alpha\n    missing_probability: 0.5\n  - name: gamma\n    missing_probability: 0.5\n  - name: delta\n    missing_probability: 0.5",
+		},
+		{
+			scenario: "without fields always present",
+			config:   "fields:\n  - name: alpha\n    missing_probability: 0.5\n  - name: beta\n    missing_probability: 0.5\n  - name: gamma\n    missing_probability: 0.5\n  - name: delta\n    missing_probability: 0.5",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.scenario, func(t *testing.T) {
+			cfg, err := config.LoadConfigFromYaml([]byte(testCase.config))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			state := newGenState(rand.Int63(), time.Now())
+			template, _ := generateTextTemplateFromField(cfg, fields, false, state)
+			t.Logf("with template: %s", string(template))
+
+			g := makeGeneratorWithTextTemplate(t, cfg, fields, template, 0)
+			for i := 0; i < 100; i++ {
+				var buf bytes.Buffer
+				if err := g.Emit(&buf); err != nil {
+					t.Fatal(err)
+				}
+
+				if !json.Valid(buf.Bytes()) {
+					t.Fatalf("Expected valid JSON, got %s", buf.String())
+				}
+
+				// the keys are in the order of the fields definition
+				previous := -1
+				for _, field := range fields {
+					idx := bytes.Index(buf.Bytes(), []byte(`"`+field.Name+`"`))
+					if idx < 0 {
+						continue
+					}
+
+					if idx < previous {
+						t.Errorf("Expected keys in the order of the fields definition, got %s", buf.String())
+					}
+
+					previous = idx
+				}
+			}
+		})
+	}
+}
+
+func Test_FieldBoolWithTextTemplate(t *testing.T) {
+	fld := Field{
+		Name: "alpha",
+		Type: FieldTypeBool,
+	}
+
+	template := []byte(`{"alpha":{{generate "alpha"}}}`)
+	t.Logf("with template: %s", string(template))
+	// Enough spins, so we can make sure we get at least one true and at least one false
+	var cntTrue int
+	nSpins := 1024
+	for i := 0; i < nSpins; i++ {
+		b := testSingleTWithTextTemplate[bool](t, fld, nil, template)
+
+		if b {
+			cntTrue += 1
+		}
+	}
+
+	if cntTrue == 0 {
+		t.Errorf("No true values, really?")
+	}
+
+	if cntTrue == nSpins {
+		t.Errorf("No false values, really?")
+	}
+}
+
+func Test_FieldConstKeywordWithTextTemplate(t *testing.T) {
+
+	fld := Field{
+		Name:  "alpha",
+		Type:  FieldTypeConstantKeyword,
+		Value: "constant_keyword",
+	}
+
+	template := []byte(`{"alpha":"{{generate "alpha"}}"}`)
+	t.Logf("with template: %s", string(template))
+	b := testSingleTWithTextTemplate[string](t, fld, nil, template)
+	if b != fld.Value {
+		t.Errorf("static value not match")
+	}
+}
+
+func Test_FieldStaticOverrideStringWithTextTemplate(t *testing.T) {
+	fld := Field{
+		Name: "alpha",
+		Type: FieldTypeKeyword,
+	}
+
+	yaml := []byte("fields:\n  - name: alpha\n    value: beta")
+	template := []byte(`{"alpha":"{{generate "alpha"}}"}`)
+	t.Logf("with template: %s", string(template))
+	b := testSingleTWithTextTemplate[string](t, fld, yaml, template)
+	if b != "beta" {
+		t.Errorf("static value not match")
+	}
+}
+
+func Test_FieldStaticOverrideNumericWithTextTemplate(t *testing.T) {
+	fld := Field{
+
+		Name: "alpha",
+		Type: FieldTypeKeyword,
+	}
+
+	yaml := []byte("fields:\n  - name: alpha\n    value: 33")
+	template := []byte(`{"alpha":{{generate "alpha"}}}`)
+	t.Logf("with template: %s", string(template))
+	b := testSingleTWithTextTemplate[float64](t, fld, yaml, template)
+
+	if b != 33.0 {
+		t.Errorf("static value not match")
+	}
+}
+
+func Test_FieldStaticOverrideBoolWithTextTemplate(t *testing.T) {
+	fld := Field{
+		Name: "alpha",
+		Type: FieldTypeKeyword,
+	}
+
+	yaml := []byte("fields:\n  - name: alpha\n    value: true")
+	template := []byte(`{"alpha":{{generate "alpha"}}}`)
+	t.Logf("with template: %s", string(template))
+	b := testSingleTWithTextTemplate[bool](t, fld, yaml, template)
+
+	if b != true {
+		t.Errorf("static value not match")
+	}
+}
+
+func Test_FieldGeoPointWithTextTemplate(t *testing.T) {
+	fld := Field{
+		Name: "alpha",
+		Type: FieldTypeGeoPoint,
+	}
+
+	template := []byte(`{"alpha":"{{generate "alpha"}}"}`)
+	t.Logf("with template: %s", string(template))
+	nSpins := 1024
+	for i := 0; i < nSpins; i++ {
+
+		b := testSingleTWithTextTemplate[string](t, fld, nil, template)
+
+		// Expect geo point in form of lat,long
+		// where lat is [-90.0..90.0]
+		// and long is  [-180.0..180.0]
+
+		s := strings.Split(b, ",")
+		if len(s) != 2 {
+			t.Fatal("expected comma separated lat,long")
+		}
+
+		lat := s[0]
+		long := s[1]
+
+		// no whitespace please
+		if len(lat) != len(strings.TrimSpace(lat)) {
+			t.Errorf("extra whitespace on latitude %s", lat)
+		}
+
+		// no whitespace please
+		if len(long) != len(strings.TrimSpace(long)) {
+			t.Errorf("extra whitespace on longitude %s", long)
+		}
+
+		latF, err := strconv.ParseFloat(lat, 64)
+		if err != nil {
+			t.Errorf("Fail parse latitude as float")
+		}
+		longF, err := strconv.ParseFloat(long, 64)
+		if err != nil {
+			t.Errorf("Fail parse longitude as float")
+		}
+
+		if latF < -90.0 || latF > 90.0 {
+			t.Errorf("latitude out of range %v", latF)
+		}
+
+		if longF < -180.0 || longF > 180.0 {
+			t.Errorf("longitutde out of range %v", longF)
+		}
+	}
+}
+
+func Test_FieldDateWithTextTemplate(t *testing.T) {
+	fld := Field{
+		Name: "alpha",
+		Type: FieldTypeDate,
+	}
+
+	template := []byte(`{{$alpha := generate "alpha"}}{"alpha":"{{$alpha.Format "2006-01-02T15:04:05.999999999-07:00"}}"}`)
+	t.Logf("with template: %s", string(template))
+	nSpins := rand.Intn(1024) + 1
+	for i := 0; i < nSpins; i++ {
+		startTime := time.Now().Truncate(time.Microsecond)
+		b := testSingleTWithTextTemplate[string](t, fld, nil, template, WithStartTime(startTime))
+
+		if ts, err := time.Parse(FieldTypeTimeLayout, b); err != nil {
+			t.Errorf("Fail parse timestamp %v", err)
+		} else {
+			// Timestamp should be from now within a FieldTypeDurationSpan milliseconds of slop
+			diff := ts.Sub(startTime)
+			if diff < 0 || diff > FieldTypeDurationSpan*time.Millisecond {
+				t.Errorf("Data generated before now, diff: %v", diff)
+			}
+		}
+	}
+}
+
+func Test_FieldDateAndPeriodPositiveWithTextTemplate(t *testing.T) {
+	fld := Field{
+		Name: "alpha",
+		Type: FieldTypeDate,
+	}
+
+	template := []byte(`{{$alpha := generate "alpha"}}{"alpha":"{{$alpha.Format "2006-01-02T15:04:05.999999999-07:00"}}"}`)
+	configYaml := []byte("fields:\n  - name: alpha\n    period: 10s")
+	t.Logf("with template: %s", string(template))
+
+	cfg, err := config.LoadConfigFromYaml(configYaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	startTime := time.Now().Truncate(time.Microsecond)
+	g := makeGeneratorWithTextTemplate(t, cfg, []Field{fld}, template, 10, WithStartTime(startTime))
+
+	var buf bytes.Buffer
+
+	nSpins := 10
+	for i := 0; i < nSpins; i++ {
+		if err := g.Emit(&buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[string](t, buf.Bytes())
+		buf.Reset()
+
+		if len(m) != 1 {
+			t.Errorf("Expected map size 1, got %d", len(m))
+		}
+
+		v, ok := m[fld.Name]
+
+		if !ok {
+			t.Errorf("Missing key %v", fld.Name)
+		}
+
+		if ts, err := time.Parse(FieldTypeTimeLayout, v); err != nil {
+			t.Errorf("Fail parse timestamp %v", err)
+		} else {
+			// Timestamp should be +1s for every iteration
+			expectedTime := startTime.Add(time.Second * time.Duration(i))
+
+			diff := expectedTime.Sub(ts)
+			if diff != 0 {
+				t.Errorf("Date generated out of period range %v", diff)
+			}
+		}
+	}
+}
+
+func Test_FieldDateAndPeriodNegativeWithTextTemplate(t *testing.T) {
+	fld := Field{
+		Name: "alpha",
+		Type: FieldTypeDate,
+	}
+
+	template := []byte(`{{$alpha := generate "alpha"}}{"alpha":"{{$alpha.Format "2006-01-02T15:04:05.999999999-07:00"}}"}`)
+	configYaml := []byte("fields:\n  - name: alpha\n    period: -10s")
+	t.Logf("with template: %s", string(template))
+
+	cfg, err := config.LoadConfigFromYaml(configYaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	startTime := time.Now().Truncate(time.Microsecond)
+	g := makeGeneratorWithTextTemplate(t, cfg, []Field{fld}, template, 10, WithStartTime(startTime))
+
+	var buf bytes.Buffer
+
+	nSpins := 10
+	for i := 0; i < nSpins; i++ {
+		if err := g.Emit(&buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[string](t, buf.Bytes())
+		buf.Reset()
+
+		if len(m) != 1 {
+			t.Errorf("Expected map size 1, got %d", len(m))
+		}
+
+		v, ok := m[fld.Name]
+
+		if !ok {
+			t.Errorf("Missing key %v", fld.Name)
+		}
+
+		if ts, err := time.Parse(FieldTypeTimeLayout, v); err != nil {
+			t.Errorf("Fail parse timestamp %v", err)
+		} else {
+			// Timestamp should be +1s for every iteration
+			expectedTime := startTime.Add(-10*time.Second + time.Second*time.Duration(i))
+
+			diff := expectedTime.Sub(ts)
+			if diff != 0 {
+				t.Errorf("Date generated out of period range %v", diff)
+			}
+		}
+	}
+}
+
+func Test_FieldDateAndRangeFromInThePastWithTextTemplate(t *testing.T) {
+	fld := Field{
+		Name: "alpha",
+		Type: FieldTypeDate,
+	}
+
+	startTime := time.Now().Truncate(time.Microsecond)
+	from := startTime.Add(-10 * time.Second)
+	template := []byte(`{{$alpha := generate "alpha"}}{"alpha":"{{$alpha.Format "2006-01-02T15:04:05.999999999-07:00"}}"}`)
+	configYaml := []byte(fmt.Sprintf("fields:\n  - name: alpha\n    range:\n      from: %s", from.Format("2006-01-02T15:04:05.999999999-07:00")))
+	t.Logf("with template: %s", string(template))
+
+	cfg, err := config.LoadConfigFromYaml(configYaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := makeGeneratorWithTextTemplate(t, cfg, []Field{fld}, template, 10, WithStartTime(startTime))
+
+	var buf bytes.Buffer
+
+	period := startTime.UTC().Sub(from.UTC())
+
+	nSpins := int64(10)
+	for i := int64(0); i < nSpins; i++ {
+		if err := g.Emit(&buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[string](t, buf.Bytes())
+		buf.Reset()
+
+		if len(m) != 1 {
+			t.Errorf("Expected map size 1, got %d", len(m))
+		}
+
+		v, ok := m[fld.Name]
+
+		if !ok {
+			t.Errorf("Missing key %v", fld.Name)
+		}
+
+		if ts, err := time.Parse(FieldTypeTimeLayout, v); err != nil {
+			t.Errorf("Fail parse timestamp %v", err)
+		} else {
+			// Timestamp should be +(period.Nanoseconds() / nSpins) * i) for every iteration
+			expectedTime := startTime.Add(time.Duration((period.Nanoseconds() / nSpins) * i))
+
+			diff := expectedTime.Sub(ts)
+			if diff != 0 {
+				t.Errorf("Date generated out of period range %v", diff)
+			}
+		}
+	}
+}
+
+func Test_FieldDateAndRangeFromInTheFutureWithTextTemplate(t *testing.T) {
+	fld := Field{
+		Name: "alpha",
+		Type: FieldTypeDate,
+	}
+
+	startTime := time.Now().Truncate(time.Microsecond)
+	from := startTime.Add(10 * time.Second)
+	template := []byte(`{{$alpha := generate "alpha"}}{"alpha":"{{$alpha.Format "2006-01-02T15:04:05.999999999-07:00"}}"}`)
+	configYaml := []byte(fmt.Sprintf("fields:\n  - name: alpha\n    range:\n      from: %s", from.Format("2006-01-02T15:04:05.999999999-07:00")))
+	t.Logf("with template: %s", string(template))
+
+	cfg, err := config.LoadConfigFromYaml(configYaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := makeGeneratorWithTextTemplate(t, cfg, []Field{fld}, template, 10, WithStartTime(startTime))
+
+	var buf bytes.Buffer
+
+	period := from.UTC().Sub(startTime.UTC())
+
+	nSpins := int64(10)
+	for i := int64(0); i < nSpins; i++ {
+		if err := g.Emit(&buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[string](t, buf.Bytes())
+		buf.Reset()
+
+		if len(m) != 1 {
+			t.Errorf("Expected map size 1, got %d", len(m))
+		}
+
+		v, ok := m[fld.Name]
+
+		if !ok {
+			t.Errorf("Missing key %v", fld.Name)
+		}
+
+		if ts, err := time.Parse(FieldTypeTimeLayout, v); err != nil {
+			t.Errorf("Fail parse timestamp %v", err)
+		} else {
+			// Timestamp should be +(period.Nanoseconds() / nSpins) * i) for every iteration
+			expectedTime := startTime.Add(time.Duration((period.Nanoseconds() / nSpins) * i))
+
+			diff := expectedTime.Sub(ts)
+			if diff != 0 {
+				t.Errorf("Date generated out of period range %v", diff)
+			}
+		}
+	}
+}
+
+func Test_FieldDateAndRangeToInThePastWithTextTemplate(t *testing.T) {
+	fld := Field{
+		Name: "alpha",
+		Type: FieldTypeDate,
+	}
+
+	startTime := time.Now().Truncate(time.Microsecond)
+	to := startTime.Add(-10 * time.Second)
+	template := []byte(`{{$alpha := generate "alpha"}}{"alpha":"{{$alpha.Format "2006-01-02T15:04:05.999999999-07:00"}}"}`)
+	configYaml := []byte(fmt.Sprintf("fields:\n  - name: alpha\n    range:\n      to: %s", to.Format("2006-01-02T15:04:05.999999999-07:00")))
+	t.Logf("with template: %s", string(template))
+
+	cfg, err := config.LoadConfigFromYaml(configYaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := makeGeneratorWithTextTemplate(t, cfg, []Field{fld}, template, 10, WithStartTime(startTime))
+
+	var buf bytes.Buffer
+
+	period := startTime.UTC().Sub(to.UTC())
+
+	nSpins := int64(10)
+	for i := int64(0); i < nSpins; i++ {
+		if err := g.Emit(&buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[string](t, buf.Bytes())
+		buf.Reset()
+
+		if len(m) != 1 {
+			t.Errorf("Expected map size 1, got %d", len(m))
+		}
+
+		v, ok := m[fld.Name]
+
+		if !ok {
+			t.Errorf("Missing key %v", fld.Name)
+		}
+
+		if ts, err := time.Parse(FieldTypeTimeLayout, v); err != nil {
+			t.Errorf("Fail parse timestamp %v", err)
+		} else {
+			// Timestamp should be +(period.Nanoseconds() / nSpins) * i) for every iteration
+			expectedTime := startTime.Add(time.Duration((period.Nanoseconds() / nSpins) * i))
+
+			diff := expectedTime.Sub(ts)
+			if diff != 0 {
+				t.Errorf("Date generated out of period range %v", diff)
+			}
+		}
+	}
+}
+
+func Test_FieldDateAndRangeToInTheFutureWithTextTemplate(t *testing.T) {
+	fld := Field{
+		Name: "alpha",
+		Type: FieldTypeDate,
+	}
+
+	startTime := time.Now().Truncate(time.Microsecond)
+	to := startTime.Add(10 * time.Second)
+	template := []byte(`{{$alpha := generate "alpha"}}{"alpha":"{{$alpha.Format "2006-01-02T15:04:05.999999999-07:00"}}"}`)
+	configYaml := []byte(fmt.Sprintf("fields:\n  - name: alpha\n    range:\n      to: %s", to.Format("2006-01-02T15:04:05.999999999-07:00")))
+	t.Logf("with template: %s", string(template))
+
+	cfg, err := config.LoadConfigFromYaml(configYaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := makeGeneratorWithTextTemplate(t, cfg, []Field{fld}, template, 10, WithStartTime(startTime))
+
+	var buf bytes.Buffer
+
+	period := to.UTC().Sub(startTime.UTC())
+
+	nSpins := int64(10)
+	for i := int64(0); i < nSpins; i++ {
+		if err := g.Emit(&buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[string](t, buf.Bytes())
+		buf.Reset()
+
+		if len(m) != 1 {
+			t.Errorf("Expected map size 1, got %d", len(m))
+		}
+
+		v, ok := m[fld.Name]
+
+		if !ok {
+			t.Errorf("Missing key %v", fld.Name)
+		}
+
+		if ts, err := time.Parse(FieldTypeTimeLayout, v); err != nil {
+			t.Errorf("Fail parse timestamp %v", err)
+		} else {
+			// Timestamp should be +(period.Nanoseconds() / nSpins) * i) for every iteration
+			expectedTime := startTime.Add(time.Duration((period.Nanoseconds() / nSpins) * i))
+
+			diff := expectedTime.Sub(ts)
+			if diff != 0 {
+				t.Errorf("Date generated out of period range %v", diff)
+			}
+		}
+	}
+}
+
+func Test_FieldDateAndRangeFromAndToPositiveWithTextTemplate(t *testing.T) {
+	fld := Field{
+		Name: "alpha",
+		Type: FieldTypeDate,
+	}
+
+	startTime := time.Now().Truncate(time.Microsecond)
+	from := startTime.Add(-10 * time.Second)
+	to := startTime.Add(10 * time.Second)
+	template := []byte(`{{$alpha := generate "alpha"}}{"alpha":"{{$alpha.Format "2006-01-02T15:04:05.999999999-07:00"}}"}`)
+	configYaml := []byte(fmt.Sprintf("fields:\n  - name: alpha\n    range:\n      from: %s\n      to: %s", from.Format("2006-01-02T15:04:05.999999999-07:00"), to.Format("2006-01-02T15:04:05.999999999-07:00")))
+	t.Logf("with template: %s", string(template))
+
+	cfg, err := config.LoadConfigFromYaml(configYaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := makeGeneratorWithTextTemplate(t, cfg, []Field{fld}, template, 10, WithStartTime(startTime))
+
+	var buf bytes.Buffer
+
+	period := to.UTC().Sub(from.UTC())
+
+	nSpins := int64(10)
+	for i := int64(0); i < nSpins; i++ {
+		if err := g.Emit(&buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[string](t, buf.Bytes())
+		buf.Reset()
+
+		if len(m) != 1 {
+			t.Errorf("Expected map size 1, got %d", len(m))
+		}
+
+		v, ok := m[fld.Name]
+
+		if !ok {
+			t.Errorf("Missing key %v", fld.Name)
+		}
+
+		if ts, err := time.Parse(FieldTypeTimeLayout, v); err != nil {
+			t.Errorf("Fail parse timestamp %v", err)
+		} else {
+			// Timestamp should be +(period.Nanoseconds() / nSpins) * i) for every iteration
+			expectedTime := from.Add(time.Duration((period.Nanoseconds() / nSpins) * i))
+
+			diff := expectedTime.Sub(ts)
+			if diff != 0 {
+				t.Errorf("Date generated out of period range %v", diff)
+			}
+		}
+	}
+}
+
+func Test_FieldDateAndRangeFromAndToNegativeWithTextTemplate(t *testing.T) {
+	fld := Field{
+		Name: "alpha",
+		Type: FieldTypeDate,
+	}
+
+	startTime := time.Now().Truncate(time.Microsecond)
+	from := startTime.Add(10 * time.Second)
+	to := startTime.Add(-10 * time.Second)
+	template := []byte(`{{$alpha := generate "alpha"}}{"alpha":"{{$alpha.Format "2006-01-02T15:04:05.999999999-07:00"}}"}`)
+	configYaml := []byte(fmt.Sprintf("fields:\n  - name: alpha\n    range:\n      from: %s\n      to: %s", from.Format("2006-01-02T15:04:05.999999999-07:00"), to.Format("2006-01-02T15:04:05.999999999-07:00")))
+	t.Logf("with template: %s", string(template))
+
+	cfg, err := config.LoadConfigFromYaml(configYaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := makeGeneratorWithTextTemplate(t, cfg, []Field{fld}, template, 10, WithStartTime(startTime))
+
+	var buf bytes.Buffer
+
+	period := to.UTC().Sub(from.UTC())
+
+	nSpins := int64(10)
+	for i := int64(0); i < nSpins; i++ {
+		if err := g.Emit(&buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[string](t, buf.Bytes())
+		buf.Reset()
+
+		if len(m) != 1 {
+			t.Errorf("Expected map size 1, got %d", len(m))
+		}
+
+		v, ok := m[fld.Name]
+
+		if !ok {
+			t.Errorf("Missing key %v", fld.Name)
+		}
+
+		if ts, err := time.Parse(FieldTypeTimeLayout, v); err != nil {
+			t.Errorf("Fail parse timestamp %v", err)
+		} else {
+			// Timestamp should be +(period.Nanoseconds() / nSpins) * i) for every iteration
+			expectedTime := from.Add(time.Duration((period.Nanoseconds() / nSpins) * (nSpins - i)))
+
+			diff := expectedTime.Sub(ts)
+			if diff != 0 {
+				t.Errorf("Date generated out of period range %v", diff)
+			}
+		}
+	}
+}
+
+func Test_FieldIPWithTextTemplate(t *testing.T) {
+	fld := Field{
+		Name: "alpha",
+		Type: FieldTypeIP,
+	}
+
+	template := []byte(`{"alpha":"{{generate "alpha"}}"}`)
+	t.Logf("with template: %s", string(template))
+	nSpins := rand.Intn(1024) + 1
+	for i := 0; i < nSpins; i++ {
+
+		b := testSingleTWithTextTemplate[string](t, fld, nil, template)
+
+		if ip := net.ParseIP(b); ip == nil {
+			t.Errorf("Fail parse ip %s", b)
+		}
+	}
+}
+
+func Test_FieldLongCounterResetAfterN5WithTextTemplate(t *testing.T) {
+	fld := Field{
+		Name: "counter_reset_test",
+		Type: FieldTypeLong,
+	}
+
+	afterN := 5
+
+	template := []byte(`{{$counter_reset_test := generate "counter_reset_test"}}{"counter_reset_test":"{{$counter_reset_test}}"}`)
+	configYaml := []byte(fmt.Sprintf(`fields:
+- name: counter_reset_test
+  counter: true
+  counter_reset:
+    strategy: after_n
+    reset_after_n: %d`, afterN))
+	t.Logf("with template: %s", string(template))
+
+	cfg, err := config.LoadConfigFromYaml(configYaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := makeGeneratorWithTextTemplate(t, cfg, []Field{fld}, template, 40)
+
+	var buf bytes.Buffer
+
+	nSpins := int64(40)
+
+	var resetCount int64
+	expectedResetCount := nSpins / int64(afterN) // 8
+
+	for i := int64(0); i < nSpins; i++ {
+		if err := g.Emit(&buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[string](t, buf.Bytes())
+		buf.Reset()
+
+		if len(m) != 1 {
+			t.Errorf("Expected map size 1, got %d", len(m))
+		}
+
+		v, ok := m[fld.Name]
+		if !ok {
+			t.Errorf("Missing key %v", fld.Name)
+		}
+
+		if i%int64(afterN) == 0 {
+			if v != "0" {
+				t.Errorf("Expected counter to reset to 0, got %v", v)
+			}
+			resetCount++
+		}
+
+		t.Logf("counter value: %v", v)
+	}
+
+	if resetCount != expectedResetCount {
+		t.Errorf("Expected counter to reset %d times, got %d", expectedResetCount, resetCount)
+	}
+}
+
+func Test_FieldIPConfigWithTextTemplate(t *testing.T) {
+	fields := []Field{
+		{Name: "source.ip", Type: FieldTypeIP},
+		{Name: "destination.ip", Type: FieldTypeIP},
+		{Name: "host.ip", Type: FieldTypeIP},
+	}
+
+	template := []byte(`{"source.ip":"{{generate "source.ip"}}","destination.ip":"{{generate "destination.ip"}}","host.ip":"{{generate "host.ip"}}"}`)
+	configYaml := []byte(`fields:
+  - name: source.ip
+    ip:
+      cidrs: ["10.0.0.0/8", "192.168.1.0/28", "2001:db8::/32"]
+      ipv6_ratio: 0.5
+  - name: destination.ip
+    ip:
+      private: true
+      ipv6_ratio: 1
+  - name: host.ip
+    cardinality: 5
+    ip:
+      cidrs: ["172.16.0.0/12"]
+`)
+	t.Logf("with template: %s", string(template))
+
+	cfg, err := config.LoadConfigFromYaml(configYaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nSpins := 1000
+	g := makeGeneratorWithTextTemplate(t, cfg, fields, template, uint64(nSpins))
+
+	sourcePrefixes := []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("192.168.1.0/28"),
+		netip.MustParsePrefix("2001:db8::/32"),
+	}
+
+	var sourceIPv6 int
+	hostIPs := make(map[string]struct{})
+	for i := 0; i < nSpins; i++ {
+		var buf bytes.Buffer
+		if err := g.Emit(&buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[string](t, buf.Bytes())
+
+		sourceIP := netip.MustParseAddr(m["source.ip"])
+		inPrefix := false
+		for _, prefix := range sourcePrefixes {
+			inPrefix = inPrefix || prefix.Contains(sourceIP)
+		}
+
+		if !inPrefix {
+			t.Errorf("Expected source.ip %s to be in the configured cidrs", sourceIP)
+		}
+
+		if sourceIP.Is6() {
+			sourceIPv6++
+		}
+
+		if destinationIP := netip.MustParseAddr(m["destination.ip"]); !destinationIP.Is6() || !destinationIP.IsPrivate() {
+			t.Errorf("Expected destination.ip %s to be a private ipv6 address", destinationIP)
+		}
+
+		if hostIP := netip.MustParseAddr(m["host.ip"]); !netip.MustParsePrefix("172.16.0.0/12").Contains(hostIP) {
+			t.Errorf("Expected host.ip %s to be in 172.16.0.0/12", hostIP)
+		}
+
+		hostIPs[m["host.ip"]] = struct{}{}
+	}
+
+	if sourceIPv6 < nSpins*4/10 || sourceIPv6 > nSpins*6/10 {
+		t.Errorf("Expected around 50%% of ipv6 source.ip, got %d over %d", sourceIPv6, nSpins)
+	}
+
+	if len(hostIPs) != 5 {
+		t.Errorf("Expected 5 different host.ip, got %d", len(hostIPs))
+	}
+}
+
+func Test_FieldGeoPointConfigWithTextTemplate(t *testing.T) {
+	fields := Fields{
+		{Name: "box", Type: FieldTypeGeoPoint},
+		{Name: "city", Type: FieldTypeGeoPoint},
+		{Name: "polygon", Type: FieldTypeGeoPoint},
+		{Name: "wkt", Type: FieldTypeGeoPoint},
+	}
+
+	configYaml := []byte(`fields:
+  - name: box
+    geo_point:
+      bounding_boxes:
+        - {min_lat: 40, max_lat: 41, min_lon: -75, max_lon: -73}
+  - name: city
+    geo_point:
+      format: object
+      cities:
+        - name: paris
+          radius: 10
+        - lat: -33.8688
+          lon: 151.2093
+          radius: 10
+  - name: polygon
+    geo_point:
+      format: geojson
+      polygons:
+        - [[0, 0], [10, 0], [0, 10]]
+  - name: wkt
+    geo_point:
+      format: wkt
+      bounding_boxes:
+        - {min_lat: -1, max_lat: 1, min_lon: -1, max_lon: 1}
+`)
+
+	cfg, err := config.LoadConfigFromYaml(configYaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the template is auto-generated, so that the wrap of the values depends on the format
+	state := newGenState(rand.Int63(), time.Now())
+	template, _ := generateTextTemplateFromField(cfg, fields, false, state)
+	t.Logf("with template: %s", string(template))
+
+	g := makeGeneratorWithTextTemplate(t, cfg, fields, template, 0)
+
+	wktRegex := regexp.MustCompile(`^POINT \((-?[0-9.]+) (-?[0-9.]+)\)$`)
+	for i := 0; i < 100; i++ {
+		var buf bytes.Buffer
+		if err := g.Emit(&buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[any](t, buf.Bytes())
+
+		var lat, lon float64
+		if _, err := fmt.Sscanf(m["box"].(string), "%f,%f", &lat, &lon); err != nil || lat < 40 || lat > 41 || lon < -75 || lon > -73 {
+			t.Errorf("Expected box point in the bounding box, got %v", m["box"])
+		}
+
+		city := m["city"].(map[string]any)
+		lat, lon = city["lat"].(float64), city["lon"].(float64)
+		inParis := math.Abs(lat-48.8566) < 0.1 && math.Abs(lon-2.3522) < 0.15
+		inSydney := math.Abs(lat+33.8688) < 0.1 && math.Abs(lon-151.2093) < 0.15
+		if !inParis && !inSydney {
+			t.Errorf("Expected city point close to Paris or Sydney, got %v", city)
+		}
+
+		polygon := m["polygon"].(map[string]any)
+		coordinates := polygon["coordinates"].([]any)
+		lon, lat = coordinates[0].(float64), coordinates[1].(float64)
+		if polygon["type"] != "Point" || lat < 0 || lon < 0 || lat+lon > 10 {
+			t.Errorf("Expected polygon point in the polygon, got %v", polygon)
+		}
+
+		if matches := wktRegex.FindStringSubmatch(m["wkt"].(string)); matches == nil {
+			t.Errorf("Expected wkt point, got %v", m["wkt"])
+		}
+	}
+}
+
+func Test_FieldTextWithTextTemplate(t *testing.T) {
+	fields := []Field{
+		{Name: "message", Type: FieldTypeMatchOnlyText},
+		{Name: "lorem", Type: FieldTypeText},
+		{Name: "log", Type: FieldTypeWildcard},
+		{Name: "short", Type: FieldTypeText},
+		{Name: "long", Type: FieldTypeText},
+	}
+
+	template := []byte(`{"message":"{{generate "message"}}","lorem":"{{generate "lorem"}}","log":"{{generate "log"}}","short":"{{generate "short"}}","long":"{{generate "long"}}"}`)
+	configYaml := []byte(`fields:
+  - name: lorem
+    text:
+      style: lorem
+      min_words: 3
+      max_words: 3
+      min_sentences: 2
+      max_sentences: 2
+  - name: log
+    text:
+      style: log
+  - name: short
+    text:
+      max_length: 20
+  - name: long
+    text:
+      style: words
+      min_length: 200
+`)
+	t.Logf("with template: %s", string(template))
+
+	cfg, err := config.LoadConfigFromYaml(configYaml)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := makeGeneratorWithTextTemplate(t, cfg, fields, template, 0)
+
+	logRegex := regexp.MustCompile(`^(DEBUG|INFO|WARN|ERROR) \[\S+\.\S+\] .+ \S+=\d+$`)
+	for i := 0; i < 100; i++ {
+		var buf bytes.Buffer
+		if err := g.Emit(&buf); err != nil {
+			t.Fatal(err)
+		}
+
+		m := unmarshalJSONT[string](t, buf.Bytes())
+
+		words := len(strings.Fields(m["message"]))
+		if words < 5 || words > 15 || !strings.HasSuffix(m["message"], ".") {
+			t.Errorf("Expected a sentence of 5 to 15 words, got %s", m["message"])
+		}
+
+		if words := len(strings.Fields(m["lorem"])); words != 6 {
+			t.Errorf("Expected two sentences of 3 words, got %s", m["lorem"])
+		}
+
+		if !logRegex.MatchString(m["log"]) {
+			t.Errorf("Expected a log line, got %s", m["log"])
+		}
+
+		if len(m["short"]) > 20 {
+			t.Errorf("Expected at most 20 characters, got %s", m["short"])
+		}
+
+		if len(m["long"]) < 200 {
+			t.Errorf("Expected at least 200 characters, got %s", m["long"])
+		}
 	}
 }
 
-func Test_FieldStaticOverrideNumericWithTextTemplate(t *testing.T) {
-	fld := Field{
-
-		Name: "alpha",
-		Type: FieldTypeKeyword,
+func Test_FieldVersionWithTextTemplate(t *testing.T) {
+	fields := []Field{
+		{Name: "agent.version", Type: FieldTypeVersion},
+		{Name: "package.version", Type: FieldTypeVersion},
+		{Name: "service.version", Type: FieldTypeVersion},
 	}
 
-	yaml := []byte("fields:\n  - name: alpha\n    value: 33")
-	template := []byte(`{"alpha":{{generate "alpha"}}}`)
+	template := []byte(`{"agent.version":"{{generate "agent.version"}}","package.version":"{{generate "package.version"}}","service.version":"{{generate "service.version"}}"}`)
+	configYaml := []byte(`fields:
+  - name: agent.version
+    cardinality: 5
+    version:
+      major: {min: 8, max: 8}
+      minor: {max: 17}
+      prerelease_probability: 0.5
+  - name: package.version
+    enum: ["1.0.0", "1.1.0"]
+  - name: service.version
+    version:
+      major: {min: 2}
+`)
 	t.Logf("with template: %s", string(template))
-	b := testSingleTWithTextTemplate[float64](t, fld, yaml, template)
 
-	if b != 33.0 {
-		t.Errorf("static value not match")
+	cfg, err := config.LoadConfigFromYaml(configYaml)
+	if err != nil {
+		t.Fatal(err)
 	}
-}
 
-func Test_FieldStaticOverrideBoolWithTextTemplate(t *testing.T) {
-	fld := Field{
-		Name: "alpha",
-		Type: FieldTypeKeyword,
-	}
+	nSpins := 1000
+	g := makeGeneratorWithTextTemplate(t, cfg, fields, template, uint64(nSpins))
 
-	yaml := []byte("fields:\n  - name: alpha\n    value: true")
-	template := []byte(`{"alpha":{{generate "alpha"}}}`)
-	t.Logf("with template: %s", string(template))
-	b := testSingleTWithTextTemplate[bool](t, fld, yaml, template)
+	agentRegex := regexp.MustCompile(`^8\.(\d|1[0-7])\.\d+(-(alpha|beta|rc)\.[1-5]|-SNAPSHOT)?$`)
+	serviceRegex := regexp.MustCompile(`^[2-9]\.\d+\.\d+$`)
+	agentVersions := make(map[string]struct{})
+	for i := 0; i < nSpins; i++ {
+		var buf bytes.Buffer
+		if err := g.Emit(&buf); err != nil {
+			t.Fatal(err)
+		}
 
-	if b != true {
-		t.Errorf("static value not match")
+		m := unmarshalJSONT[string](t, buf.Bytes())
+
+		if !agentRegex.MatchString(m["agent.version"]) {
+			t.Errorf("Expected agent.version to be a 8.x version, got %s", m["agent.version"])
+		}
+
+		agentVersions[m["agent.version"]] = struct{}{}
+
+		if m["package.version"] != "1.0.0" && m["package.version"] != "1.1.0" {
+			t.Errorf("Expected package.version to be in the enum, got %s", m["package.version"])
+		}
+
+		if !serviceRegex.MatchString(m["service.version"]) {
+			t.Errorf("Expected service.version to have a major version of at least 2, got %s", m["service.version"])
+		}
+	}
+
+	if len(agentVersions) != 5 {
+		t.Errorf("Expected 5 different agent.version values, got %d", len(agentVersions))
 	}
 }
 
-func Test_FieldGeoPointWithTextTemplate(t *testing.T) {
-	fld := Field{
-		Name: "alpha",
-		Type: FieldTypeGeoPoint,
+func Test_FieldUnsignedLongWithTextTemplate(t *testing.T) {
+	fields := []Field{
+		{Name: "bytes", Type: FieldTypeUnsignedLong},
+		{Name: "ranged", Type: FieldTypeUnsignedLong},
+		{Name: "fuzzy", Type: FieldTypeUnsignedLong},
+		{Name: "packets", Type: FieldTypeUnsignedLong},
 	}
 
-	template := []byte(`{"alpha":"{{generate "alpha"}}"}`)
+	template := []byte(`{"bytes":{{generate "bytes"}},"ranged":{{generate "ranged"}},"fuzzy":{{generate "fuzzy"}},"packets":{{generate "packets"}}}`)
+	configYaml := []byte(`fields:
+  - name: bytes
+    counter: true
+    fuzziness: 0.5
+  - name: ranged
+    range:
+      min: 10000000000000000000
+      max: 12000000000000000000
+  - name: fuzzy
+    fuzziness: 0.1
+    range:
+      min: 10000000000000000000
+  - name: packets
+    counter: true
+    counter_reset:
+      strategy: after_n
+      reset_after_n: 10
+`)
 	t.Logf("with template: %s", string(template))
-	nSpins := 1024
-	for i := 0; i < nSpins; i++ {
 
-		b := testSingleTWithTextTemplate[string](t, fld, nil, template)
+	cfg, err := config.LoadConfigFromYaml(configYaml)
+	if err != nil {
+		t.Fatal(err)
+	}
 
-		// Expect geo point in form of lat,long
-		// where lat is [-90.0..90.0]
-		// and long is  [-180.0..180.0]
+	nSpins := 2000
+	g := makeGeneratorWithTextTemplate(t, cfg, fields, template, uint64(nSpins))
 
-		s := strings.Split(b, ",")
-		if len(s) != 2 {
-			t.Fatal("expected comma separated lat,long")
+	var overflowed bool
+	var previousBytes uint64
+	for i := 0; i < nSpins; i++ {
+		var buf bytes.Buffer
+		if err := g.Emit(&buf); err != nil {
+			t.Fatal(err)
 		}
 
-		lat := s[0]
-		long := s[1]
+		m := unmarshalJSONT[uint64](t, buf.Bytes())
 
-		// no whitespace please
-		if len(lat) != len(strings.TrimSpace(lat)) {
-			t.Errorf("extra whitespace on latitude %s", lat)
+		if m["bytes"] > math.MaxInt64 {
+			overflowed = true
 		}
 
-		// no whitespace please
-		if len(long) != len(strings.TrimSpace(long)) {
-			t.Errorf("extra whitespace on longitude %s", long)
+		// the counter wraps around to zero only past math.MaxUint64
+		if m["bytes"] < previousBytes && previousBytes < math.MaxUint64/2 {
+			t.Errorf("Expected bytes counter to increase, got %d after %d", m["bytes"], previousBytes)
 		}
 
-		latF, err := strconv.ParseFloat(lat, 64)
-		if err != nil {
-			t.Errorf("Fail parse latitude as float")
-		}
-		longF, err := strconv.ParseFloat(long, 64)
-		if err != nil {
-			t.Errorf("Fail parse longitude as float")
-		}
+		previousBytes = m["bytes"]
 
-		if latF < -90.0 || latF > 90.0 {
-			t.Errorf("latitude out of range %v", latF)
+		if m["ranged"] < 10000000000000000000 || m["ranged"] > 12000000000000000000 {
+			t.Errorf("Expected ranged to be in range, got %d", m["ranged"])
 		}
 
-		if longF < -180.0 || longF > 180.0 {
-			t.Errorf("longitutde out of range %v", longF)
+		if m["fuzzy"] < 10000000000000000000 {
+			t.Errorf("Expected fuzzy to be greater than the range min, got %d", m["fuzzy"])
 		}
-	}
-}
 
-func Test_FieldDateWithTextTemplate(t *testing.T) {
-	fld := Field{
-		Name: "alpha",
-		Type: FieldTypeDate,
+		if i%10 == 0 && m["packets"] != 0 {
+			t.Errorf("Expected packets counter to reset to 0, got %d", m["packets"])
+		}
 	}
 
-	template := []byte(`{{$alpha := generate "alpha"}}{"alpha":"{{$alpha.Format "2006-01-02T15:04:05.999999999-07:00"}}"}`)
-	t.Logf("with template: %s", string(template))
-	nSpins := rand.Intn(1024) + 1
-	for i := 0; i < nSpins; i++ {
-		startTime := time.Now().Truncate(time.Microsecond)
-		b := testSingleTWithTextTemplate[string](t, fld, nil, template, WithStartTime(startTime))
-
-		if ts, err := time.Parse(FieldTypeTimeLayout, b); err != nil {
-			t.Errorf("Fail parse timestamp %v", err)
-		} else {
-			// Timestamp should be from now within a FieldTypeDurationSpan milliseconds of slop
-			diff := ts.Sub(startTime)
-			if diff < 0 || diff > FieldTypeDurationSpan*time.Millisecond {
-				t.Errorf("Data generated before now, diff: %v", diff)
-			}
-		}
+	if !overflowed {
+		t.Errorf("Expected bytes counter to grow past %d", int64(math.MaxInt64))
 	}
 }
 
-func Test_FieldDateAndPeriodPositiveWithTextTemplate(t *testing.T) {
-	fld := Field{
-		Name: "alpha",
-		Type: FieldTypeDate,
+func Test_FieldHistogramAndAggregateMetricDoubleWithTextTemplate(t *testing.T) {
+	fields := []Field{
+		{Name: "latency", Type: FieldTypeHistogram},
+		{Name: "cpu", Type: FieldTypeAggregateMetricDouble},
 	}
 
-	template := []byte(`{{$alpha := generate "alpha"}}{"alpha":"{{$alpha.Format "2006-01-02T15:04:05.999999999-07:00"}}"}`)
-	configYaml := []byte("fields:\n  - name: alpha\n    period: 10s")
+	template := []byte(`{"latency":{{generate "latency"}},"cpu":{{generate "cpu"}}}`)
+	configYaml := []byte(`fields:
+  - name: latency
+    buckets: 5
+    samples:
+      min: 5
+      max: 50
+    distribution:
+      type: normal
+      mean: 100
+      stddev: 10
+    range:
+      min: 0
+  - name: cpu
+    cardinality: 3
+    range:
+      min: 0
+      max: 1
+`)
 	t.Logf("with template: %s", string(template))
 
 	cfg, err := config.LoadConfigFromYaml(configYaml)
@@ -359,52 +1899,105 @@ func Test_FieldDateAndPeriodPositiveWithTextTemplate(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	startTime := time.Now().Truncate(time.Microsecond)
-	g := makeGeneratorWithTextTemplate(t, cfg, []Field{fld}, template, 10, WithStartTime(startTime))
-
-	var buf bytes.Buffer
+	nSpins := 100
+	g := makeGeneratorWithTextTemplate(t, cfg, fields, template, uint64(nSpins))
+
+	type event struct {
+		Latency struct {
+			Values []float64 `json:"values"`
+			Counts []int64   `json:"counts"`
+		} `json:"latency"`
+		CPU struct {
+			Min        float64 `json:"min"`
+			Max        float64 `json:"max"`
+			Sum        float64 `json:"sum"`
+			ValueCount int64   `json:"value_count"`
+		} `json:"cpu"`
+	}
 
-	nSpins := 10
+	cpuValues := make(map[float64]struct{})
 	for i := 0; i < nSpins; i++ {
+		var buf bytes.Buffer
 		if err := g.Emit(&buf); err != nil {
 			t.Fatal(err)
 		}
 
-		m := unmarshalJSONT[string](t, buf.Bytes())
-		buf.Reset()
+		var e event
+		if err := json.Unmarshal(buf.Bytes(), &e); err != nil {
+			t.Fatal(err)
+		}
 
-		if len(m) != 1 {
-			t.Errorf("Expected map size 1, got %d", len(m))
+		if len(e.Latency.Values) == 0 || len(e.Latency.Values) > 5 || len(e.Latency.Values) != len(e.Latency.Counts) {
+			t.Errorf("Expected up to 5 buckets with a count each, got %v", e.Latency)
 		}
 
-		v, ok := m[fld.Name]
+		var totCount int64
+		for j, count := range e.Latency.Counts {
+			if j > 0 && e.Latency.Values[j] <= e.Latency.Values[j-1] {
+				t.Errorf("Expected histogram values to be strictly increasing, got %v", e.Latency.Values)
+			}
 
-		if !ok {
-			t.Errorf("Missing key %v", fld.Name)
+			if count <= 0 {
+				t.Errorf("Expected histogram counts to be greater than zero, got %v", e.Latency.Counts)
+			}
+
+			totCount += count
 		}
 
-		if ts, err := time.Parse(FieldTypeTimeLayout, v); err != nil {
-			t.Errorf("Fail parse timestamp %v", err)
-		} else {
-			// Timestamp should be +1s for every iteration
-			expectedTime := startTime.Add(time.Second * time.Duration(i))
+		if totCount < 5 || totCount > 50 {
+			t.Errorf("Expected between 5 and 50 samples, got %d", totCount)
+		}
 
-			diff := expectedTime.Sub(ts)
-			if diff != 0 {
-				t.Errorf("Date generated out of period range %v", diff)
-			}
+		if e.CPU.Min < 0 || e.CPU.Max > 1 || e.CPU.Min > e.CPU.Max {
+			t.Errorf("Expected cpu min and max to be in range, got %v", e.CPU)
 		}
+
+		if e.CPU.ValueCount < 1 || e.CPU.ValueCount > 100 {
+			t.Errorf("Expected cpu value_count to be between 1 and 100, got %d", e.CPU.ValueCount)
+		}
+
+		if e.CPU.Sum < e.CPU.Min*float64(e.CPU.ValueCount)-1e-9 || e.CPU.Sum > e.CPU.Max*float64(e.CPU.ValueCount)+1e-9 {
+			t.Errorf("Expected cpu sum to be consistent with min, max and value_count, got %v", e.CPU)
+		}
+
+		cpuValues[e.CPU.Sum] = struct{}{}
+	}
+
+	if len(cpuValues) != 3 {
+		t.Errorf("Expected 3 different cpu values, got %d", len(cpuValues))
 	}
 }
 
-func Test_FieldDateAndPeriodNegativeWithTextTemplate(t *testing.T) {
-	fld := Field{
-		Name: "alpha",
-		Type: FieldTypeDate,
+func Test_FieldVectorWithTextTemplate(t *testing.T) {
+	fields := []Field{
+		{Name: "embedding", Type: FieldTypeDenseVector},
+		{Name: "quantized", Type: FieldTypeDenseVector},
+		{Name: "binary", Type: FieldTypeDenseVector},
+		{Name: "tokens", Type: FieldTypeSparseVector},
 	}
 
-	template := []byte(`{{$alpha := generate "alpha"}}{"alpha":"{{$alpha.Format "2006-01-02T15:04:05.999999999-07:00"}}"}`)
-	configYaml := []byte("fields:\n  - name: alpha\n    period: -10s")
+	template := []byte(`{"embedding":{{generate "embedding"}},"quantized":{{generate "quantized"}},"binary":{{generate "binary"}},"tokens":{{generate "tokens"}}}`)
+	configYaml := []byte(`fields:
+  - name: embedding
+    vector:
+      dims: 8
+      normalize: true
+      centroids: 2
+      spread: 0.01
+  - name: quantized
+    vector:
+      dims: 16
+      element_type: byte
+  - name: binary
+    vector:
+      dims: 32
+      element_type: bit
+  - name: tokens
+    vector:
+      vocabulary_size: 100
+      min_tokens: 5
+      max_tokens: 10
+`)
 	t.Logf("with template: %s", string(template))
 
 	cfg, err := config.LoadConfigFromYaml(configYaml)
@@ -412,54 +2005,104 @@ func Test_FieldDateAndPeriodNegativeWithTextTemplate(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	startTime := time.Now().Truncate(time.Microsecond)
-	g := makeGeneratorWithTextTemplate(t, cfg, []Field{fld}, template, 10, WithStartTime(startTime))
+	nSpins := 100
+	g := makeGeneratorWithTextTemplate(t, cfg, fields, template, uint64(nSpins))
 
-	var buf bytes.Buffer
+	type event struct {
+		Embedding []float64          `json:"embedding"`
+		Quantized []int8             `json:"quantized"`
+		Binary    []int8             `json:"binary"`
+		Tokens    map[string]float64 `json:"tokens"`
+	}
 
-	nSpins := 10
+	var clusters [][]float64
 	for i := 0; i < nSpins; i++ {
+		var buf bytes.Buffer
 		if err := g.Emit(&buf); err != nil {
 			t.Fatal(err)
 		}
 
-		m := unmarshalJSONT[string](t, buf.Bytes())
-		buf.Reset()
+		var e event
+		if err := json.Unmarshal(buf.Bytes(), &e); err != nil {
+			t.Fatal(err)
+		}
 
-		if len(m) != 1 {
-			t.Errorf("Expected map size 1, got %d", len(m))
+		if len(e.Embedding) != 8 {
+			t.Fatalf("Expected embedding to have 8 dims, got %v", e.Embedding)
 		}
 
-		v, ok := m[fld.Name]
+		var norm float64
+		for _, component := range e.Embedding {
+			norm += component * component
+		}
 
-		if !ok {
-			t.Errorf("Missing key %v", fld.Name)
+		if math.Abs(math.Sqrt(norm)-1) > 1e-4 {
+			t.Errorf("Expected embedding to be normalised, got norm %f", math.Sqrt(norm))
 		}
 
-		if ts, err := time.Parse(FieldTypeTimeLayout, v); err != nil {
-			t.Errorf("Fail parse timestamp %v", err)
-		} else {
-			// Timestamp should be +1s for every iteration
-			expectedTime := startTime.Add(-10*time.Second + time.Second*time.Duration(i))
+		// vectors generated around the same centroid point in the same direction
+		inCluster := false
+		for _, cluster := range clusters {
+			var dot float64
+			for j := range cluster {
+				dot += cluster[j] * e.Embedding[j]
+			}
 
-			diff := expectedTime.Sub(ts)
-			if diff != 0 {
-				t.Errorf("Date generated out of period range %v", diff)
+			inCluster = inCluster || dot > 0.95
+		}
+
+		if !inCluster {
+			clusters = append(clusters, e.Embedding)
+		}
+
+		if len(e.Quantized) != 16 {
+			t.Errorf("Expected quantized to have 16 dims, got %v", e.Quantized)
+		}
+
+		if len(e.Binary) != 4 {
+			t.Errorf("Expected binary to have 4 bytes, got %v", e.Binary)
+		}
+
+		if len(e.Tokens) < 5 || len(e.Tokens) > 10 {
+			t.Errorf("Expected between 5 and 10 tokens, got %v", e.Tokens)
+		}
+
+		for token, weight := range e.Tokens {
+			if weight <= 0 {
+				t.Errorf("Expected token %s to have a positive weight, got %f", token, weight)
 			}
 		}
 	}
+
+	if len(clusters) > 2 {
+		t.Errorf("Expected embeddings around 2 centroids, got %d clusters", len(clusters))
+	}
 }
 
-func Test_FieldDateAndRangeFromInThePastWithTextTemplate(t *testing.T) {
-	fld := Field{
-		Name: "alpha",
-		Type: FieldTypeDate,
+func Test_FieldRangeWithTextTemplate(t *testing.T) {
+	fields := []Field{
+		{Name: "ports", Type: FieldTypeIntegerRange},
+		{Name: "ratio", Type: FieldTypeDoubleRange},
+		{Name: "window", Type: FieldTypeDateRange},
+		{Name: "network", Type: FieldTypeIPRange},
 	}
 
-	startTime := time.Now().Truncate(time.Microsecond)
-	from := startTime.Add(-10 * time.Second)
-	template := []byte(`{{$alpha := generate "alpha"}}{"alpha":"{{$alpha.Format "2006-01-02T15:04:05.999999999-07:00"}}"}`)
-	configYaml := []byte(fmt.Sprintf("fields:\n  - name: alpha\n    range:\n      from: %s", from.Format("2006-01-02T15:04:05.999999999-07:00")))
+	template := []byte(`{"ports":{{generate "ports"}},"ratio":{{generate "ratio"}},"window":{{generate "window"}},"network":{{generate "network"}}}`)
+	configYaml := []byte(`fields:
+  - name: ports
+    range:
+      min: 1024
+      max: 2048
+  - name: ratio
+    range:
+      min: 0
+      max: 1
+  - name: window
+    period: 24h
+  - name: network
+    ip:
+      cidrs: ["10.0.0.0/8", "2001:db8::/32"]
+`)
 	t.Logf("with template: %s", string(template))
 
 	cfg, err := config.LoadConfigFromYaml(configYaml)
@@ -467,406 +2110,545 @@ func Test_FieldDateAndRangeFromInThePastWithTextTemplate(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	g := makeGeneratorWithTextTemplate(t, cfg, []Field{fld}, template, 10, WithStartTime(startTime))
-
-	var buf bytes.Buffer
-
-	period := startTime.UTC().Sub(from.UTC())
+	nSpins := 100
+	now := time.Now()
+	g := makeGeneratorWithTextTemplate(t, cfg, fields, template, uint64(nSpins))
+
+	type event struct {
+		Ports struct {
+			Gte int64 `json:"gte"`
+			Lte int64 `json:"lte"`
+		} `json:"ports"`
+		Ratio struct {
+			Gte float64 `json:"gte"`
+			Lte float64 `json:"lte"`
+		} `json:"ratio"`
+		Window struct {
+			Gte time.Time `json:"gte"`
+			Lte time.Time `json:"lte"`
+		} `json:"window"`
+		Network struct {
+			Gte string `json:"gte"`
+			Lte string `json:"lte"`
+		} `json:"network"`
+	}
 
-	nSpins := int64(10)
-	for i := int64(0); i < nSpins; i++ {
+	var previousWindow time.Time
+	for i := 0; i < nSpins; i++ {
+		var buf bytes.Buffer
 		if err := g.Emit(&buf); err != nil {
 			t.Fatal(err)
 		}
 
-		m := unmarshalJSONT[string](t, buf.Bytes())
-		buf.Reset()
+		var e event
+		if err := json.Unmarshal(buf.Bytes(), &e); err != nil {
+			t.Fatal(err)
+		}
 
-		if len(m) != 1 {
-			t.Errorf("Expected map size 1, got %d", len(m))
+		if e.Ports.Gte < 1024 || e.Ports.Lte > 2048 || e.Ports.Gte > e.Ports.Lte {
+			t.Errorf("Expected ordered ports range between 1024 and 2048, got %v", e.Ports)
 		}
 
-		v, ok := m[fld.Name]
+		if e.Ratio.Gte < 0 || e.Ratio.Lte > 1 || e.Ratio.Gte > e.Ratio.Lte {
+			t.Errorf("Expected ordered ratio range between 0 and 1, got %v", e.Ratio)
+		}
 
-		if !ok {
-			t.Errorf("Missing key %v", fld.Name)
+		if e.Window.Gte.After(e.Window.Lte) || e.Window.Lte.After(now.Add(24*time.Hour+time.Second)) {
+			t.Errorf("Expected ordered window range within the period, got %v", e.Window)
 		}
 
-		if ts, err := time.Parse(FieldTypeTimeLayout, v); err != nil {
-			t.Errorf("Fail parse timestamp %v", err)
-		} else {
-			// Timestamp should be +(period.Nanoseconds() / nSpins) * i) for every iteration
-			expectedTime := startTime.Add(time.Duration((period.Nanoseconds() / nSpins) * i))
+		if e.Window.Gte.Before(previousWindow) {
+			t.Errorf("Expected window ranges to be progressive, got %v after %v", e.Window.Gte, previousWindow)
+		}
 
-			diff := expectedTime.Sub(ts)
-			if diff != 0 {
-				t.Errorf("Date generated out of period range %v", diff)
-			}
+		previousWindow = e.Window.Gte
+
+		gte, lte := netip.MustParseAddr(e.Network.Gte), netip.MustParseAddr(e.Network.Lte)
+		if gte.Is4() != lte.Is4() || lte.Less(gte) {
+			t.Errorf("Expected ordered network range of the same ip family, got %v", e.Network)
+		}
+
+		if !netip.MustParsePrefix("10.0.0.0/8").Contains(gte) && !netip.MustParsePrefix("2001:db8::/32").Contains(gte) {
+			t.Errorf("Expected network range in the configured cidrs, got %v", e.Network)
 		}
 	}
 }
 
-func Test_FieldDateAndRangeFromInTheFutureWithTextTemplate(t *testing.T) {
-	fld := Field{
-		Name: "alpha",
-		Type: FieldTypeDate,
+func Test_FieldArrayWithTextTemplate(t *testing.T) {
+	fields := []Field{
+		{Name: "tags", Type: FieldTypeKeyword},
+		{Name: "related.ip", Type: FieldTypeIP},
+		{Name: "event.created", Type: FieldTypeDate},
+		{Name: "event.category", Type: FieldTypeKeyword},
 	}
 
-	startTime := time.Now().Truncate(time.Microsecond)
-	from := startTime.Add(10 * time.Second)
-	template := []byte(`{{$alpha := generate "alpha"}}{"alpha":"{{$alpha.Format "2006-01-02T15:04:05.999999999-07:00"}}"}`)
-	configYaml := []byte(fmt.Sprintf("fields:\n  - name: alpha\n    range:\n      from: %s", from.Format("2006-01-02T15:04:05.999999999-07:00")))
-	t.Logf("with template: %s", string(template))
+	configYaml := []byte(`fields:
+  - name: tags
+    enum: ["alpha", "beta", "gamma"]
+    array:
+      min_length: 3
+      max_length: 3
+      unique: true
+  - name: related.ip
+    array:
+      min_length: 1
+      max_length: 4
+  - name: event.created
+    array:
+      min_length: 2
+  - name: event.category
+    enum: ["network"]
+    array:
+      min_length: 0
+      max_length: 2
+`)
 
 	cfg, err := config.LoadConfigFromYaml(configYaml)
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	g := makeGeneratorWithTextTemplate(t, cfg, []Field{fld}, template, 10, WithStartTime(startTime))
+	// the template is auto-generated, so that the values are not wrapped
+	state := newGenState(rand.Int63(), time.Now())
+	template, _ := generateTextTemplateFromField(cfg, fields, false, state)
+	t.Logf("with template: %s", string(template))
 
-	var buf bytes.Buffer
+	g := makeGeneratorWithTextTemplate(t, cfg, fields, template, 0)
 
-	period := from.UTC().Sub(startTime.UTC())
+	type event struct {
+		Tags          []string    `json:"tags"`
+		RelatedIP     []string    `json:"related.ip"`
+		EventCreated  []time.Time `json:"event.created"`
+		EventCategory []string    `json:"event.category"`
+	}
 
-	nSpins := int64(10)
-	for i := int64(0); i < nSpins; i++ {
+	for i := 0; i < 100; i++ {
+		var buf bytes.Buffer
 		if err := g.Emit(&buf); err != nil {
 			t.Fatal(err)
 		}
 
-		m := unmarshalJSONT[string](t, buf.Bytes())
-		buf.Reset()
+		var e event
+		if err := json.Unmarshal(buf.Bytes(), &e); err != nil {
+			t.Fatal(err)
+		}
+
+		// unique arrays can be shorter than their min length, when not enough different values are generated
+		if len(e.Tags) == 0 || len(e.Tags) > 3 {
+			t.Errorf("Expected between 1 and 3 tags, got %v", e.Tags)
+		}
+
+		tags := make(map[string]struct{})
+		for _, tag := range e.Tags {
+			if _, ok := tags[tag]; ok {
+				t.Errorf("Expected tags to be unique, got %v", e.Tags)
+			}
+
+			if tag != "alpha" && tag != "beta" && tag != "gamma" {
+				t.Errorf("Expected tags to be enum values, got %v", e.Tags)
+			}
 
-		if len(m) != 1 {
-			t.Errorf("Expected map size 1, got %d", len(m))
+			tags[tag] = struct{}{}
 		}
 
-		v, ok := m[fld.Name]
+		if len(e.RelatedIP) < 1 || len(e.RelatedIP) > 4 {
+			t.Errorf("Expected between 1 and 4 related.ip, got %v", e.RelatedIP)
+		}
 
-		if !ok {
-			t.Errorf("Missing key %v", fld.Name)
+		for _, ip := range e.RelatedIP {
+			if _, err := netip.ParseAddr(ip); err != nil {
+				t.Errorf("Expected related.ip to be ip addresses, got %v", e.RelatedIP)
+			}
 		}
 
-		if ts, err := time.Parse(FieldTypeTimeLayout, v); err != nil {
-			t.Errorf("Fail parse timestamp %v", err)
-		} else {
-			// Timestamp should be +(period.Nanoseconds() / nSpins) * i) for every iteration
-			expectedTime := startTime.Add(time.Duration((period.Nanoseconds() / nSpins) * i))
+		if len(e.EventCreated) != 2 {
+			t.Errorf("Expected 2 event.created, got %v", e.EventCreated)
+		}
 
-			diff := expectedTime.Sub(ts)
-			if diff != 0 {
-				t.Errorf("Date generated out of period range %v", diff)
-			}
+		if len(e.EventCategory) > 2 || (len(e.EventCategory) > 0 && e.EventCategory[0] != "network") {
+			t.Errorf("Expected up to 2 event.category, got %v", e.EventCategory)
 		}
 	}
 }
 
-func Test_FieldDateAndRangeToInThePastWithTextTemplate(t *testing.T) {
-	fld := Field{
-		Name: "alpha",
-		Type: FieldTypeDate,
+func Test_NestedObjectsWithTextTemplate(t *testing.T) {
+	fields := Fields{
+		{Name: "host.name", Type: FieldTypeKeyword},
+		{Name: "host.ip", Type: FieldTypeIP},
+		{Name: "event.created", Type: FieldTypeDate},
+		{Name: "event.duration", Type: FieldTypeLong},
+		{Name: "labels.*", Type: FieldTypeKeyword},
+		{Name: "process", Type: FieldTypeObject, ObjectType: FieldTypeKeyword},
+		{Name: "http.request", Type: FieldTypeKeyword},
+		{Name: "http.request.method", Type: FieldTypeKeyword},
+		{Name: "message", Type: FieldTypeKeyword},
 	}
 
-	startTime := time.Now().Truncate(time.Microsecond)
-	to := startTime.Add(-10 * time.Second)
-	template := []byte(`{{$alpha := generate "alpha"}}{"alpha":"{{$alpha.Format "2006-01-02T15:04:05.999999999-07:00"}}"}`)
-	configYaml := []byte(fmt.Sprintf("fields:\n  - name: alpha\n    range:\n      to: %s", to.Format("2006-01-02T15:04:05.999999999-07:00")))
-	t.Logf("with template: %s", string(template))
+	configYaml := []byte(`fields:
+  - name: event.duration
+    missing_probability: 0.5
+  - name: process
+    object_keys: ["name", "title"]
+  - name: http.request.method
+    enum: ["GET"]
+`)
 
 	cfg, err := config.LoadConfigFromYaml(configYaml)
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	g := makeGeneratorWithTextTemplate(t, cfg, []Field{fld}, template, 10, WithStartTime(startTime))
-
-	var buf bytes.Buffer
+	state := newGenState(rand.Int63(), time.Now())
+	template, objectKeysField := generateTextTemplateFromField(cfg, fields, true, state)
+	t.Logf("with template: %s", string(template))
 
-	period := startTime.UTC().Sub(to.UTC())
+	fields = append(fields, objectKeysField...)
+	g := makeGeneratorWithTextTemplate(t, cfg, fields, template, 0)
 
-	nSpins := int64(10)
-	for i := int64(0); i < nSpins; i++ {
+	for i := 0; i < 100; i++ {
+		var buf bytes.Buffer
 		if err := g.Emit(&buf); err != nil {
 			t.Fatal(err)
 		}
 
-		m := unmarshalJSONT[string](t, buf.Bytes())
-		buf.Reset()
+		var e map[string]any
+		if err := json.Unmarshal(buf.Bytes(), &e); err != nil {
+			t.Fatalf("Expected valid JSON, got %s: %v", buf.String(), err)
+		}
 
-		if len(m) != 1 {
-			t.Errorf("Expected map size 1, got %d", len(m))
+		host, ok := e["host"].(map[string]any)
+		if !ok {
+			t.Fatalf("Expected host to be an object, got %s", buf.String())
 		}
 
-		v, ok := m[fld.Name]
+		if _, ok := host["name"].(string); !ok {
+			t.Errorf("Expected host.name to be nested, got %s", buf.String())
+		}
 
+		if _, err := netip.ParseAddr(host["ip"].(string)); err != nil {
+			t.Errorf("Expected host.ip to be nested, got %s", buf.String())
+		}
+
+		event, ok := e["event"].(map[string]any)
 		if !ok {
-			t.Errorf("Missing key %v", fld.Name)
+			t.Fatalf("Expected event to be an object, got %s", buf.String())
 		}
 
-		if ts, err := time.Parse(FieldTypeTimeLayout, v); err != nil {
-			t.Errorf("Fail parse timestamp %v", err)
-		} else {
-			// Timestamp should be +(period.Nanoseconds() / nSpins) * i) for every iteration
-			expectedTime := startTime.Add(time.Duration((period.Nanoseconds() / nSpins) * i))
+		if _, err := time.Parse(time.RFC3339Nano, event["created"].(string)); err != nil {
+			t.Errorf("Expected event.created to be nested, got %s", buf.String())
+		}
 
-			diff := expectedTime.Sub(ts)
-			if diff != 0 {
-				t.Errorf("Date generated out of period range %v", diff)
+		if duration, ok := event["duration"]; ok {
+			if _, ok := duration.(float64); !ok {
+				t.Errorf("Expected event.duration to be nested, got %s", buf.String())
 			}
 		}
+
+		if labels, ok := e["labels"]; ok {
+			if _, ok := labels.(map[string]any); !ok {
+				t.Errorf("Expected labels to be an object, got %s", buf.String())
+			}
+		}
+
+		process, ok := e["process"].(map[string]any)
+		if !ok || len(process) != 2 || process["name"] == nil || process["title"] == nil {
+			t.Errorf("Expected process to be an object with the object keys, got %s", buf.String())
+		}
+
+		// http.request is a field, so the method cannot be nested in it
+		http, ok := e["http"].(map[string]any)
+		if !ok || http["request.method"] != "GET" {
+			t.Errorf("Expected http.request.method to be a dotted key in http, got %s", buf.String())
+		}
+
+		if _, ok := e["message"].(string); !ok {
+			t.Errorf("Expected message at the root, got %s", buf.String())
+		}
 	}
 }
 
-func Test_FieldDateAndRangeToInTheFutureWithTextTemplate(t *testing.T) {
-	fld := Field{
-		Name: "alpha",
-		Type: FieldTypeDate,
+func Test_FieldDateFormatWithTextTemplate(t *testing.T) {
+	fields := Fields{
+		{Name: "epoch_millis", Type: FieldTypeDate},
+		{Name: "epoch_second", Type: FieldTypeDate},
+		{Name: "rfc3164", Type: FieldTypeDate},
+		{Name: "common_log", Type: FieldTypeDate},
+		{Name: "custom", Type: FieldTypeDate},
+		{Name: "nanos", Type: FieldTypeDateNanos},
+		{Name: "nanos_array", Type: FieldTypeDateNanos},
 	}
 
-	startTime := time.Now().Truncate(time.Microsecond)
-	to := startTime.Add(10 * time.Second)
-	template := []byte(`{{$alpha := generate "alpha"}}{"alpha":"{{$alpha.Format "2006-01-02T15:04:05.999999999-07:00"}}"}`)
-	configYaml := []byte(fmt.Sprintf("fields:\n  - name: alpha\n    range:\n      to: %s", to.Format("2006-01-02T15:04:05.999999999-07:00")))
-	t.Logf("with template: %s", string(template))
+	configYaml := []byte(`fields:
+  - name: epoch_millis
+    format: epoch_millis
+  - name: epoch_second
+    format: epoch_second
+  - name: rfc3164
+    format: rfc3164
+  - name: common_log
+    format: common_log
+  - name: custom
+    format: "2006/01/02 15:04"
+  - name: nanos_array
+    format: epoch_second
+    array:
+      min_length: 2
+`)
 
 	cfg, err := config.LoadConfigFromYaml(configYaml)
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	g := makeGeneratorWithTextTemplate(t, cfg, []Field{fld}, template, 10, WithStartTime(startTime))
+	startTime := time.Date(2024, 3, 5, 7, 8, 9, 123456789, time.UTC)
+	state := newGenState(rand.Int63(), time.Now())
+	template, _ := generateTextTemplateFromField(cfg, fields, false, state)
+	t.Logf("with template: %s", string(template))
+
+	g := makeGeneratorWithTextTemplate(t, cfg, fields, template, 0, WithStartTime(startTime))
 
 	var buf bytes.Buffer
+	if err := g.Emit(&buf); err != nil {
+		t.Fatal(err)
+	}
 
-	period := to.UTC().Sub(startTime.UTC())
+	var e struct {
+		EpochMillis int64   `json:"epoch_millis"`
+		EpochSecond int64   `json:"epoch_second"`
+		RFC3164     string  `json:"rfc3164"`
+		CommonLog   string  `json:"common_log"`
+		Custom      string  `json:"custom"`
+		Nanos       string  `json:"nanos"`
+		NanosArray  []int64 `json:"nanos_array"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &e); err != nil {
+		t.Fatalf("Expected valid JSON, got %s: %v", buf.String(), err)
+	}
 
-	nSpins := int64(10)
-	for i := int64(0); i < nSpins; i++ {
-		if err := g.Emit(&buf); err != nil {
-			t.Fatal(err)
-		}
+	// without totEvents and period the dates are at most a second after the start time
+	if e.EpochMillis < startTime.UnixMilli() || e.EpochMillis > startTime.Add(time.Second).UnixMilli() {
+		t.Errorf("Expected epoch_millis near %d, got %d", startTime.UnixMilli(), e.EpochMillis)
+	}
 
-		m := unmarshalJSONT[string](t, buf.Bytes())
-		buf.Reset()
+	if e.EpochSecond < startTime.Unix() || e.EpochSecond > startTime.Add(5*time.Second).Unix() {
+		t.Errorf("Expected epoch_second near %d, got %d", startTime.Unix(), e.EpochSecond)
+	}
 
-		if len(m) != 1 {
-			t.Errorf("Expected map size 1, got %d", len(m))
-		}
+	if !strings.HasPrefix(e.RFC3164, "Mar  5 07:") {
+		t.Errorf("Expected rfc3164 date, got %s", e.RFC3164)
+	}
 
-		v, ok := m[fld.Name]
+	if !strings.HasPrefix(e.CommonLog, "05/Mar/2024:07:") || !strings.HasSuffix(e.CommonLog, " +0000") {
+		t.Errorf("Expected common log date, got %s", e.CommonLog)
+	}
 
-		if !ok {
-			t.Errorf("Missing key %v", fld.Name)
-		}
+	if !strings.HasPrefix(e.Custom, "2024/03/05 07:") || len(e.Custom) != len("2024/03/05 07:08") {
+		t.Errorf("Expected custom layout date, got %s", e.Custom)
+	}
 
-		if ts, err := time.Parse(FieldTypeTimeLayout, v); err != nil {
-			t.Errorf("Fail parse timestamp %v", err)
-		} else {
-			// Timestamp should be +(period.Nanoseconds() / nSpins) * i) for every iteration
-			expectedTime := startTime.Add(time.Duration((period.Nanoseconds() / nSpins) * i))
+	if !regexp.MustCompile(`^2024-03-05T07:\d{2}:\d{2}\.\d{9}Z$`).MatchString(e.Nanos) {
+		t.Errorf("Expected date with nanoseconds, got %s", e.Nanos)
+	}
 
-			diff := expectedTime.Sub(ts)
-			if diff != 0 {
-				t.Errorf("Date generated out of period range %v", diff)
-			}
-		}
+	if len(e.NanosArray) != 2 || e.NanosArray[0] < startTime.Unix() {
+		t.Errorf("Expected array of epoch seconds, got %v", e.NanosArray)
 	}
 }
 
-func Test_FieldDateAndRangeFromAndToPositiveWithTextTemplate(t *testing.T) {
-	fld := Field{
-		Name: "alpha",
-		Type: FieldTypeDate,
+func Test_FieldDateArrivalWithTextTemplate(t *testing.T) {
+	fields := Fields{
+		{Name: "timestamp", Type: FieldTypeDate},
 	}
 
-	startTime := time.Now().Truncate(time.Microsecond)
-	from := startTime.Add(-10 * time.Second)
-	to := startTime.Add(10 * time.Second)
-	template := []byte(`{{$alpha := generate "alpha"}}{"alpha":"{{$alpha.Format "2006-01-02T15:04:05.999999999-07:00"}}"}`)
-	configYaml := []byte(fmt.Sprintf("fields:\n  - name: alpha\n    range:\n      from: %s\n      to: %s", from.Format("2006-01-02T15:04:05.999999999-07:00"), to.Format("2006-01-02T15:04:05.999999999-07:00")))
-	t.Logf("with template: %s", string(template))
+	configYaml := []byte(`fields:
+  - name: timestamp
+    range:
+      from: "2024-03-04T00:00:00+00:00"
+      to: "2024-03-05T00:00:00+00:00"
+    arrival:
+      process: poisson
+`)
 
 	cfg, err := config.LoadConfigFromYaml(configYaml)
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	g := makeGeneratorWithTextTemplate(t, cfg, []Field{fld}, template, 10, WithStartTime(startTime))
-
-	var buf bytes.Buffer
+	from := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
 
-	period := to.UTC().Sub(from.UTC())
+	template := []byte(`{{ $timestamp := generate "timestamp" }}{{ $timestamp.Format "2006-01-02T15:04:05.999999999Z07:00" }}`)
+	g := makeGeneratorWithTextTemplate(t, cfg, fields, template, 1000)
 
-	nSpins := int64(10)
-	for i := int64(0); i < nSpins; i++ {
+	var previous time.Time
+	for i := 0; i < 1000; i++ {
+		var buf bytes.Buffer
 		if err := g.Emit(&buf); err != nil {
 			t.Fatal(err)
 		}
 
-		m := unmarshalJSONT[string](t, buf.Bytes())
-		buf.Reset()
-
-		if len(m) != 1 {
-			t.Errorf("Expected map size 1, got %d", len(m))
+		timestamp, err := time.Parse(time.RFC3339Nano, buf.String())
+		if err != nil {
+			t.Fatal(err)
 		}
 
-		v, ok := m[fld.Name]
+		if timestamp.Before(from) || timestamp.After(to) {
+			t.Fatalf("Expected timestamp within the range, got %s", timestamp)
+		}
 
-		if !ok {
-			t.Errorf("Missing key %v", fld.Name)
+		if timestamp.Before(previous) {
+			t.Fatalf("Expected ordered timestamps, got %s after %s", timestamp, previous)
 		}
 
-		if ts, err := time.Parse(FieldTypeTimeLayout, v); err != nil {
-			t.Errorf("Fail parse timestamp %v", err)
-		} else {
-			// Timestamp should be +(period.Nanoseconds() / nSpins) * i) for every iteration
-			expectedTime := from.Add(time.Duration((period.Nanoseconds() / nSpins) * i))
+		previous = timestamp
+	}
 
-			diff := expectedTime.Sub(ts)
-			if diff != 0 {
-				t.Errorf("Date generated out of period range %v", diff)
-			}
-		}
+	// the last of 1000 events in a day arrives in its last minutes
+	if to.Sub(previous) > 30*time.Minute {
+		t.Errorf("Expected the last event near the end of the range, got %s", previous)
 	}
 }
 
-func Test_FieldDateAndRangeFromAndToNegativeWithTextTemplate(t *testing.T) {
-	fld := Field{
-		Name: "alpha",
-		Type: FieldTypeDate,
+func Test_FieldDateOutOfOrderWithTextTemplate(t *testing.T) {
+	fields := Fields{
+		{Name: "@timestamp", Type: FieldTypeDate},
+		{Name: "event.ingested", Type: FieldTypeDate},
 	}
 
-	startTime := time.Now().Truncate(time.Microsecond)
-	from := startTime.Add(10 * time.Second)
-	to := startTime.Add(-10 * time.Second)
-	template := []byte(`{{$alpha := generate "alpha"}}{"alpha":"{{$alpha.Format "2006-01-02T15:04:05.999999999-07:00"}}"}`)
-	configYaml := []byte(fmt.Sprintf("fields:\n  - name: alpha\n    range:\n      from: %s\n      to: %s", from.Format("2006-01-02T15:04:05.999999999-07:00"), to.Format("2006-01-02T15:04:05.999999999-07:00")))
-	t.Logf("with template: %s", string(template))
+	configYaml := []byte(`fields:
+  - name: "@timestamp"
+    period: 1000s
+    out_of_order:
+      probability: 0.5
+  - name: event.ingested
+    period: 1000s
+`)
 
 	cfg, err := config.LoadConfigFromYaml(configYaml)
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	g := makeGeneratorWithTextTemplate(t, cfg, []Field{fld}, template, 10, WithStartTime(startTime))
-
-	var buf bytes.Buffer
-
-	period := to.UTC().Sub(from.UTC())
+	template := []byte(`{{ $timestamp := generate "@timestamp" }}{{ $ingested := generate "event.ingested" }}{{ $ingested.Sub $timestamp }}`)
+	g := makeGeneratorWithTextTemplate(t, cfg, fields, template, 1000)
 
-	nSpins := int64(10)
-	for i := int64(0); i < nSpins; i++ {
+	var late int
+	for i := 0; i < 1000; i++ {
+		var buf bytes.Buffer
 		if err := g.Emit(&buf); err != nil {
 			t.Fatal(err)
 		}
 
-		m := unmarshalJSONT[string](t, buf.Bytes())
-		buf.Reset()
-
-		if len(m) != 1 {
-			t.Errorf("Expected map size 1, got %d", len(m))
+		lag, err := time.ParseDuration(buf.String())
+		if err != nil {
+			t.Fatal(err)
 		}
 
-		v, ok := m[fld.Name]
-
-		if !ok {
-			t.Errorf("Missing key %v", fld.Name)
+		// late by up to a minute by default
+		if lag < 0 || lag > time.Minute {
+			t.Fatalf("Expected lag between 0 and 1m, got %s", lag)
 		}
 
-		if ts, err := time.Parse(FieldTypeTimeLayout, v); err != nil {
-			t.Errorf("Fail parse timestamp %v", err)
-		} else {
-			// Timestamp should be +(period.Nanoseconds() / nSpins) * i) for every iteration
-			expectedTime := from.Add(time.Duration((period.Nanoseconds() / nSpins) * (nSpins - i)))
-
-			diff := expectedTime.Sub(ts)
-			if diff != 0 {
-				t.Errorf("Date generated out of period range %v", diff)
-			}
+		if lag > 0 {
+			late++
 		}
 	}
+
+	if late < 400 || late > 600 {
+		t.Errorf("Expected about 500 late events, got %d", late)
+	}
 }
 
-func Test_FieldIPWithTextTemplate(t *testing.T) {
-	fld := Field{
-		Name: "alpha",
-		Type: FieldTypeIP,
+func Test_FieldDateRelativeToWithTextTemplate(t *testing.T) {
+	fields := Fields{
+		{Name: "event.start", Type: FieldTypeDate},
+		{Name: "event.end", Type: FieldTypeDate},
 	}
 
-	template := []byte(`{"alpha":"{{generate "alpha"}}"}`)
-	t.Logf("with template: %s", string(template))
-	nSpins := rand.Intn(1024) + 1
-	for i := 0; i < nSpins; i++ {
+	configYaml := []byte(`fields:
+  - name: event.end
+    relative_to:
+      field: event.start
+      min: 1m
+      max: 2m
+`)
 
-		b := testSingleTWithTextTemplate[string](t, fld, nil, template)
+	cfg, err := config.LoadConfigFromYaml(configYaml)
+	if err != nil {
+		t.Fatal(err)
+	}
 
-		if ip := net.ParseIP(b); ip == nil {
-			t.Errorf("Fail parse ip %s", b)
+	// event.end is generated before event.start
+	template := []byte(`{{ $end := generate "event.end" }}{{ $start := generate "event.start" }}{{ $end.Sub $start }}`)
+	g := makeGeneratorWithTextTemplate(t, cfg, fields, template, 0)
+
+	for i := 0; i < 1000; i++ {
+		var buf bytes.Buffer
+		if err := g.Emit(&buf); err != nil {
+			t.Fatal(err)
+		}
+
+		duration, err := time.ParseDuration(buf.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if duration < time.Minute || duration > 2*time.Minute {
+			t.Fatalf("Expected duration between 1m and 2m, got %s", duration)
 		}
 	}
 }
 
-func Test_FieldLongCounterResetAfterN5WithTextTemplate(t *testing.T) {
-	fld := Field{
-		Name: "counter_reset_test",
-		Type: FieldTypeLong,
+func Test_FieldDateCounterWithTextTemplate(t *testing.T) {
+	fields := Fields{
+		{Name: "@timestamp", Type: FieldTypeDate},
 	}
 
-	afterN := 5
-
-	template := []byte(`{{$counter_reset_test := generate "counter_reset_test"}}{"counter_reset_test":"{{$counter_reset_test}}"}`)
-	configYaml := []byte(fmt.Sprintf(`fields:
-- name: counter_reset_test
-  counter: true
-  counter_reset:
-    strategy: after_n
-    reset_after_n: %d`, afterN))
-	t.Logf("with template: %s", string(template))
+	configYaml := []byte(`fields:
+  - name: "@timestamp"
+    counter: true
+    period: 10s
+    fuzziness: 0.02
+    range:
+      from: "2024-03-04T00:00:00+00:00"
+`)
 
 	cfg, err := config.LoadConfigFromYaml(configYaml)
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	g := makeGeneratorWithTextTemplate(t, cfg, []Field{fld}, template, 40)
-
-	var buf bytes.Buffer
-
-	nSpins := int64(40)
+	from := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
 
-	var resetCount int64
-	expectedResetCount := nSpins / int64(afterN) // 8
+	template := []byte(`{{ $timestamp := generate "@timestamp" }}{{ $timestamp.Format "2006-01-02T15:04:05.999999999Z07:00" }}`)
+	g := makeGeneratorWithTextTemplate(t, cfg, fields, template, 0)
 
-	for i := int64(0); i < nSpins; i++ {
+	var fuzzy int
+	for i := 0; i < 1000; i++ {
+		var buf bytes.Buffer
 		if err := g.Emit(&buf); err != nil {
 			t.Fatal(err)
 		}
 
-		m := unmarshalJSONT[string](t, buf.Bytes())
-		buf.Reset()
-
-		if len(m) != 1 {
-			t.Errorf("Expected map size 1, got %d", len(m))
+		timestamp, err := time.Parse(time.RFC3339Nano, buf.String())
+		if err != nil {
+			t.Fatal(err)
 		}
 
-		v, ok := m[fld.Name]
-		if !ok {
-			t.Errorf("Missing key %v", fld.Name)
+		// the delta does not accumulate
+		expected := from.Add(time.Duration(i) * 10 * time.Second)
+		delta := timestamp.Sub(expected)
+		if delta.Abs() > 200*time.Millisecond {
+			t.Fatalf("Expected sample %d at %s ± 200ms, got %s", i, expected, timestamp)
 		}
 
-		if i%int64(afterN) == 0 {
-			if v != "0" {
-				t.Errorf("Expected counter to reset to 0, got %v", v)
-			}
-			resetCount++
+		if delta != 0 {
+			fuzzy++
 		}
-
-		t.Logf("counter value: %v", v)
 	}
 
-	if resetCount != expectedResetCount {
-		t.Errorf("Expected counter to reset %d times, got %d", expectedResetCount, resetCount)
+	if fuzzy == 0 {
+		t.Errorf("Expected samples moved by fuzziness")
 	}
 }
 
@@ -0,0 +1,208 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib/config"
+)
+
+// kilometers in a degree of latitude
+const kmPerDegree = 111.32
+
+// geoPolygonMaxTries is the maximum number of points drawn from the bounding box of a polygon
+// before giving up on finding one inside it
+const geoPolygonMaxTries = 1000
+
+// geoCities are the centres of the cities that can be referenced by name in the `geo_point.cities` config
+var geoCities = map[string][2]float64{
+	"amsterdam":     {52.3676, 4.9041},
+	"beijing":       {39.9042, 116.4074},
+	"berlin":        {52.5200, 13.4050},
+	"chicago":       {41.8781, -87.6298},
+	"dubai":         {25.2048, 55.2708},
+	"johannesburg":  {-26.2041, 28.0473},
+	"london":        {51.5074, -0.1278},
+	"los_angeles":   {34.0522, -118.2437},
+	"madrid":        {40.4168, -3.7038},
+	"mexico_city":   {19.4326, -99.1332},
+	"mumbai":        {19.0760, 72.8777},
+	"new_york":      {40.7128, -74.0060},
+	"paris":         {48.8566, 2.3522},
+	"rome":          {41.9028, 12.4964},
+	"san_francisco": {37.7749, -122.4194},
+	"sao_paulo":     {-23.5505, -46.6333},
+	"singapore":     {1.3521, 103.8198},
+	"sydney":        {-33.8688, 151.2093},
+	"tokyo":         {35.6762, 139.6503},
+	"toronto":       {43.6532, -79.3832},
+}
+
+// geoPoint is a point generated for a `geo_point` field formatted as a JSON object.
+// It is printed as JSON in gotext templates, and its coordinates are accessible as .Lat and .Lon
+type geoPoint struct {
+	Lat    float64
+	Lon    float64
+	format string
+}
+
+func (p geoPoint) MarshalJSON() ([]byte, error) {
+	if p.format == config.GeoPointFormatGeoJSON {
+		return json.Marshal(map[string]any{"type": "Point", "coordinates": []float64{p.Lon, p.Lat}})
+	}
+
+	return json.Marshal(map[string]float64{"lat": p.Lat, "lon": p.Lon})
+}
+
+func (p geoPoint) String() string {
+	b, _ := p.MarshalJSON()
+	return string(b)
+}
+
+// geoRegion draws random points, as latitude and longitude, from a region
+type geoRegion func(r *rand.Rand) (float64, float64)
+
+// makeGeoPointFunc returns a function generating points according to the `geo_point` config of the field,
+// as a string or as a geoPoint depending on the format
+func makeGeoPointFunc(geoCfg config.GeoPoint) (func(r *rand.Rand) any, error) {
+	if err := geoCfg.Valid(); err != nil {
+		return nil, err
+	}
+
+	var regions []geoRegion
+	for _, box := range geoCfg.BoundingBoxes {
+		regions = append(regions, makeGeoBoundingBoxRegion(box))
+	}
+
+	for _, city := range geoCfg.Cities {
+		region, err := makeGeoCityRegion(city)
+		if err != nil {
+			return nil, err
+		}
+
+		regions = append(regions, region)
+	}
+
+	for _, polygon := range geoCfg.Polygons {
+		regions = append(regions, makeGeoPolygonRegion(polygon))
+	}
+
+	if len(regions) == 0 {
+		regions = append(regions, makeGeoBoundingBoxRegion(config.BoundingBox{MinLat: -90, MaxLat: 90, MinLon: -180, MaxLon: 180}))
+	}
+
+	format := geoCfg.FormatOrDefault()
+
+	return func(r *rand.Rand) any {
+		lat, lon := regions[r.Intn(len(regions))](r)
+		lat, lon = roundCoordinate(lat), roundCoordinate(lon)
+
+		switch format {
+		case config.GeoPointFormatObject, config.GeoPointFormatGeoJSON:
+			return geoPoint{Lat: lat, Lon: lon, format: format}
+		case config.GeoPointFormatWKT:
+			return fmt.Sprintf("POINT (%s %s)", formatCoordinate(lon), formatCoordinate(lat))
+		default:
+			return formatCoordinate(lat) + "," + formatCoordinate(lon)
+		}
+	}, nil
+}
+
+// roundCoordinate rounds the coordinate to 6 decimals, about 10 centimeters
+func roundCoordinate(v float64) float64 {
+	return math.Round(v*1e6) / 1e6
+}
+
+func formatCoordinate(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func makeGeoBoundingBoxRegion(box config.BoundingBox) geoRegion {
+	return func(r *rand.Rand) (float64, float64) {
+		lat := box.MinLat + r.Float64()*(box.MaxLat-box.MinLat)
+		lon := box.MinLon + r.Float64()*(box.MaxLon-box.MinLon)
+		return lat, lon
+	}
+}
+
+func makeGeoCityRegion(city config.GeoCity) (geoRegion, error) {
+	var centerLat, centerLon float64
+	if city.Lat != nil {
+		centerLat, centerLon = *city.Lat, *city.Lon
+	} else {
+		center, ok := geoCities[strings.ToLower(city.Name)]
+		if !ok {
+			return nil, fmt.Errorf("geo_point city %s not known, set its 'lat' and 'lon' values", city.Name)
+		}
+
+		centerLat, centerLon = center[0], center[1]
+	}
+
+	return func(r *rand.Rand) (float64, float64) {
+		// uniform distribution over the disk, using an equirectangular approximation
+		distance := city.Radius * math.Sqrt(r.Float64())
+		bearing := 2 * math.Pi * r.Float64()
+
+		lat := centerLat + distance*math.Cos(bearing)/kmPerDegree
+		lon := centerLon + distance*math.Sin(bearing)/(kmPerDegree*math.Cos(centerLat*math.Pi/180))
+
+		return math.Max(math.Min(lat, 90), -90), normaliseLongitude(lon)
+	}, nil
+}
+
+func normaliseLongitude(lon float64) float64 {
+	for lon > 180 {
+		lon -= 360
+	}
+
+	for lon < -180 {
+		lon += 360
+	}
+
+	return lon
+}
+
+// makeGeoPolygonRegion returns a region drawing points from the bounding box of the polygon, until one is inside it
+func makeGeoPolygonRegion(polygon [][]float64) geoRegion {
+	box := config.BoundingBox{MinLat: 90, MaxLat: -90, MinLon: 180, MaxLon: -180}
+	for _, point := range polygon {
+		box.MinLon, box.MaxLon = math.Min(box.MinLon, point[0]), math.Max(box.MaxLon, point[0])
+		box.MinLat, box.MaxLat = math.Min(box.MinLat, point[1]), math.Max(box.MaxLat, point[1])
+	}
+
+	boxRegion := makeGeoBoundingBoxRegion(box)
+
+	return func(r *rand.Rand) (float64, float64) {
+		var lat, lon float64
+		for i := 0; i < geoPolygonMaxTries; i++ {
+			lat, lon = boxRegion(r)
+			if isInPolygon(polygon, lat, lon) {
+				break
+			}
+		}
+
+		return lat, lon
+	}
+}
+
+// isInPolygon checks if the point is inside the polygon, made of [lon, lat] points, with the ray casting algorithm
+func isInPolygon(polygon [][]float64, lat, lon float64) bool {
+	inside := false
+	for i, j := 0, len(polygon)-1; i < len(polygon); j, i = i, i+1 {
+		lonI, latI := polygon[i][0], polygon[i][1]
+		lonJ, latJ := polygon[j][0], polygon[j][1]
+		if (latI > lat) != (latJ > lat) && lon < (lonJ-lonI)*(lat-latI)/(latJ-latI)+lonI {
+			inside = !inside
+		}
+	}
+
+	return inside
+}
@@ -0,0 +1,183 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"math/rand"
+)
+
+// histogram is a value generated for a `histogram` field, printed as JSON.
+// Values are strictly increasing, and each value has the count of the samples in its bucket.
+type histogram struct {
+	Values []float64 `json:"values"`
+	Counts []int64   `json:"counts"`
+}
+
+func (h histogram) String() string {
+	b, _ := json.Marshal(h)
+	return string(b)
+}
+
+// aggregateMetricDouble is a value generated for an `aggregate_metric_double` field, printed as JSON
+type aggregateMetricDouble struct {
+	Min        float64 `json:"min"`
+	Max        float64 `json:"max"`
+	Sum        float64 `json:"sum"`
+	ValueCount int64   `json:"value_count"`
+}
+
+func (a aggregateMetricDouble) String() string {
+	b, _ := json.Marshal(a)
+	return string(b)
+}
+
+// makeSamplesFunc returns a function drawing the samples summarised by `histogram` and `aggregate_metric_double`
+// fields, from the `distribution` and within the `range` of the field
+func makeSamplesFunc(fieldCfg ConfigField, field Field) (func(r *rand.Rand) []float64, error) {
+	if err := fieldCfg.ValidSamples(); err != nil {
+		return nil, err
+	}
+
+	minSamples, maxSamples := fieldCfg.Samples.OrDefault()
+
+	return func(r *rand.Rand) []float64 {
+		sampleFunc := makeFloatFunc(r, fieldCfg, field)
+
+		samples := make([]float64, minSamples+r.Intn(maxSamples-minSamples+1))
+		for i := range samples {
+			samples[i] = sampleFunc()
+		}
+
+		return samples
+	}, nil
+}
+
+// makeHistogramFunc returns a function generating the values of `histogram` fields: the samples are counted
+// in buckets of the same width between their min and max, and empty buckets are omitted
+func makeHistogramFunc(fieldCfg ConfigField, field Field) (func(r *rand.Rand) histogram, error) {
+	samplesFunc, err := makeSamplesFunc(fieldCfg, field)
+	if err != nil {
+		return nil, err
+	}
+
+	buckets := fieldCfg.BucketsOrDefault()
+
+	return func(r *rand.Rand) histogram {
+		samples := samplesFunc(r)
+
+		minValue, maxValue := math.Inf(1), math.Inf(-1)
+		for _, sample := range samples {
+			minValue, maxValue = math.Min(minValue, sample), math.Max(maxValue, sample)
+		}
+
+		width := (maxValue - minValue) / float64(buckets)
+		counts := make([]int64, buckets)
+		for _, sample := range samples {
+			bucket := buckets - 1
+			if width > 0 {
+				bucket = min(int((sample-minValue)/width), buckets-1)
+			}
+
+			counts[bucket]++
+		}
+
+		var h histogram
+		for bucket, count := range counts {
+			if count == 0 {
+				continue
+			}
+
+			h.Values = append(h.Values, minValue+width*(float64(bucket)+0.5))
+			h.Counts = append(h.Counts, count)
+		}
+
+		return h
+	}, nil
+}
+
+// makeAggregateMetricDoubleFunc returns a function generating the values of `aggregate_metric_double` fields
+func makeAggregateMetricDoubleFunc(fieldCfg ConfigField, field Field) (func(r *rand.Rand) aggregateMetricDouble, error) {
+	samplesFunc, err := makeSamplesFunc(fieldCfg, field)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(r *rand.Rand) aggregateMetricDouble {
+		samples := samplesFunc(r)
+
+		a := aggregateMetricDouble{Min: math.Inf(1), Max: math.Inf(-1), ValueCount: int64(len(samples))}
+		for _, sample := range samples {
+			a.Min, a.Max = math.Min(a.Min, sample), math.Max(a.Max, sample)
+			a.Sum += sample
+		}
+
+		return a
+	}, nil
+}
+
+func bindHistogram(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	histogramFunc, err := makeHistogramFunc(fieldCfg, field)
+	if err != nil {
+		return err
+	}
+
+	var emitFNotReturn emitFNotReturn
+	emitFNotReturn = func(state *genState, buf *bytes.Buffer) error {
+		buf.WriteString(histogramFunc(state.rand).String())
+		return nil
+	}
+
+	fieldMap[field.Name] = emitFNotReturn
+	return nil
+}
+
+func bindHistogramWithReturn(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	histogramFunc, err := makeHistogramFunc(fieldCfg, field)
+	if err != nil {
+		return err
+	}
+
+	var emitF emitF
+	emitF = func(state *genState) any {
+		return histogramFunc(state.rand)
+	}
+
+	fieldMap[field.Name] = emitF
+	return nil
+}
+
+func bindAggregateMetricDouble(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	aggregateMetricDoubleFunc, err := makeAggregateMetricDoubleFunc(fieldCfg, field)
+	if err != nil {
+		return err
+	}
+
+	var emitFNotReturn emitFNotReturn
+	emitFNotReturn = func(state *genState, buf *bytes.Buffer) error {
+		buf.WriteString(aggregateMetricDoubleFunc(state.rand).String())
+		return nil
+	}
+
+	fieldMap[field.Name] = emitFNotReturn
+	return nil
+}
+
+func bindAggregateMetricDoubleWithReturn(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	aggregateMetricDoubleFunc, err := makeAggregateMetricDoubleFunc(fieldCfg, field)
+	if err != nil {
+		return err
+	}
+
+	var emitF emitF
+	emitF = func(state *genState) any {
+		return aggregateMetricDoubleFunc(state.rand)
+	}
+
+	fieldMap[field.Name] = emitF
+	return nil
+}
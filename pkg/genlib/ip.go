@@ -0,0 +1,97 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+import (
+	"math/rand"
+	"net/netip"
+
+	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib/config"
+)
+
+// private ranges used when `ip.private` is set, as defined in RFC 1918 and RFC 4193
+var (
+	privateIPv4Prefixes = []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("172.16.0.0/12"),
+		netip.MustParsePrefix("192.168.0.0/16"),
+	}
+	privateIPv6Prefixes = []netip.Prefix{
+		netip.MustParsePrefix("fc00::/7"),
+	}
+)
+
+// makeIPFunc returns a function generating ip addresses according to the `ip` config of the field
+func makeIPFunc(ipCfg config.IP) (func(r *rand.Rand) string, error) {
+	if err := ipCfg.Valid(); err != nil {
+		return nil, err
+	}
+
+	var ipv4Prefixes, ipv6Prefixes []netip.Prefix
+	for _, cidr := range ipCfg.CIDRs {
+		prefix := netip.MustParsePrefix(cidr).Masked()
+		if prefix.Addr().Is4() {
+			ipv4Prefixes = append(ipv4Prefixes, prefix)
+		} else {
+			ipv6Prefixes = append(ipv6Prefixes, prefix)
+		}
+	}
+
+	if ipCfg.Private {
+		ipv4Prefixes = privateIPv4Prefixes
+		ipv6Prefixes = privateIPv6Prefixes
+	}
+
+	ipv6Ratio := ipCfg.IPv6RatioOrDefault()
+
+	return func(r *rand.Rand) string {
+		if ipv6Ratio > 0 && (ipv6Ratio >= 1 || r.Float64() < ipv6Ratio) {
+			if len(ipv6Prefixes) == 0 {
+				return randIPv6(r).String()
+			}
+
+			return randIPInPrefix(r, ipv6Prefixes[r.Intn(len(ipv6Prefixes))]).String()
+		}
+
+		if len(ipv4Prefixes) == 0 {
+			i0, i1, i2, i3 := randIP(r)
+			return netip.AddrFrom4([4]byte{byte(i0), byte(i1), byte(i2), byte(i3)}).String()
+		}
+
+		return randIPInPrefix(r, ipv4Prefixes[r.Intn(len(ipv4Prefixes))]).String()
+	}, nil
+}
+
+func randIPv6(r *rand.Rand) netip.Addr {
+	var b [16]byte
+	r.Read(b[:])
+
+	return netip.AddrFrom16(b)
+}
+
+// randIPInPrefix returns a random address in the prefix, keeping the bits of the prefix and randomising the others
+func randIPInPrefix(r *rand.Rand, prefix netip.Prefix) netip.Addr {
+	b := prefix.Addr().AsSlice()
+	random := make([]byte, len(b))
+	r.Read(random)
+
+	for i := range b {
+		// number of bits of the byte that belong to the prefix
+		prefixBits := prefix.Bits() - i*8
+		switch {
+		case prefixBits >= 8:
+			continue
+		case prefixBits <= 0:
+			b[i] = random[i]
+		default:
+			hostMask := byte(0xff >> prefixBits)
+			b[i] = b[i]&^hostMask | random[i]&hostMask
+		}
+	}
+
+	addr, _ := netip.AddrFromSlice(b)
+
+	return addr
+}
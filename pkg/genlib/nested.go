@@ -0,0 +1,119 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// nestedLeafNames returns the names of the fields that are not dynamic. Dotted prefixes of field names
+// are nested objects in the nested template, unless they are the name of one of these fields, see nestedFieldPath.
+func nestedLeafNames(fields Fields) map[string]struct{} {
+	leafNames := make(map[string]struct{}, len(fields))
+	for _, field := range fields {
+		if !isDynamicField(field) {
+			leafNames[field.Name] = struct{}{}
+		}
+	}
+
+	return leafNames
+}
+
+// nestedFieldPath splits the name of a field into the path of the objects it is nested in, and its key
+// in the innermost one. The key keeps its dots from the first prefix that is the name of another field,
+// since a value cannot be an object at the same time.
+func nestedFieldPath(name string, leafNames map[string]struct{}) ([]string, string) {
+	parts := strings.Split(name, ".")
+
+	var path []string
+	for i := 0; i < len(parts)-1; i++ {
+		if _, ok := leafNames[strings.Join(parts[:i+1], ".")]; ok {
+			break
+		}
+
+		path = parts[:i+1]
+	}
+
+	return path, strings.Join(parts[len(path):], ".")
+}
+
+// generateNestedTemplateFromField generates a template where dotted field names are emitted as nested JSON objects
+func generateNestedTemplateFromField(cfg Config, fields Fields, templateEngine int, state *genState) ([]byte, []Field) {
+	dupes := make(map[string]struct{})
+	objectKeysField := make([]Field, 0, len(fields))
+	leafNames := nestedLeafNames(fields)
+
+	root := newTemplateObject()
+	for _, field := range fields {
+		if keysFields := objectKeysFields(cfg, field); len(keysFields) > 0 {
+			// the keys are bound with the object field, see bindObject
+			for _, keyField := range keysFields {
+				path, key := nestedFieldPath(keyField.Name, leafNames)
+				object := root.object(path)
+				object.addField(nestedFieldTemplate(cfg, keyField, key, templateEngine))
+			}
+
+			continue
+		}
+
+		if isDynamicField(field) {
+			// This is a special case.  We are randomly generating keys on the fly
+			// Will set the json field name as "field.Name.N"
+			N := 5
+			for ii := 0; ii < N; ii++ {
+				// Fire or skip
+				if state.rand.Int()%2 == 0 {
+					continue
+				}
+
+				rNoun := dynamicFieldKey(state, dupes)
+
+				originalFieldName := field.Name
+				field.Name = replacer.Replace(field.Name) + "." + rNoun
+				objectKeysField = append(objectKeysField, field)
+
+				path, key := nestedFieldPath(field.Name, leafNames)
+				object := root.object(path)
+				object.addField(nestedFieldTemplate(cfg, field, key, templateEngine))
+
+				field.Name = originalFieldName
+			}
+
+			continue
+		}
+
+		path, key := nestedFieldPath(field.Name, leafNames)
+		object := root.object(path)
+		if isMissingField(cfg, field) {
+			object.addMissingField(field, key)
+			continue
+		}
+
+		object.addField(nestedFieldTemplate(cfg, field, key, templateEngine))
+	}
+
+	templateBuffer := bytes.NewBufferString("")
+	var fieldSeparatorDeclared bool
+	root.write(templateBuffer, cfg, templateEngine, nil, state, &fieldSeparatorDeclared)
+
+	return templateBuffer.Bytes(), objectKeysField
+}
+
+// nestedFieldTemplate returns the template of a field emitted with key in its object
+func nestedFieldTemplate(cfg Config, field Field, key string, templateEngine int) string {
+	fieldWrap := fieldValueWrapByConfig(cfg, field)
+	if templateEngine == customTemplateEngine {
+		return fmt.Sprintf(`"%s": %s{{.%s}}%s`, key, fieldWrap, field.Name, fieldWrap)
+	}
+
+	if isDateField(field) && !isArrayField(cfg, field) {
+		fieldVariableName := fieldNormalizerRegex.ReplaceAllString(field.Name, "") + "Var"
+		return fmt.Sprintf(`{{ $%s := generate "%s" }}"%s": %s%s%s`, fieldVariableName, field.Name, key, fieldWrap, dateValueTemplate(cfg, field, fieldVariableName), fieldWrap)
+	}
+
+	return fmt.Sprintf(`"%s": %s{{generate "%s"}}%s`, key, fieldWrap, field.Name, fieldWrap)
+}
@@ -11,6 +11,8 @@ type options struct {
 	randSeed  int64
 	startTime time.Time
 	template  []byte
+	rawValues bool
+	nested    bool
 	make      func(Config, Fields, uint64, options) (Generator, error)
 }
 
@@ -47,6 +49,22 @@ func WithCustomTemplate(template []byte) Option {
 	}
 }
 
+// WithRawValues disables the JSON escaping of the values of quoted fields in the placeholder engine,
+// for templates not generating JSON, like Schema A log lines.
+func WithRawValues() Option {
+	return func(o *options) {
+		o.rawValues = true
+	}
+}
+
+// WithNestedObjects makes the template auto-generated from the fields emit dotted field names as nested JSON objects,
+// like `{"a": {"b": 1}}` instead of `{"a.b": 1}`. It has no effect when a template is provided.
+func WithNestedObjects() Option {
+	return func(o *options) {
+		o.nested = true
+	}
+}
+
 // applyOptions applies the given options and returns the final configuration.
 func applyOptions(opts []Option) options {
 	// This initialization is executed in a concurrent context, any accesss
@@ -0,0 +1,120 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+import (
+	"math/rand"
+	"regexp/syntax"
+	"strings"
+)
+
+// patternMaxRepeat is the maximum number of repetitions generated for unbounded repeats, like `*` and `+`
+const patternMaxRepeat = 10
+
+// printable ASCII range, preferred when generating characters out of wide classes like `.` or `[^a-z]`
+const (
+	patternPrintableMin = 0x20
+	patternPrintableMax = 0x7e
+)
+
+// makePatternFunc returns a function generating random strings matching the given regular expression
+func makePatternFunc(pattern string) (func(r *rand.Rand) string, error) {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return nil, err
+	}
+
+	re = re.Simplify()
+
+	return func(r *rand.Rand) string {
+		var sb strings.Builder
+		writePattern(&sb, r, re)
+		return sb.String()
+	}, nil
+}
+
+func writePattern(sb *strings.Builder, r *rand.Rand, re *syntax.Regexp) {
+	switch re.Op {
+	case syntax.OpLiteral:
+		for _, c := range re.Rune {
+			sb.WriteRune(c)
+		}
+	case syntax.OpCharClass:
+		sb.WriteRune(randRuneFromRanges(r, re.Rune))
+	case syntax.OpAnyCharNotNL, syntax.OpAnyChar:
+		sb.WriteRune(rune(patternPrintableMin + r.Intn(patternPrintableMax-patternPrintableMin+1)))
+	case syntax.OpCapture:
+		writePattern(sb, r, re.Sub[0])
+	case syntax.OpStar:
+		writePatternRepeat(sb, r, re.Sub[0], 0, patternMaxRepeat)
+	case syntax.OpPlus:
+		writePatternRepeat(sb, r, re.Sub[0], 1, patternMaxRepeat)
+	case syntax.OpQuest:
+		writePatternRepeat(sb, r, re.Sub[0], 0, 1)
+	case syntax.OpRepeat:
+		max := re.Max
+		if max < 0 {
+			max = re.Min + patternMaxRepeat
+		}
+
+		writePatternRepeat(sb, r, re.Sub[0], re.Min, max)
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			writePattern(sb, r, sub)
+		}
+	case syntax.OpAlternate:
+		writePattern(sb, r, re.Sub[r.Intn(len(re.Sub))])
+	default:
+		// empty matches, like anchors and word boundaries, do not generate anything
+	}
+}
+
+func writePatternRepeat(sb *strings.Builder, r *rand.Rand, re *syntax.Regexp, min, max int) {
+	n := min + r.Intn(max-min+1)
+	for i := 0; i < n; i++ {
+		writePattern(sb, r, re)
+	}
+}
+
+// randRuneFromRanges returns a random rune from the given pairs of inclusive ranges, as in syntax.Regexp.Rune,
+// restricted to printable ASCII characters when the ranges include any
+func randRuneFromRanges(r *rand.Rand, ranges []rune) rune {
+	printable := make([]rune, 0, len(ranges))
+	for i := 0; i+1 < len(ranges); i += 2 {
+		lo, hi := ranges[i], ranges[i+1]
+		if lo < patternPrintableMin {
+			lo = patternPrintableMin
+		}
+
+		if hi > patternPrintableMax {
+			hi = patternPrintableMax
+		}
+
+		if lo <= hi {
+			printable = append(printable, lo, hi)
+		}
+	}
+
+	if len(printable) > 0 {
+		ranges = printable
+	}
+
+	var total int
+	for i := 0; i+1 < len(ranges); i += 2 {
+		total += int(ranges[i+1]-ranges[i]) + 1
+	}
+
+	n := r.Intn(total)
+	for i := 0; i+1 < len(ranges); i += 2 {
+		size := int(ranges[i+1]-ranges[i]) + 1
+		if n < size {
+			return ranges[i] + rune(n)
+		}
+
+		n -= size
+	}
+
+	return ranges[0]
+}
@@ -0,0 +1,204 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/netip"
+	"time"
+
+	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib/config"
+)
+
+// dateRangeMaxSpan is the maximum span between the bounds of the values of `date_range` fields
+const dateRangeMaxSpan = time.Hour
+
+// ipRangeFamilyTries is the number of addresses generated for the upper bound of `ip_range` fields
+// looking for one of the same family of the lower bound, before using the lower bound itself
+const ipRangeFamilyTries = 10
+
+// rangeValue is a value generated for a range field, printed as JSON.
+// Gte is always less than or equal to Lte.
+type rangeValue struct {
+	Gte any
+	Lte any
+}
+
+func (v rangeValue) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]any{"gte": rangeBound(v.Gte), "lte": rangeBound(v.Lte)})
+}
+
+func (v rangeValue) String() string {
+	b, _ := v.MarshalJSON()
+	return string(b)
+}
+
+// rangeBound formats the dates as the values of `date` fields
+func rangeBound(bound any) any {
+	if t, ok := bound.(time.Time); ok {
+		return t.Format(FieldTypeTimeLayout)
+	}
+
+	return bound
+}
+
+// makeRangeFunc returns a function generating the values of range fields, with bounds generated as the values
+// of the underlying type, honouring the `range`, `period`, `distribution` and `ip` settings of the field
+func makeRangeFunc(fieldCfg ConfigField, field Field) (func(state *genState) (rangeValue, error), error) {
+	switch field.Type {
+	case FieldTypeIntegerRange, FieldTypeLongRange:
+		if err := fieldCfg.ValidDistribution(); err != nil {
+			return nil, err
+		}
+
+		boundField := field
+		boundField.Type = FieldTypeLong
+		if field.Type == FieldTypeIntegerRange {
+			boundField.Type = FieldTypeInteger
+		}
+
+		return func(state *genState) (rangeValue, error) {
+			intFunc, err := makeIntFunc(state.rand, fieldCfg, boundField)
+			if err != nil {
+				return rangeValue{}, err
+			}
+
+			gte, lte := intFunc(), intFunc()
+			return rangeValue{Gte: min(gte, lte), Lte: max(gte, lte)}, nil
+		}, nil
+	case FieldTypeFloatRange, FieldTypeDoubleRange:
+		if err := fieldCfg.ValidDistribution(); err != nil {
+			return nil, err
+		}
+
+		return func(state *genState) (rangeValue, error) {
+			floatFunc := makeFloatFunc(state.rand, fieldCfg, field)
+
+			gte, lte := floatFunc(), floatFunc()
+			return rangeValue{Gte: min(gte, lte), Lte: max(gte, lte)}, nil
+		}, nil
+	case FieldTypeDateRange:
+		if err := fieldCfg.ValidForDateField(); err != nil {
+			return nil, err
+		}
+
+		return func(state *genState) (rangeValue, error) {
+			gte := nearTime(fieldCfg, state)
+
+			span := dateRangeMaxSpan
+			if end, ok := dateRangeEnd(fieldCfg, state); ok {
+				span = max(min(span, end.Sub(gte)), 0)
+			}
+
+			lte := gte.Add(time.Duration(state.rand.Int63n(int64(span) + 1)))
+			return rangeValue{Gte: gte, Lte: lte}, nil
+		}, nil
+	default:
+		var ipCfg config.IP
+		if fieldCfg.IP != nil {
+			ipCfg = *fieldCfg.IP
+		}
+
+		ipFunc, err := makeIPFunc(ipCfg)
+		if err != nil {
+			return nil, err
+		}
+
+		return func(state *genState) (rangeValue, error) {
+			gte := netip.MustParseAddr(ipFunc(state.rand))
+
+			lte := gte
+			for i := 0; i < ipRangeFamilyTries; i++ {
+				if ip := netip.MustParseAddr(ipFunc(state.rand)); ip.Is4() == gte.Is4() {
+					lte = ip
+					break
+				}
+			}
+
+			if lte.Less(gte) {
+				gte, lte = lte, gte
+			}
+
+			return rangeValue{Gte: gte.String(), Lte: lte.String()}, nil
+		}, nil
+	}
+}
+
+// dateRangeEnd returns the end of the time window the values of `date_range` fields are generated in,
+// if the field defines one with `range` or `period`
+func dateRangeEnd(fieldCfg ConfigField, state *genState) (time.Time, bool) {
+	from, errFrom := fieldCfg.Range.FromAsTime()
+	to, errTo := fieldCfg.Range.ToAsTime()
+
+	switch {
+	case errFrom == nil && errTo == nil:
+		return to, true
+	case errTo == nil:
+		if to.After(state.originTime) {
+			return to, true
+		}
+
+		return state.originTime, true
+	case errFrom == nil:
+		if from.After(state.originTime) {
+			return from, true
+		}
+
+		return state.originTime, true
+	case fieldCfg.Period > 0:
+		return state.originTime.Add(fieldCfg.Period), true
+	case fieldCfg.Period < 0:
+		return state.originTime, true
+	}
+
+	return time.Time{}, false
+}
+
+func bindRange(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	rangeFunc, err := makeRangeFunc(fieldCfg, field)
+	if err != nil {
+		return err
+	}
+
+	var emitFNotReturn emitFNotReturn
+	emitFNotReturn = func(state *genState, buf *bytes.Buffer) error {
+		value, err := rangeFunc(state)
+		if err != nil {
+			return err
+		}
+
+		b, err := value.MarshalJSON()
+		if err != nil {
+			return err
+		}
+
+		buf.Write(b)
+		return nil
+	}
+
+	fieldMap[field.Name] = emitFNotReturn
+	return nil
+}
+
+func bindRangeWithReturn(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	rangeFunc, err := makeRangeFunc(fieldCfg, field)
+	if err != nil {
+		return err
+	}
+
+	var emitF emitF
+	emitF = func(state *genState) any {
+		value, err := rangeFunc(state)
+		if err != nil {
+			panic(err)
+		}
+
+		return value
+	}
+
+	fieldMap[field.Name] = emitF
+	return nil
+}
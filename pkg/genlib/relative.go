@@ -0,0 +1,46 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+import (
+	"math"
+	"time"
+)
+
+// makeRelativeTimeFunc returns a function generating the values of date fields with `relative_to`, the value
+// of the related date field in the current event plus a random duration. The related field is a dependency,
+// see fieldDependencies, so its value is the same one emitted in the event, regardless of the position of the
+// fields in the template.
+func makeRelativeTimeFunc(fieldCfg ConfigField) (dateFunc, error) {
+	relativeTo := *fieldCfg.RelativeTo
+	if err := relativeTo.Valid(); err != nil {
+		return nil, err
+	}
+
+	unit := float64(relativeTo.UnitOrDefault())
+	minDuration := float64(relativeTo.Min)
+	maxDuration := float64(relativeTo.MaxOrDefault())
+
+	return func(state *genState) (time.Time, error) {
+		t, err := eventTime(state, relativeTo.Field)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		duration := minDuration + state.rand.Float64()*(maxDuration-minDuration)
+		if relativeTo.Distribution != nil {
+			// the distribution is drawn in units
+			duration = makeDistributionFunc(state.rand, *relativeTo.Distribution, minDuration/unit, maxDuration/unit)() * unit
+		}
+
+		duration = math.Min(math.Max(duration, minDuration), maxDuration)
+		if duration >= math.MaxInt64 {
+			// unbounded distributions can draw durations overflowing time.Duration
+			return t.Add(math.MaxInt64), nil
+		}
+
+		return t.Add(time.Duration(duration)), nil
+	}, nil
+}
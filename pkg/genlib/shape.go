@@ -0,0 +1,79 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+import (
+	"math"
+
+	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib/config"
+)
+
+// makeShapeFunc returns a function computing the value of the shape at the time of the current event, clamped between min and max
+func makeShapeFunc(shape config.Shape, min, max float64) func(state *genState) (float64, error) {
+	timestampField := shape.TimestampFieldOrDefault()
+	period := shape.PeriodOrDefault()
+
+	return func(state *genState) (float64, error) {
+		t, err := eventTime(state, timestampField)
+		if err != nil {
+			return 0, err
+		}
+
+		value := shape.Base
+
+		// seasonality is relative to the unix epoch, so that the phase is always the same regardless of the start time
+		if shape.Amplitude != 0 {
+			cycles := float64(t.Add(-shape.Phase).UnixNano()%period.Nanoseconds()) / float64(period.Nanoseconds())
+			value += shape.Amplitude * math.Sin(2*math.Pi*cycles)
+		}
+
+		// trend is relative to the start time of the generator
+		if shape.Trend != 0 {
+			value += shape.Trend * t.Sub(state.originTime).Hours()
+		}
+
+		if shape.Noise > 0 {
+			value += value * shape.Noise * (2*state.rand.Float64() - 1)
+		}
+
+		return math.Min(math.Max(value, min), max), nil
+	}
+}
+
+func makeIntShapeFunc(fieldCfg ConfigField, field Field) func(state *genState) (int64, error) {
+	minValue, maxValue := getIntTypeBounds(field.Type)
+	if rangeMin, err := fieldCfg.Range.MinAsInt64(); err == nil && rangeMin > minValue {
+		minValue = rangeMin
+	}
+
+	if rangeMax, err := fieldCfg.Range.MaxAsInt64(); err == nil && rangeMax < maxValue {
+		maxValue = rangeMax
+	}
+
+	shapeFunc := makeShapeFunc(*fieldCfg.Shape, float64(minValue), float64(maxValue))
+
+	return func(state *genState) (int64, error) {
+		value, err := shapeFunc(state)
+		if err != nil {
+			return 0, err
+		}
+
+		return clampFloat64ToInt64(math.Round(value), minValue, maxValue), nil
+	}
+}
+
+func makeFloatShapeFunc(fieldCfg ConfigField) func(state *genState) (float64, error) {
+	minValue, err := fieldCfg.Range.MinAsFloat64()
+	if err != nil {
+		minValue = -math.MaxFloat64
+	}
+
+	maxValue, err := fieldCfg.Range.MaxAsFloat64()
+	if err != nil {
+		maxValue = math.MaxFloat64
+	}
+
+	return makeShapeFunc(*fieldCfg.Shape, minValue, maxValue)
+}
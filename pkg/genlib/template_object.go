@@ -0,0 +1,165 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// missingSeparator is how a field that can be missing is separated from the other fields of its object,
+// according to its position in the auto-generated template
+type missingSeparator int
+
+const (
+	// a field always emitted precedes the field in its object: the separator is emitted before its key
+	missingSeparatorBefore missingSeparator = iota
+	// fields always emitted only follow the field in its object: the separator is emitted after its value
+	missingSeparatorAfter
+	// no field of the object is always emitted: the separator is emitted before its key only when another
+	// field of the object has been emitted before in the event
+	missingSeparatorTracked
+)
+
+// missingFieldPosition is the position of a field that can be missing in the auto-generated template.
+// In the placeholder engine the bound function of the field emits its whole key, see makeMissingFieldStub.
+type missingFieldPosition struct {
+	// key of the field in its object
+	key string
+	// object is the path of the object of the field, identifying the fields emitted in the same object
+	object    string
+	separator missingSeparator
+}
+
+// templateObject is a JSON object of the auto-generated template, with the templates of its fields and nested
+// objects in the order of the fields definition. The flat template is a single object, with dotted keys.
+type templateObject struct {
+	items   []templateItem
+	objects map[string]*templateObject
+}
+
+// templateItem is either the template of a field, a field that can be missing, or the key of a nested object
+type templateItem struct {
+	template string
+	missing  *Field
+	key      string
+	object   string
+}
+
+func newTemplateObject() *templateObject {
+	return &templateObject{objects: make(map[string]*templateObject)}
+}
+
+// object returns the object at path, creating the missing ones
+func (o *templateObject) object(path []string) *templateObject {
+	for _, key := range path {
+		child, ok := o.objects[key]
+		if !ok {
+			child = newTemplateObject()
+			o.objects[key] = child
+			o.items = append(o.items, templateItem{object: key})
+		}
+
+		o = child
+	}
+
+	return o
+}
+
+// addField adds the template of a field always emitted
+func (o *templateObject) addField(template string) {
+	o.items = append(o.items, templateItem{template: template})
+}
+
+// addMissingField adds a field that can be missing, emitted with key
+func (o *templateObject) addMissingField(field Field, key string) {
+	o.items = append(o.items, templateItem{missing: &field, key: key})
+}
+
+// write writes the object at path to buf. The separators of the fields that can be missing depend on the fields
+// always emitted in the object: their position is recorded in state for the placeholder engine, while the text
+// template tracks with the $fieldSeparator variable whether a field has been emitted in the objects where none
+// is always emitted. fieldSeparatorDeclared tracks whether the variable is already declared.
+func (o *templateObject) write(buf *bytes.Buffer, cfg Config, templateEngine int, path []string, state *genState, fieldSeparatorDeclared *bool) {
+	lastPresent := -1
+	for i, item := range o.items {
+		if item.missing == nil {
+			lastPresent = i
+		}
+	}
+
+	buf.WriteString("{ ")
+	if lastPresent < 0 && len(o.items) > 0 && templateEngine == textTemplateEngine {
+		if *fieldSeparatorDeclared {
+			buf.WriteString(`{{ $fieldSeparator = "" }}`)
+		} else {
+			buf.WriteString(`{{ $fieldSeparator := "" }}`)
+			*fieldSeparatorDeclared = true
+		}
+	}
+
+	var presentBefore bool
+	for i, item := range o.items {
+		if item.missing != nil {
+			separator := missingSeparatorTracked
+			switch {
+			case presentBefore:
+				separator = missingSeparatorBefore
+			case i < lastPresent:
+				separator = missingSeparatorAfter
+			}
+
+			position := missingFieldPosition{key: item.key, object: strings.Join(path, "."), separator: separator}
+			state.missingFieldPositions[item.missing.Name] = position
+			buf.WriteString(missingFieldTemplate(cfg, *item.missing, position, templateEngine))
+			continue
+		}
+
+		if presentBefore {
+			buf.WriteByte(',')
+		}
+
+		presentBefore = true
+
+		if len(item.object) == 0 {
+			buf.WriteString(item.template)
+			continue
+		}
+
+		buf.WriteString(fmt.Sprintf(`"%s": `, item.object))
+		o.objects[item.object].write(buf, cfg, templateEngine, append(path[:len(path):len(path)], item.object), state, fieldSeparatorDeclared)
+	}
+
+	buf.WriteString(" }")
+}
+
+// missingFieldTemplate returns the template of a field that can be missing, emitting its key and separator
+// only when the field is present
+func missingFieldTemplate(cfg Config, field Field, position missingFieldPosition, templateEngine int) string {
+	if templateEngine == customTemplateEngine {
+		// the key and the separator are emitted by the bound function, see makeMissingFieldStub
+		return fmt.Sprintf(` {{.%s}}`, field.Name)
+	}
+
+	fieldWrap := fieldValueWrapByConfig(cfg, field)
+	fieldVariableName := fieldNormalizerRegex.ReplaceAllString(field.Name, "") + "Var"
+	fieldValue := fmt.Sprintf("{{$%s}}", fieldVariableName)
+	if isDateField(field) && !isArrayField(cfg, field) {
+		fieldValue = dateValueTemplate(cfg, field, fieldVariableName)
+	}
+
+	keyValue := fmt.Sprintf(`"%s": %s%s%s`, position.key, fieldWrap, fieldValue, fieldWrap)
+	switch position.separator {
+	case missingSeparatorBefore:
+		keyValue = "," + keyValue
+	case missingSeparatorAfter:
+		keyValue += ","
+	default:
+		keyValue = `{{ $fieldSeparator }}` + keyValue + `{{ $fieldSeparator = "," }}`
+	}
+
+	return fmt.Sprintf(`{{ $%s := generate "%s" }}{{ if ne $%s nil }}%s{{ end }}`, fieldVariableName, field.Name, fieldVariableName, keyValue)
+}
@@ -0,0 +1,146 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib/config"
+)
+
+var logLevels = []string{"DEBUG", "INFO", "INFO", "INFO", "WARN", "ERROR"}
+
+// makeTextFunc returns a function generating the values of `text`, `match_only_text` and `wildcard` fields
+func makeTextFunc(textCfg config.Text) (func(state *genState) string, error) {
+	if err := textCfg.Valid(); err != nil {
+		return nil, err
+	}
+
+	minWords, maxWords := textCfg.WordsOrDefault()
+	minSentences, maxSentences := textCfg.SentencesOrDefault()
+
+	var sentenceFunc func(state *genState, wordCount int) string
+	switch textCfg.StyleOrDefault() {
+	case config.TextStyleWords:
+		sentenceFunc = func(state *genState, wordCount int) string {
+			var buf bytes.Buffer
+			genNounsN(state, wordCount, &buf)
+			return buf.String()
+		}
+	case config.TextStyleLorem:
+		sentenceFunc = func(state *genState, wordCount int) string {
+			return state.faker.LoremIpsumSentence(wordCount)
+		}
+	case config.TextStyleLog:
+		sentenceFunc = genLogLine
+	default:
+		sentenceFunc = func(state *genState, wordCount int) string {
+			return state.faker.Sentence(wordCount)
+		}
+	}
+
+	randBetween := func(state *genState, min, max int) int {
+		return min + state.rand.Intn(max-min+1)
+	}
+
+	return func(state *genState) string {
+		var sb strings.Builder
+		sentences := randBetween(state, minSentences, maxSentences)
+		for i := 0; i < sentences || sb.Len() < textCfg.MinLength; i++ {
+			if i > 0 {
+				sb.WriteByte(' ')
+			}
+
+			sb.WriteString(sentenceFunc(state, randBetween(state, minWords, maxWords)))
+		}
+
+		if textCfg.MaxLength > 0 {
+			return truncateText(sb.String(), textCfg.MaxLength)
+		}
+
+		return sb.String()
+	}, nil
+}
+
+// genLogLine generates a log line made of a level, a logger name, a message and a key-value pair
+func genLogLine(state *genState, wordCount int) string {
+	var sb strings.Builder
+	sb.WriteString(logLevels[state.rand.Intn(len(logLevels))])
+	sb.WriteString(" [")
+	sb.WriteString(logIdentifier(state))
+	sb.WriteString(".")
+	sb.WriteString(logIdentifier(state))
+	sb.WriteString("] ")
+	sb.WriteString(strings.TrimSuffix(state.faker.Sentence(wordCount), "."))
+	sb.WriteString(" ")
+	sb.WriteString(logIdentifier(state))
+	sb.WriteString("=")
+	sb.WriteString(strconv.Itoa(state.rand.Intn(10000)))
+
+	return sb.String()
+}
+
+// logIdentifier returns a lowercase noun without spaces, usable in logger names and keys of log lines
+func logIdentifier(state *genState) string {
+	return strings.ReplaceAll(strings.ToLower(state.faker.Noun()), " ", "_")
+}
+
+// truncateText truncates the text to maxLength bytes, without breaking runes and without trailing spaces
+func truncateText(text string, maxLength int) string {
+	if len(text) <= maxLength {
+		return text
+	}
+
+	text = text[:maxLength]
+	for len(text) > 0 && !utf8.ValidString(text) {
+		text = text[:len(text)-1]
+	}
+
+	return strings.TrimRight(text, " ")
+}
+
+func bindText(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	var textCfg config.Text
+	if fieldCfg.Text != nil {
+		textCfg = *fieldCfg.Text
+	}
+
+	textFunc, err := makeTextFunc(textCfg)
+	if err != nil {
+		return err
+	}
+
+	var emitFNotReturn emitFNotReturn
+	emitFNotReturn = func(state *genState, buf *bytes.Buffer) error {
+		buf.WriteString(textFunc(state))
+		return nil
+	}
+
+	fieldMap[field.Name] = emitFNotReturn
+	return nil
+}
+
+func bindTextWithReturn(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	var textCfg config.Text
+	if fieldCfg.Text != nil {
+		textCfg = *fieldCfg.Text
+	}
+
+	textFunc, err := makeTextFunc(textCfg)
+	if err != nil {
+		return err
+	}
+
+	var emitF emitF
+	emitF = func(state *genState) any {
+		return textFunc(state)
+	}
+
+	fieldMap[field.Name] = emitF
+	return nil
+}
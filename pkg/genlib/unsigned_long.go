@@ -0,0 +1,297 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+
+	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib/config"
+)
+
+// makeUint64RangeFunc returns a function generating uniformly random values in [min, max].
+// The arithmetic is done modulo 2^64, so that two's complement int64 bounds can be used as well.
+func makeUint64RangeFunc(r *rand.Rand, min, max uint64) func() uint64 {
+	// number of distinct values in the range, in uint64
+	n := max - min + 1
+
+	// uint64 overflow, no rejections needed
+	if n == 0 {
+		return func() uint64 {
+			// uniform in [min, max]
+			return r.Uint64()
+		}
+	}
+
+	// the largest multiple of n that fits in a uint64
+	limit := ^uint64(0) - (^uint64(0) % n)
+
+	return func() uint64 {
+		for {
+			// uniform in [0, 2^64)
+			u := r.Uint64()
+			// accept only values in a multiple of n
+			if u < limit {
+				// uniform in [0, n)
+				x := u % n
+				// uniform in [min, max]
+				return min + x
+			}
+		}
+	}
+}
+
+// makeUintFunc returns a function generating the values of `unsigned_long` fields, over the full uint64 range
+// when no `range` is defined
+func makeUintFunc(r *rand.Rand, fieldCfg ConfigField) (func() uint64, error) {
+	minValue, _ := fieldCfg.Range.MinAsUint64()
+	maxValue, _ := fieldCfg.Range.MaxAsUint64()
+
+	if minValue > maxValue {
+		return nil, fmt.Errorf("invalid range: min %d greater than max %d", minValue, maxValue)
+	}
+
+	if fieldCfg.Distribution != nil {
+		distributionFunc := makeDistributionFunc(r, *fieldCfg.Distribution, float64(minValue), float64(maxValue))
+		return func() uint64 {
+			return clampFloat64ToUint64(math.Round(distributionFunc()), minValue, maxValue)
+		}, nil
+	}
+
+	return makeUint64RangeFunc(r, minValue, maxValue), nil
+}
+
+// clampFloat64ToUint64 converts v to uint64 within min and max, taking care of float64 not representing exactly the uint64 bounds
+func clampFloat64ToUint64(v float64, min, max uint64) uint64 {
+	if v >= float64(max) {
+		return max
+	}
+
+	if v <= float64(min) {
+		return min
+	}
+
+	return uint64(v)
+}
+
+func makeUintShapeFunc(fieldCfg ConfigField) func(state *genState) (uint64, error) {
+	minValue, _ := fieldCfg.Range.MinAsUint64()
+	maxValue, _ := fieldCfg.Range.MaxAsUint64()
+
+	shapeFunc := makeShapeFunc(*fieldCfg.Shape, float64(minValue), float64(maxValue))
+
+	return func(state *genState) (uint64, error) {
+		value, err := shapeFunc(state)
+		if err != nil {
+			return 0, err
+		}
+
+		return clampFloat64ToUint64(math.Round(value), minValue, maxValue), nil
+	}
+}
+
+func fuzzyUint(r *rand.Rand, previous uint64, fuzziness float64, min, max uint64) uint64 {
+	lowerBound := clampFloat64ToUint64(float64(previous)*(1-fuzziness), min, max)
+	higherBound := clampFloat64ToUint64(float64(previous)*(1+fuzziness), min, max)
+	return makeUint64RangeFunc(r, lowerBound, higherBound)()
+}
+
+// fuzzyUintCounter increases the counter by at most fuzziness of its value, and at least by up to 1 so that
+// it keeps growing after a reset to zero. Like real unsigned counters, it wraps around to zero past math.MaxUint64.
+func fuzzyUintCounter(r *rand.Rand, previous uint64, fuzziness float64) uint64 {
+	delta := clampFloat64ToUint64(math.Ceil(float64(previous)*fuzziness), 1, math.MaxUint64)
+	return previous + makeUint64RangeFunc(r, 0, delta)()
+}
+
+func makeUintCounterFunc(r *rand.Rand, previousDummyUint uint64, field Field) func() uint64 {
+	var dummyFunc func() uint64
+
+	switch {
+	case len(field.Example) == 0:
+		dummyFunc = func() uint64 { return previousDummyUint + uint64(r.Int63n(10)) }
+	default:
+		totDigit := len(field.Example)
+		max := int64(math.Pow10(totDigit))
+		dummyFunc = func() uint64 {
+			return previousDummyUint + uint64(r.Int63n(max))
+		}
+	}
+
+	return dummyFunc
+}
+
+// shouldResetCounter checks if the counter of the field must be reset to zero in the current event,
+// according to its `counter_reset` config
+func shouldResetCounter(state *genState, fieldCfg ConfigField) bool {
+	if fieldCfg.CounterReset == nil {
+		return false
+	}
+
+	switch fieldCfg.CounterReset.Strategy {
+	case config.CounterResetStrategyRandom:
+		// 50% chance to reset
+		return state.rand.Intn(2) == 0
+	case config.CounterResetStrategyProbabilistic:
+		// Probability% chance to reset
+		return state.rand.Intn(100) < int(*fieldCfg.CounterReset.Probability)
+	case config.CounterResetStrategyAfterN:
+		// Reset after N
+		return state.counter%*fieldCfg.CounterReset.ResetAfterN == 0
+	}
+
+	return false
+}
+
+func validUnsignedLong(fieldCfg ConfigField) error {
+	if err := fieldCfg.ValidCounter(); err != nil {
+		return err
+	}
+
+	if err := fieldCfg.ValidDistribution(); err != nil {
+		return err
+	}
+
+	if err := fieldCfg.ValidShape(); err != nil {
+		return err
+	}
+
+	if err := fieldCfg.ValidateCounterResetStrategy(); err != nil {
+		return err
+	}
+
+	if err := fieldCfg.ValidateCounterResetAfterN(); err != nil {
+		return err
+	}
+
+	if err := fieldCfg.ValidateCounterResetProbabilistic(); err != nil {
+		return err
+	}
+
+	// check that all the enum values are valid unsigned longs, if any
+	for i, v := range fieldCfg.Enum {
+		_, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return fmt.Errorf("field %s enum value #%d is not an unsigned long: %w", fieldCfg.Name, i, err)
+		}
+	}
+
+	return nil
+}
+
+// makeUnsignedLongFunc returns a function generating the values of `unsigned_long` fields, carried as uint64
+// through enum, counter, shape, range and fuzziness settings
+func makeUnsignedLongFunc(fieldCfg ConfigField, field Field) (func(state *genState) (uint64, error), error) {
+	if err := validUnsignedLong(fieldCfg); err != nil {
+		return nil, err
+	}
+
+	if len(fieldCfg.Enum) > 0 {
+		return func(state *genState) (uint64, error) {
+			idx := state.rand.Intn(len(fieldCfg.Enum))
+			return strconv.ParseUint(fieldCfg.Enum[idx], 10, 64)
+		}, nil
+	}
+
+	if fieldCfg.Counter {
+		return func(state *genState) (uint64, error) {
+			previous := uint64(1)
+			var dummyUint uint64
+
+			if previousDummyUint, ok := state.prevCache[field.Name].(uint64); ok {
+				previous = previousDummyUint
+			}
+
+			if fieldCfg.Fuzziness <= 0 {
+				dummyUint = makeUintCounterFunc(state.rand, previous, field)()
+			} else {
+				dummyUint = fuzzyUintCounter(state.rand, previous, fieldCfg.Fuzziness)
+			}
+
+			if shouldResetCounter(state, fieldCfg) {
+				dummyUint = 0
+			}
+
+			state.prevCache[field.Name] = dummyUint
+			return dummyUint, nil
+		}, nil
+	}
+
+	if fieldCfg.Shape != nil {
+		return makeUintShapeFunc(fieldCfg), nil
+	}
+
+	minValue, _ := fieldCfg.Range.MinAsUint64()
+	maxValue, _ := fieldCfg.Range.MaxAsUint64()
+
+	return func(state *genState) (uint64, error) {
+		dummyFunc, err := makeUintFunc(state.rand, fieldCfg)
+		if err != nil {
+			return 0, err
+		}
+
+		if fieldCfg.Fuzziness <= 0 {
+			return dummyFunc(), nil
+		}
+
+		var dummyUint uint64
+		if previousDummyUint, ok := state.prevCache[field.Name].(uint64); ok {
+			if previousDummyUint == 0 {
+				previousDummyUint = 1
+			}
+			dummyUint = fuzzyUint(state.rand, previousDummyUint, fieldCfg.Fuzziness, minValue, maxValue)
+		} else {
+			dummyUint = dummyFunc()
+		}
+
+		state.prevCache[field.Name] = dummyUint
+		return dummyUint, nil
+	}, nil
+}
+
+func bindUnsignedLong(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	unsignedLongFunc, err := makeUnsignedLongFunc(fieldCfg, field)
+	if err != nil {
+		return err
+	}
+
+	var emitFNotReturn emitFNotReturn
+	emitFNotReturn = func(state *genState, buf *bytes.Buffer) error {
+		dummyUint, err := unsignedLongFunc(state)
+		if err != nil {
+			return err
+		}
+
+		v := make([]byte, 0, 32)
+		v = strconv.AppendUint(v, dummyUint, 10)
+		buf.Write(v)
+		return nil
+	}
+
+	fieldMap[field.Name] = emitFNotReturn
+	return nil
+}
+
+func bindUnsignedLongWithReturn(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	unsignedLongFunc, err := makeUnsignedLongFunc(fieldCfg, field)
+	if err != nil {
+		return err
+	}
+
+	var emitF emitF
+	emitF = func(state *genState) any {
+		dummyUint, err := unsignedLongFunc(state)
+		if err != nil {
+			panic(err)
+		}
+
+		return dummyUint
+	}
+
+	fieldMap[field.Name] = emitF
+	return nil
+}
@@ -0,0 +1,259 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/brianvoe/gofakeit/v7/data"
+	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib/config"
+)
+
+// sparseVectorZipfS is the exponent of the Zipf distribution the tokens of `sparse_vector` fields are drawn from,
+// so that the first tokens of the vocabulary are the most frequent ones, as terms in natural language
+const sparseVectorZipfS = 1.1
+
+// floatVector is a value generated for a `dense_vector` field with `float` element type, printed as a JSON array
+type floatVector []float64
+
+// String formats the components with strconv instead of encoding/json, as the components are always finite
+// numbers and formatting them can't fail
+func (v floatVector) String() string {
+	b := make([]byte, 0, 2+len(v)*10)
+	b = append(b, '[')
+	for i, component := range v {
+		if i > 0 {
+			b = append(b, ',')
+		}
+
+		b = strconv.AppendFloat(b, component, 'f', -1, 64)
+	}
+
+	b = append(b, ']')
+	return string(b)
+}
+
+// byteVector is a value generated for a `dense_vector` field with `byte` or `bit` element type, printed as a JSON array
+type byteVector []int8
+
+func (v byteVector) String() string {
+	b, _ := json.Marshal([]int8(v))
+	return string(b)
+}
+
+// sparseVector is a value generated for a `sparse_vector` field, printed as a JSON object of token weights
+type sparseVector map[string]float64
+
+func (v sparseVector) String() string {
+	b, _ := json.Marshal(map[string]float64(v))
+	return string(b)
+}
+
+// vectorWords are the distinct lowercase words the vocabulary of `sparse_vector` fields is built from
+var vectorWords = sync.OnceValue(func() []string {
+	seen := make(map[string]struct{})
+	for _, words := range data.Word {
+		for _, word := range words {
+			word = strings.ToLower(word)
+			if strings.ContainsAny(word, " .'") {
+				continue
+			}
+
+			seen[word] = struct{}{}
+		}
+	}
+
+	words := make([]string, 0, len(seen))
+	for word := range seen {
+		words = append(words, word)
+	}
+
+	sort.Strings(words)
+
+	return words
+})
+
+// makeVectorVocabulary returns size distinct tokens. Once the words are exhausted, tokens are made
+// of a word and a number, like the word pieces of the vocabulary of a language model
+func makeVectorVocabulary(size int) []string {
+	words := vectorWords()
+
+	vocabulary := make([]string, size)
+	for i := range vocabulary {
+		vocabulary[i] = words[i%len(words)]
+		if i >= len(words) {
+			vocabulary[i] += strconv.Itoa(i / len(words))
+		}
+	}
+
+	return vocabulary
+}
+
+// makeDenseVectorFunc returns a function generating the values of `dense_vector` fields. Components are drawn
+// uniformly in [-1, 1], or around a centroid picked at random when centroids are configured, and are then
+// scaled to the element type
+func makeDenseVectorFunc(vectorCfg config.Vector) (func(r *rand.Rand) any, error) {
+	if err := vectorCfg.Valid(); err != nil {
+		return nil, err
+	}
+
+	dims := vectorCfg.DimsOrDefault()
+	elementType := vectorCfg.ElementTypeOrDefault()
+	spread := vectorCfg.SpreadOrDefault()
+
+	// centroids are generated once, with the first value, so that they are the same for all the events
+	var centroids [][]float64
+
+	return func(r *rand.Rand) any {
+		if vectorCfg.Centroids > 0 && centroids == nil {
+			centroids = make([][]float64, vectorCfg.Centroids)
+			for i := range centroids {
+				centroids[i] = make([]float64, dims)
+				for j := range centroids[i] {
+					centroids[i][j] = 2*r.Float64() - 1
+				}
+			}
+		}
+
+		components := make([]float64, dims)
+		if len(centroids) > 0 {
+			centroid := centroids[r.Intn(len(centroids))]
+			for i := range components {
+				components[i] = centroid[i] + r.NormFloat64()*spread
+			}
+		} else {
+			for i := range components {
+				components[i] = 2*r.Float64() - 1
+			}
+		}
+
+		switch elementType {
+		case config.VectorElementTypeByte:
+			v := make(byteVector, dims)
+			for i, component := range components {
+				v[i] = int8(math.Max(math.Min(math.Round(component*math.MaxInt8), math.MaxInt8), math.MinInt8))
+			}
+
+			return v
+		case config.VectorElementTypeBit:
+			// each byte packs 8 dimensions, set when the component is positive
+			bits := make([]uint8, dims/8)
+			for i, component := range components {
+				if component > 0 {
+					bits[i/8] |= 0x80 >> (i % 8)
+				}
+			}
+
+			v := make(byteVector, len(bits))
+			for i, b := range bits {
+				v[i] = int8(b)
+			}
+
+			return v
+		default:
+			if vectorCfg.Normalize {
+				var norm float64
+				for _, component := range components {
+					norm += component * component
+				}
+
+				// the zero vector has no direction, and is left as it is
+				if norm > 0 {
+					norm = math.Sqrt(norm)
+					for i := range components {
+						components[i] /= norm
+					}
+				}
+			}
+
+			for i := range components {
+				components[i] = math.Round(components[i]*1e6) / 1e6
+			}
+
+			return floatVector(components)
+		}
+	}, nil
+}
+
+// makeSparseVectorFunc returns a function generating the values of `sparse_vector` fields: distinct tokens
+// of the vocabulary, drawn from a Zipf distribution, with positive weights
+func makeSparseVectorFunc(vectorCfg config.Vector) (func(r *rand.Rand) any, error) {
+	if err := vectorCfg.Valid(); err != nil {
+		return nil, err
+	}
+
+	vocabulary := makeVectorVocabulary(vectorCfg.VocabularySizeOrDefault())
+	minTokens, maxTokens := vectorCfg.TokensOrDefault()
+
+	return func(r *rand.Rand) any {
+		tokens := minTokens + r.Intn(maxTokens-minTokens+1)
+		zipf := rand.NewZipf(r, sparseVectorZipfS, 1, uint64(len(vocabulary)-1))
+
+		v := make(sparseVector, tokens)
+		for len(v) < tokens {
+			token := vocabulary[zipf.Uint64()]
+			if _, ok := v[token]; ok {
+				// frequent tokens are drawn again and again, fall back to a uniform pick
+				token = vocabulary[r.Intn(len(vocabulary))]
+			}
+
+			v[token] = math.Round((0.01+r.ExpFloat64())*1e4) / 1e4
+		}
+
+		return v
+	}, nil
+}
+
+func makeVectorFunc(fieldCfg ConfigField, field Field) (func(r *rand.Rand) any, error) {
+	var vectorCfg config.Vector
+	if fieldCfg.Vector != nil {
+		vectorCfg = *fieldCfg.Vector
+	}
+
+	if field.Type == FieldTypeSparseVector {
+		return makeSparseVectorFunc(vectorCfg)
+	}
+
+	return makeDenseVectorFunc(vectorCfg)
+}
+
+func bindVector(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	vectorFunc, err := makeVectorFunc(fieldCfg, field)
+	if err != nil {
+		return err
+	}
+
+	var emitFNotReturn emitFNotReturn
+	emitFNotReturn = func(state *genState, buf *bytes.Buffer) error {
+		_, err := fmt.Fprint(buf, vectorFunc(state.rand))
+		return err
+	}
+
+	fieldMap[field.Name] = emitFNotReturn
+	return nil
+}
+
+func bindVectorWithReturn(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	vectorFunc, err := makeVectorFunc(fieldCfg, field)
+	if err != nil {
+		return err
+	}
+
+	var emitF emitF
+	emitF = func(state *genState) any {
+		return vectorFunc(state.rand)
+	}
+
+	fieldMap[field.Name] = emitF
+	return nil
+}
@@ -0,0 +1,96 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package genlib
+
+import (
+	"bytes"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"github.com/elastic/elastic-integration-corpus-generator-tool/pkg/genlib/config"
+)
+
+// versionPrereleases are the identifiers of the pre-release part of the generated versions
+var versionPrereleases = []string{"alpha", "beta", "rc", "SNAPSHOT"}
+
+// makeVersionFunc returns a function generating the values of `version` fields, picking them from
+// the `enum` or `weighted_enum` of the field when defined, or generating semver strings otherwise
+func makeVersionFunc(fieldCfg ConfigField) (func(r *rand.Rand) string, error) {
+	if err := fieldCfg.ValidVersion(); err != nil {
+		return nil, err
+	}
+
+	if len(fieldCfg.WeightedEnum) > 0 {
+		return makeWeightedEnumFunc(fieldCfg)
+	}
+
+	if len(fieldCfg.Enum) > 0 {
+		return func(r *rand.Rand) string {
+			return fieldCfg.Enum[r.Intn(len(fieldCfg.Enum))]
+		}, nil
+	}
+
+	var versionCfg config.Version
+	if fieldCfg.Version != nil {
+		versionCfg = *fieldCfg.Version
+	}
+
+	minMajor, maxMajor := versionCfg.Major.OrDefault(config.DefaultVersionMaxMajor)
+	minMinor, maxMinor := versionCfg.Minor.OrDefault(config.DefaultVersionMaxMinor)
+	minPatch, maxPatch := versionCfg.Patch.OrDefault(config.DefaultVersionMaxPatch)
+
+	return func(r *rand.Rand) string {
+		var sb strings.Builder
+		sb.WriteString(strconv.Itoa(minMajor + r.Intn(maxMajor-minMajor+1)))
+		sb.WriteByte('.')
+		sb.WriteString(strconv.Itoa(minMinor + r.Intn(maxMinor-minMinor+1)))
+		sb.WriteByte('.')
+		sb.WriteString(strconv.Itoa(minPatch + r.Intn(maxPatch-minPatch+1)))
+
+		if versionCfg.PrereleaseProbability > 0 && r.Float64() < versionCfg.PrereleaseProbability {
+			prerelease := versionPrereleases[r.Intn(len(versionPrereleases))]
+			sb.WriteByte('-')
+			sb.WriteString(prerelease)
+			if prerelease != "SNAPSHOT" {
+				sb.WriteByte('.')
+				sb.WriteString(strconv.Itoa(1 + r.Intn(5)))
+			}
+		}
+
+		return sb.String()
+	}, nil
+}
+
+func bindVersion(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	versionFunc, err := makeVersionFunc(fieldCfg)
+	if err != nil {
+		return err
+	}
+
+	var emitFNotReturn emitFNotReturn
+	emitFNotReturn = func(state *genState, buf *bytes.Buffer) error {
+		buf.WriteString(versionFunc(state.rand))
+		return nil
+	}
+
+	fieldMap[field.Name] = emitFNotReturn
+	return nil
+}
+
+func bindVersionWithReturn(fieldCfg ConfigField, field Field, fieldMap map[string]any) error {
+	versionFunc, err := makeVersionFunc(fieldCfg)
+	if err != nil {
+		return err
+	}
+
+	var emitF emitF
+	emitF = func(state *genState) any {
+		return versionFunc(state.rand)
+	}
+
+	fieldMap[field.Name] = emitF
+	return nil
+}